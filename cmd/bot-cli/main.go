@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"command-bot/internal/bot/quote"
+	"command-bot/internal/bot/registry"
+	"command-bot/internal/config"
+	"command-bot/internal/logging"
+	"command-bot/internal/repl"
+)
+
+func main() {
+	cfg, flags, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if flags.PrintConfig {
+		if err := config.Dump(os.Stdout, cfg); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
+	}
+
+	quoteStore, err := quote.NewFileStore(cfg.Commands.Quote.File, quote.DefaultQuotes())
+	if err != nil {
+		log.Fatalf("Failed to open quote store: %v", err)
+	}
+
+	bot, err := registry.Build(registry.OptionsFromConfig(cfg, quoteStore))
+	if err != nil {
+		log.Fatalf("Failed to build command registry: %v", err)
+	}
+	fmt.Printf("Command Bot started. Registered commands: %s\n", strings.Join(bot.Names, ", "))
+	fmt.Printf("Type '%shelp' for available commands. Type '%s' to quit.\n", cfg.Bot.Prefix, repl.ExitCommand)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	session := repl.New(os.Stdin, os.Stdout, repl.Options{UserID: cfg.CLI.UserID, ChatID: cfg.CLI.ChatID})
+
+	// Рассылка цитаты дня работает, только если команда quote включена и задано время.
+	// Сбои рассылки пишутся в stderr, чтобы не смешиваться с ответами бота.
+	if bot.Quote != nil && cfg.Commands.Quote.DailyTime != "" {
+		logger, err := logging.New(os.Stderr, cfg.Log.Format, new(slog.LevelVar))
+		if err != nil {
+			log.Fatalf("Failed to set up logging: %v", err)
+		}
+
+		scheduler, err := quote.NewScheduler(quoteStore, bot.Quote.DailyMessage, quote.PostTo(session),
+			cfg.Commands.Quote.DailyTime, cfg.Commands.Quote.Location(), logger)
+		if err != nil {
+			log.Fatalf("Failed to create daily quote scheduler: %v", err)
+		}
+		go scheduler.Run(ctx)
+	}
+
+	if err := session.Receive(ctx, bot.Handler); err != nil {
+		log.Fatalf("Failed to read input: %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"command-bot/internal/certs"
+	"command-bot/internal/config"
+	"command-bot/internal/health"
+	"command-bot/internal/logging"
+	"command-bot/internal/metrics"
+	"command-bot/internal/server"
+	"command-bot/internal/tracing"
+	"command-bot/pkg/transport"
+)
+
+func main() {
+	cfg, flags, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if flags.PrintConfig {
+		if err := config.Dump(os.Stdout, cfg); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
+	}
+
+	// По умолчанию журнал пишется в stdout, откуда его забирает journald.
+	// Если файл журнала недоступен, например у пользователя сервиса нет прав на /var/log,
+	// записи идут в stderr, пока файл не удастся открыть.
+	var out io.Writer = os.Stdout
+	var logFile *logging.RotatingFile
+	var logFileErr error
+	if cfg.Log.File != "" {
+		logFile, logFileErr = logging.OpenRotatingFile(cfg.Log.File, cfg.Log.RotateOptions(), os.Stderr)
+		defer logFile.Close()
+		out = logFile
+	}
+
+	level := new(slog.LevelVar)
+	logger, err := logging.New(out, cfg.Log.Format, level)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	// Записи пакета log, например планировщика цитат, тоже попадают в структурированный журнал
+	slog.SetDefault(logger)
+
+	logger.Info("Command Bot service starting...")
+
+	if logFileErr != nil {
+		logger.Warn("Log file is not available, logging to stderr", "file", cfg.Log.File, logging.KeyError, logFileErr)
+	}
+
+	if flags.ConfigFile != "" {
+		logger.Info("Loaded configuration", "file", flags.ConfigFile)
+	}
+
+	tracer, closeTracer, err := newTracer(cfg.Tracing)
+	if err != nil {
+		fatal(logger, "Failed to set up tracing", err)
+	}
+	defer closeTracer()
+
+	srv, err := server.New(cfg, server.Options{
+		// При перезагрузке конфигурация читается заново из тех же источников
+		Load: func() (config.Config, error) {
+			cfg, _, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv)
+			return cfg, err
+		},
+		Logger:  logger,
+		Level:   level,
+		Metrics: metrics.New(),
+		Tracer:  tracer,
+	})
+	if err != nil {
+		fatal(logger, "Failed to start service", err)
+	}
+
+	logger.Info("Command Bot service started", "commands", srv.Runtime().Bot.Names)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := srv.Start(ctx); err != nil {
+		fatal(logger, "Failed to start daily quote scheduler", err)
+	}
+
+	// Сертификаты перечитываются при изменении файлов, например после продления
+	var certManager *certs.Manager
+	if cfg.Server.TLS.Enabled() {
+		opts := cfg.Server.TLS.Options()
+		opts.Logger = logger
+		certManager, err = certs.NewManager(opts)
+		if err != nil {
+			fatal(logger, "Failed to load TLS certificates", err)
+		}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1)
+
+	go func() {
+		for sig := range sigCh {
+			switch sig {
+			case syscall.SIGHUP:
+				// Перечитываем конфигурацию; ошибка оставляет прежнюю в силе
+				logger.Info("Received SIGHUP, reloading configuration...")
+				srv.Reload()
+				if certManager != nil {
+					if err := certManager.Reload(); err != nil {
+						logger.Error("Failed to reload TLS certificates", logging.KeyError, err)
+					}
+				}
+				continue
+			case syscall.SIGUSR1:
+				// Внешний logrotate переименовал файл журнала, открываем новый
+				if logFile != nil {
+					if err := logFile.Reopen(); err == nil {
+						logger.Info("Log file reopened", "file", cfg.Log.File)
+					}
+				}
+				continue
+			}
+
+			// Повторный сигнал останавливает сервис, не дожидаясь команд
+			if ctx.Err() != nil {
+				logger.Warn("Received second signal, exiting immediately", "signal", sig.String())
+				os.Exit(1)
+			}
+
+			logger.Info("Received signal, shutting down...", "signal", sig.String())
+			cancel()
+		}
+	}()
+
+	httpServer := &http.Server{
+		Addr:     cfg.Server.Listen,
+		Handler:  srv.Handler(),
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	// Транспорт готов, когда сервер слушает порт
+	httpReady := health.NewFlag(errors.New("not listening yet"), true)
+	srv.RegisterHealthCheck("transport.http", httpReady.Check)
+
+	go func() {
+		logger.Info("Starting HTTP server", "listen", cfg.Server.Listen, "tls", certManager != nil,
+			"client_auth", cfg.Server.TLS.ClientAuth)
+		ln, err := net.Listen("tcp", cfg.Server.Listen)
+		if err != nil {
+			fatal(logger, "HTTP server error", err)
+		}
+		if certManager != nil {
+			ln = tls.NewListener(ln, certManager.TLSConfig())
+		}
+		httpReady.Set(nil)
+
+		if err := httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			httpReady.Set(err)
+			fatal(logger, "HTTP server error", err)
+		}
+	}()
+
+	// gRPC API использует те же сертификаты, что и HTTP
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPC.Listen != "" {
+		var opts []grpc.ServerOption
+		if certManager != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(certManager.TLSConfig())))
+		}
+		grpcServer = srv.GRPCServer(opts...)
+
+		grpcReady := health.NewFlag(errors.New("not listening yet"), true)
+		srv.RegisterHealthCheck("transport.grpc", grpcReady.Check)
+
+		go func() {
+			logger.Info("Starting gRPC server", "listen", cfg.Server.GRPC.Listen, "tls", certManager != nil,
+				"reflection", cfg.Server.GRPC.Reflection)
+			ln, err := net.Listen("tcp", cfg.Server.GRPC.Listen)
+			if err != nil {
+				fatal(logger, "gRPC server error", err)
+			}
+			grpcReady.Set(nil)
+
+			if err := grpcServer.Serve(ln); err != nil {
+				grpcReady.Set(err)
+				fatal(logger, "gRPC server error", err)
+			}
+		}()
+	}
+
+	// Сервис работает как транспорт, пока не отменен ctx. Команды всех API
+	// выполняет обработчик текущей конфигурации, в том числе после перезагрузки.
+	srv.Receive(ctx, transport.HandlerFunc(func(ctx context.Context, msg transport.Message) (string, error) {
+		return srv.Runtime().Bot.Handler.HandleMessage(ctx, msg)
+	}))
+	srv.Stop(httpServer, grpcServer)
+	logger.Info("Command Bot service stopped")
+}
+
+// fatal записывает ошибку в журнал и завершает процесс
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, logging.KeyError, err)
+	os.Exit(1)
+}
+
+// newTracer создает трассировщик по конфигурации; при экспортере "none" возвращает nil
+func newTracer(cfg config.TracingConfig) (*tracing.Tracer, func(), error) {
+	switch cfg.Exporter {
+	case "stdout":
+		return tracing.NewTracer(tracing.NewWriterExporter(os.Stdout)), func() {}, nil
+	case "file":
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, logging.FileMode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		return tracing.NewTracer(tracing.NewWriterExporter(f)), func() { f.Close() }, nil
+	default:
+		return nil, func() {}, nil
+	}
+}
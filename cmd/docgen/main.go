@@ -0,0 +1,39 @@
+// Команда docgen генерирует справочник команд бота из реестра команд,
+// чтобы документация не расходилась с кодом.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"command-bot/internal/bot/docs"
+	"command-bot/internal/bot/registry"
+)
+
+func main() {
+	format := flag.String("format", "markdown", "output format: markdown, html or man")
+	output := flag.String("o", "", "output file (default: stdout)")
+	prefix := flag.String("prefix", "/", "command prefix used in the reference")
+	flag.Parse()
+
+	// Реестр собирается так же, как в bot-cli и bot-service, но со всеми командами
+	bot, err := registry.Build(registry.Options{Prefix: *prefix})
+	if err != nil {
+		log.Fatalf("Failed to build command registry: %v", err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Failed to create output file: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := docs.Write(out, docs.Build(bot.Handler, *prefix), *format); err != nil {
+		log.Fatalf("Failed to write reference: %v", err)
+	}
+}
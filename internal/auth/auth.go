@@ -0,0 +1,219 @@
+// Пакет auth проверяет учетные данные клиентов HTTP API: API-ключи, клиентские
+// сертификаты mTLS и подписанные HMAC токены JWT, которые проверяются без
+// обращения к внешним сервисам.
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// APIKeyHeader — заголовок с API-ключом
+const APIKeyHeader = "X-API-Key"
+
+// Способы аутентификации
+const (
+	MethodAPIKey     = "api_key"
+	MethodJWT        = "jwt"
+	MethodClientCert = "client_cert"
+)
+
+var (
+	// ErrNoCredentials возвращается, если запрос не содержит учетных данных
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials возвращается, если учетные данные не прошли проверку
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Identity — проверенная личность клиента
+type Identity struct {
+	UserID string
+	// Chats — чаты, в которые клиенту разрешено отправлять команды; пустой список — любые
+	Chats []string
+	// Method — способ, которым клиент подтвердил личность
+	Method string
+}
+
+// CanUseChat сообщает, может ли клиент отправлять команды в чат chatID
+func (id Identity) CanUseChat(chatID string) bool {
+	return len(id.Chats) == 0 || slices.Contains(id.Chats, chatID)
+}
+
+// DefaultChat возвращает чат для запроса без chat_id: fallback, если он разрешен,
+// иначе первый разрешенный чат
+func (id Identity) DefaultChat(fallback string) string {
+	if id.CanUseChat(fallback) {
+		return fallback
+	}
+	return id.Chats[0]
+}
+
+// APIKey — API-ключ клиента
+type APIKey struct {
+	// SHA256 — SHA-256 ключа в шестнадцатеричном виде, сам ключ не хранится
+	SHA256 string
+	UserID string
+	Chats  []string
+}
+
+// ClientCert — пользователь, которому соответствует клиентский сертификат
+type ClientCert struct {
+	// Subject — CommonName или одно из имен SAN (DNS, email, URI) сертификата
+	Subject string
+	UserID  string
+	Chats   []string
+}
+
+// JWTOptions — параметры проверки токенов JWT
+type JWTOptions struct {
+	// Secret — ключ HMAC-SHA256; пустой ключ отключает токены
+	Secret []byte
+	// Issuer и Audience, если заданы, должны совпадать с claims iss и aud
+	Issuer   string
+	Audience string
+	// Leeway — допустимое расхождение часов при проверке exp и nbf
+	Leeway time.Duration
+}
+
+// Options задает способы аутентификации
+type Options struct {
+	APIKeys []APIKey
+	JWT     JWTOptions
+	// ClientCerts проверяются, только если сервис принимает соединения по mTLS
+	ClientCerts []ClientCert
+}
+
+// Authenticator проверяет учетные данные запросов.
+// Нулевой указатель означает, что аутентификация отключена.
+type Authenticator struct {
+	keys  map[[sha256.Size]byte]APIKey
+	certs map[string]ClientCert
+	jwt   JWTOptions
+	now   func() time.Time
+}
+
+// NewAuthenticator создает проверку учетных данных. Если не задан ни один
+// API-ключ, клиентский сертификат и секрет JWT, возвращает nil: аутентификация отключена.
+func NewAuthenticator(opts Options) (*Authenticator, error) {
+	return NewAuthenticatorWithClock(opts, time.Now)
+}
+
+// NewAuthenticatorWithClock создает проверку учетных данных с заданными часами
+func NewAuthenticatorWithClock(opts Options, now func() time.Time) (*Authenticator, error) {
+	if len(opts.APIKeys) == 0 && len(opts.ClientCerts) == 0 && len(opts.JWT.Secret) == 0 {
+		return nil, nil
+	}
+
+	a := &Authenticator{
+		keys:  make(map[[sha256.Size]byte]APIKey, len(opts.APIKeys)),
+		certs: make(map[string]ClientCert, len(opts.ClientCerts)),
+		jwt:   opts.JWT,
+		now:   now,
+	}
+	for _, cert := range opts.ClientCerts {
+		a.certs[cert.Subject] = cert
+	}
+	for _, key := range opts.APIKeys {
+		sum, err := decodeSHA256(key.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API key hash for user %s: %w", key.UserID, err)
+		}
+		if _, ok := a.keys[sum]; ok {
+			return nil, fmt.Errorf("duplicate API key for user %s", key.UserID)
+		}
+		a.keys[sum] = key
+	}
+
+	return a, nil
+}
+
+// HashAPIKey возвращает SHA-256 ключа в виде, в котором он указывается в конфигурации
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Enabled сообщает, требуется ли аутентификация
+func (a *Authenticator) Enabled() bool {
+	return a != nil
+}
+
+// Authenticate проверяет API-ключ из заголовка X-API-Key, токен из
+// заголовка "Authorization: Bearer <token>" или, если заголовков нет,
+// клиентский сертификат, проверенный при установке соединения TLS
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	if a == nil {
+		return Identity{}, ErrNoCredentials
+	}
+
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		// Поиск по хешу не раскрывает ключ через время ответа
+		apiKey, ok := a.keys[sha256.Sum256([]byte(key))]
+		if !ok {
+			return Identity{}, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+		}
+		return Identity{UserID: apiKey.UserID, Chats: apiKey.Chats, Method: MethodAPIKey}, nil
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return a.authenticateCert(r)
+	}
+	if len(a.jwt.Secret) == 0 {
+		return Identity{}, fmt.Errorf("%w: bearer tokens are not accepted", ErrInvalidCredentials)
+	}
+
+	claims, err := VerifyJWT(token, a.jwt, a.now())
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	return Identity{UserID: claims.Subject, Chats: claims.Chats, Method: MethodJWT}, nil
+}
+
+// authenticateCert сопоставляет проверенный клиентский сертификат пользователю
+func (a *Authenticator) authenticateCert(r *http.Request) (Identity, error) {
+	// VerifiedChains пуст, если клиент не предъявил сертификат или сервер его не проверял
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return Identity{}, ErrNoCredentials
+	}
+
+	leaf := r.TLS.VerifiedChains[0][0]
+	for _, name := range certNames(leaf) {
+		if cert, ok := a.certs[name]; ok {
+			return Identity{UserID: cert.UserID, Chats: cert.Chats, Method: MethodClientCert}, nil
+		}
+	}
+
+	return Identity{}, fmt.Errorf("%w: client certificate %q is not mapped to a user", ErrInvalidCredentials, leaf.Subject.CommonName)
+}
+
+// certNames возвращает имена сертификата: CommonName и имена SAN
+func certNames(cert *x509.Certificate) []string {
+	names := []string{cert.Subject.CommonName}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+// decodeSHA256 разбирает хеш SHA-256 в шестнадцатеричном виде
+func decodeSHA256(s string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	if len(s) != hex.EncodedLen(sha256.Size) {
+		return sum, fmt.Errorf("expected %d hex characters, got %d", hex.EncodedLen(sha256.Size), len(s))
+	}
+	if _, err := hex.Decode(sum[:], []byte(s)); err != nil {
+		return sum, fmt.Errorf("malformed hex: %w", err)
+	}
+	return sum, nil
+}
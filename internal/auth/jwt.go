@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Claims — поля токена JWT, которые понимает сервис
+type Claims struct {
+	// Subject — идентификатор пользователя
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	// Chats — чаты, в которые разрешено отправлять команды; пустой список — любые
+	Chats []string `json:"chats,omitempty"`
+}
+
+// Audience — claim aud, который может быть строкой или списком строк
+type Audience []string
+
+// UnmarshalJSON разбирает aud в любой из двух форм
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("aud must be a string or a list of strings")
+	}
+	*a = list
+	return nil
+}
+
+// MarshalJSON записывает единственное значение строкой
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// jwtHeader — заголовок токена
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// SignJWT подписывает claims алгоритмом HS256, например для выдачи токенов клиентам
+func SignJWT(claims Claims, secret []byte) (string, error) {
+	header, err := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode claims: %w", err)
+	}
+
+	signingInput := encoding.EncodeToString(header) + "." + encoding.EncodeToString(payload)
+	return signingInput + "." + encoding.EncodeToString(sign(signingInput, secret)), nil
+}
+
+// VerifyJWT проверяет подпись HS256 и сроки действия токена на момент now.
+// Токен без exp или sub отклоняется.
+func VerifyJWT(token string, opts JWTOptions, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, fmt.Errorf("malformed token header: %w", err)
+	}
+	// Алгоритм фиксирован, чтобы токен с "alg": "none" не прошел проверку
+	if header.Alg != "HS256" {
+		return Claims{}, fmt.Errorf("unsupported signing algorithm %q", header.Alg)
+	}
+
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, errors.New("malformed token signature")
+	}
+	if !hmac.Equal(signature, sign(parts[0]+"."+parts[1], opts.Secret)) {
+		return Claims{}, errors.New("invalid token signature")
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, fmt.Errorf("malformed token claims: %w", err)
+	}
+
+	switch {
+	case claims.Subject == "":
+		return Claims{}, errors.New("token has no subject")
+	case claims.ExpiresAt == 0:
+		return Claims{}, errors.New("token has no expiration time")
+	case now.After(time.Unix(claims.ExpiresAt, 0).Add(opts.Leeway)):
+		return Claims{}, errors.New("token has expired")
+	case claims.NotBefore != 0 && now.Before(time.Unix(claims.NotBefore, 0).Add(-opts.Leeway)):
+		return Claims{}, errors.New("token is not valid yet")
+	case opts.Issuer != "" && claims.Issuer != opts.Issuer:
+		return Claims{}, fmt.Errorf("unexpected token issuer %q", claims.Issuer)
+	case opts.Audience != "" && !slices.Contains(claims.Audience, opts.Audience):
+		return Claims{}, errors.New("token is not intended for this service")
+	}
+
+	return claims, nil
+}
+
+func sign(signingInput string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := encoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
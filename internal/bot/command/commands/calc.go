@@ -43,16 +43,41 @@ func (c *CalcCommand) RequiredPermissions() []string {
 	return []string{} // Специальные разрешения не требуются
 }
 
+// Category возвращает категорию команды в справке
+func (c *CalcCommand) Category() string {
+	return "Fun"
+}
+
+// Examples возвращает примеры использования команды
+func (c *CalcCommand) Examples() []command.Example {
+	return []command.Example{
+		{Input: "calc 5 + 3", Description: "Calculates 5 + 3 = 8"},
+		{Input: "calc 10 - 4", Description: "Calculates 10 - 4 = 6"},
+		{Input: "calc 7 * 2", Description: "Calculates 7 * 2 = 14"},
+		{Input: "calc 20 / 5", Description: "Calculates 20 / 5 = 4"},
+	}
+}
+
+// Arguments описывает аргументы команды
+func (c *CalcCommand) Arguments() []command.Argument {
+	return []command.Argument{
+		{Name: "a", Type: command.ArgumentNumber, Description: "First operand", Required: true},
+		{Name: "operation", Type: command.ArgumentString, Description: "Operation to apply", Required: true,
+			Enum: []string{"add", "+", "subtract", "sub", "-", "multiply", "mul", "*", "x", "divide", "div", "/"}},
+		{Name: "b", Type: command.ArgumentNumber, Description: "Second operand", Required: true},
+	}
+}
+
 // Execute выполняет команду с заданным контекстом и возвращает ответ
 func (c *CalcCommand) Execute(ctx context.Context, cmdCtx command.CommandContext) (string, error) {
 	if len(cmdCtx.Arguments) != 3 {
-		return "", fmt.Errorf("invalid format: expected 3 arguments (number operation number), got %d", len(cmdCtx.Arguments))
+		return "", fmt.Errorf("invalid format: expected 3 arguments (number operation number), got %d: %w", len(cmdCtx.Arguments), command.ErrInvalidArguments)
 	}
 
 	// Парсим первое число
 	num1, err := strconv.ParseFloat(cmdCtx.Arguments[0], 64)
 	if err != nil {
-		return "", fmt.Errorf("invalid first number: %w", err)
+		return "", fmt.Errorf("invalid first number %q: %w", cmdCtx.Arguments[0], command.ErrInvalidArguments)
 	}
 
 	// Получаем операцию
@@ -61,7 +86,7 @@ func (c *CalcCommand) Execute(ctx context.Context, cmdCtx command.CommandContext
 	// Парсим второе число
 	num2, err := strconv.ParseFloat(cmdCtx.Arguments[2], 64)
 	if err != nil {
-		return "", fmt.Errorf("invalid second number: %w", err)
+		return "", fmt.Errorf("invalid second number %q: %w", cmdCtx.Arguments[2], command.ErrInvalidArguments)
 	}
 
 	var result float64
@@ -80,12 +105,12 @@ func (c *CalcCommand) Execute(ctx context.Context, cmdCtx command.CommandContext
 		operationSymbol = "×"
 	case "divide", "div", "/":
 		if num2 == 0 {
-			return "", fmt.Errorf("division by zero is not allowed")
+			return "", fmt.Errorf("division by zero is not allowed: %w", command.ErrInvalidArguments)
 		}
 		result = num1 / num2
 		operationSymbol = "÷"
 	default:
-		return "", fmt.Errorf("unknown operation: %s (supported: add/+, subtract/-, multiply/*, divide/)", operation)
+		return "", fmt.Errorf("unknown operation: %s (supported: add/+, subtract/-, multiply/*, divide/): %w", operation, command.ErrInvalidArguments)
 	}
 
 	// Форматируем результат, убирая десятичные нули, если результат целый
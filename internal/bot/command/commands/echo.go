@@ -3,15 +3,20 @@ package commands
 import (
 	"context"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"command-bot/pkg/command"
 )
 
-type EchoCommand struct{}
+type EchoCommand struct {
+	now func() time.Time
+}
 
 // NewEchoCommand создает новую команду echo
 func NewEchoCommand() *EchoCommand {
-	return &EchoCommand{}
+	return &EchoCommand{now: time.Now}
 }
 
 func (c *EchoCommand) Name() string {
@@ -23,21 +28,118 @@ func (c *EchoCommand) Aliases() []string {
 }
 
 func (c *EchoCommand) Description() string {
-	return "Repeats the text you provide"
+	return "Repeats the text you provide, filling in placeholders like {user} and {time}"
 }
 
 func (c *EchoCommand) Usage() string {
-	return "echo <text>"
+	return "echo [--raw] [--code] [--literal] <text with {user}, {chat}, {args}, {argN}, {time}, {date}, {value|upper|lower|title|trim|repeat:N}>"
 }
 
 func (c *EchoCommand) RequiredPermissions() []string {
 	return []string{}
 }
 
+func (c *EchoCommand) Category() string {
+	return "Utility"
+}
+
+func (c *EchoCommand) Examples() []command.Example {
+	return []command.Example{
+		{Input: "echo Hello World", Description: "Bot responds with 'Hello World'"},
+		{Input: "echo Hello {user}, it's {time}", Description: "Fills in your user ID and the current time"},
+		{Input: "echo {user|upper|repeat:2}", Description: "Applies functions to placeholders"},
+		{Input: "echo --literal {user}", Description: "Repeats the text without filling in placeholders"},
+		{Input: "echo --raw --code a   b", Description: "Keeps original spacing and sends a code block"},
+	}
+}
+
+func (c *EchoCommand) Arguments() []command.Argument {
+	return []command.Argument{
+		{Name: "text", Type: command.ArgumentString, Description: "Text to repeat, with placeholders, optionally preceded by --raw, --code and --literal", Variadic: true},
+	}
+}
+
 func (c *EchoCommand) Execute(ctx context.Context, cmdCtx command.CommandContext) (string, error) {
-	if len(cmdCtx.Arguments) == 0 {
+	// Опции идут перед текстом: --raw сохраняет исходные пробелы, --code оформляет блок кода,
+	// --literal отключает подстановку значений вместо заполнителей
+	var raw, code, literal bool
+	args := cmdCtx.Arguments
+	consumed := 0
+
+options:
+	for _, arg := range args {
+		switch arg {
+		case "--raw":
+			raw = true
+		case "--code":
+			code = true
+		case "--literal":
+			literal = true
+		default:
+			break options
+		}
+		consumed++
+	}
+	args = args[consumed:]
+
+	if len(args) == 0 {
 		return "You didn't provide any text to echo!", nil
 	}
 
-	return strings.Join(cmdCtx.Arguments, " "), nil
+	text := strings.Join(args, " ")
+	if raw {
+		// Пропускаем имя команды и опции, оставляя остаток ввода без изменений
+		text = skipFields(cmdCtx.RawInput, consumed+1)
+	}
+
+	result := text
+	if !literal {
+		var err error
+		if result, err = renderTemplate(text, cmdCtx, args, c.now()); err != nil {
+			return "", err
+		}
+	}
+
+	if code {
+		result = codeBlock(result)
+	}
+
+	return result, nil
+}
+
+// codeBlock оформляет текст блоком кода. Ограждение длиннее самой длинной
+// последовательности обратных кавычек в тексте, чтобы текст не закрыл блок раньше времени.
+func codeBlock(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + "\n" + text + "\n" + fence
+}
+
+// skipFields отбрасывает из строки первые n слов вместе с одним разделителем после них
+func skipFields(s string, n int) string {
+	for i := 0; i < n; i++ {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		s = s[end:]
+	}
+
+	// Убираем только один разделитель, чтобы сохранить значимые отступы текста
+	if s != "" {
+		_, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+	}
+
+	return s
 }
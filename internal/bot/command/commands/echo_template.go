@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"command-bot/pkg/command"
+)
+
+const (
+	// maxTemplateRepeat ограничивает число повторов в функции repeat
+	maxTemplateRepeat = 10
+	// maxTemplateOutput ограничивает длину результата шаблона в символах
+	maxTemplateOutput = 2000
+)
+
+// templateFuncs — безопасные функции, которые можно применить к значению через "|"
+var templateFuncs = map[string]func(value, arg string) (string, error){
+	"upper": func(value, _ string) (string, error) {
+		return strings.ToUpper(value), nil
+	},
+	"lower": func(value, _ string) (string, error) {
+		return strings.ToLower(value), nil
+	},
+	"title": func(value, _ string) (string, error) {
+		words := strings.Fields(value)
+		for i, w := range words {
+			runes := []rune(strings.ToLower(w))
+			runes[0] = []rune(strings.ToUpper(string(runes[0])))[0]
+			words[i] = string(runes)
+		}
+		return strings.Join(words, " "), nil
+	},
+	"trim": func(value, _ string) (string, error) {
+		return strings.TrimSpace(value), nil
+	},
+	"repeat": func(value, arg string) (string, error) {
+		n := 2
+		if arg != "" {
+			var err error
+			if n, err = strconv.Atoi(arg); err != nil {
+				return "", fmt.Errorf("repeat expects a number, got %q", arg)
+			}
+		}
+		if n < 1 || n > maxTemplateRepeat {
+			return "", fmt.Errorf("repeat count must be between 1 and %d", maxTemplateRepeat)
+		}
+		if len([]rune(value))*n > maxTemplateOutput {
+			return "", fmt.Errorf("repeated text would exceed %d characters", maxTemplateOutput)
+		}
+		return strings.Repeat(value, n), nil
+	},
+}
+
+// renderTemplate подставляет в текст значения плейсхолдеров вида {name} или {name|func:arg}.
+// Неизвестные плейсхолдеры остаются как есть, "{{" и "}}" выводят одиночные скобки.
+func renderTemplate(text string, cmdCtx command.CommandContext, args []string, now time.Time) (string, error) {
+	var sb strings.Builder
+
+	for i := 0; i < len(text); {
+		switch {
+		case strings.HasPrefix(text[i:], "{{"):
+			sb.WriteByte('{')
+			i += 2
+			continue
+		case strings.HasPrefix(text[i:], "}}"):
+			sb.WriteByte('}')
+			i += 2
+			continue
+		case text[i] != '{':
+			sb.WriteByte(text[i])
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(text[i:], '}')
+		if end < 0 {
+			sb.WriteString(text[i:])
+			break
+		}
+
+		placeholder := text[i : i+end+1]
+		value, ok, err := evalPlaceholder(placeholder[1:len(placeholder)-1], cmdCtx, args, now)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w: %w", placeholder, err, command.ErrInvalidArguments)
+		}
+		if !ok {
+			value = placeholder
+		}
+
+		sb.WriteString(value)
+		i += end + 1
+
+		if sb.Len() > maxTemplateOutput {
+			return "", fmt.Errorf("output exceeds %d characters: %w", maxTemplateOutput, command.ErrInvalidArguments)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// evalPlaceholder вычисляет выражение плейсхолдера; ok=false означает неизвестное имя
+func evalPlaceholder(expr string, cmdCtx command.CommandContext, args []string, now time.Time) (string, bool, error) {
+	parts := strings.Split(expr, "|")
+
+	value, ok := placeholderValue(strings.TrimSpace(parts[0]), cmdCtx, args, now)
+	if !ok {
+		return "", false, nil
+	}
+
+	for _, call := range parts[1:] {
+		name, arg, _ := strings.Cut(strings.TrimSpace(call), ":")
+
+		fn, exists := templateFuncs[strings.ToLower(name)]
+		if !exists {
+			return "", false, fmt.Errorf("unknown function %q", name)
+		}
+
+		var err error
+		if value, err = fn(value, arg); err != nil {
+			return "", false, err
+		}
+	}
+
+	return value, true, nil
+}
+
+// placeholderValue возвращает значение именованного плейсхолдера из контекста команды
+func placeholderValue(name string, cmdCtx command.CommandContext, args []string, now time.Time) (string, bool) {
+	switch strings.ToLower(name) {
+	case "user":
+		return cmdCtx.UserID, true
+	case "chat":
+		return cmdCtx.ChatID, true
+	case "args":
+		return strings.Join(args, " "), true
+	case "argc":
+		return strconv.Itoa(len(args)), true
+	case "time":
+		return now.Format("15:04:05"), true
+	case "date":
+		return now.Format("2006-01-02"), true
+	}
+
+	// {arg1}, {arg2}, ... — отдельные аргументы, начиная с единицы
+	if n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(name), "arg")); err == nil && strings.HasPrefix(strings.ToLower(name), "arg") {
+		if n >= 1 && n <= len(args) {
+			return args[n-1], true
+		}
+		return "", true
+	}
+
+	return "", false
+}
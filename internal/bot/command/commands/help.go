@@ -3,33 +3,58 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"sort"
+	"strconv"
 	"strings"
 
 	"command-bot/pkg/command"
 )
 
+// helpPageSize задает количество команд на одной странице списка
+const helpPageSize = 10
+
+// Форматы вывода справки
+const (
+	helpFormatText     = "text"
+	helpFormatJSON     = "json"
+	helpFormatMarkdown = "markdown"
+)
+
 // HelpCommand предоставляет информацию о доступных командах
 type HelpCommand struct {
 	handler command.CommandHandler
 
-	// Категории команд для организации вывода справки
-	categories map[string][]string
+	// Префикс команд для вывода примеров
+	prefix string
+}
+
+// helpOptions содержит разобранные опции команды help
+type helpOptions struct {
+	format string
+	page   int
+	args   []string
+}
+
+// helpPage описывает страницу списка команд в машиночитаемом виде
+type helpPage struct {
+	Query    string         `json:"query,omitempty"`
+	Page     int            `json:"page"`
+	Pages    int            `json:"pages"`
+	Total    int            `json:"total"`
+	Commands []command.Info `json:"commands"`
 }
 
 // NewHelpCommand создает новую команду help
 func NewHelpCommand(handler command.CommandHandler) *HelpCommand {
-	// Определяем категории команд
-	categories := map[string][]string{
-		"Utility":     {"help", "echo"},
-		"Information": {"ping", "time", "weather"},
-		"Fun":         {"random", "quote", "calc"},
+	prefix := "/"
+	if p, ok := handler.(interface{ Prefix() string }); ok {
+		prefix = p.Prefix()
 	}
 
 	return &HelpCommand{
-		handler:    handler,
-		categories: categories,
+		handler: handler,
+		prefix:  prefix,
 	}
 }
 
@@ -50,7 +75,7 @@ func (c *HelpCommand) Description() string {
 
 // Usage возвращает строку, показывающую, как использовать команду
 func (c *HelpCommand) Usage() string {
-	return "help [command]"
+	return "help [command | search <term>] [--page N] [--format text|json|markdown]"
 }
 
 // RequiredPermissions возвращает список разрешений, необходимых для выполнения этой команды
@@ -58,21 +83,180 @@ func (c *HelpCommand) RequiredPermissions() []string {
 	return []string{} // Специальные разрешения не требуются
 }
 
+// Category возвращает категорию команды в справке
+func (c *HelpCommand) Category() string {
+	return "Utility"
+}
+
+// Examples возвращает примеры использования команды
+func (c *HelpCommand) Examples() []command.Example {
+	return []command.Example{
+		{Input: "help", Description: "Shows list of all available commands"},
+		{Input: "help ping", Description: "Shows detailed help for the ping command"},
+		{Input: "help search time", Description: "Finds commands mentioning 'time' in name, aliases or description"},
+		{Input: "help --page 2", Description: "Shows the second page of the command list"},
+		{Input: "help --format json", Description: "Returns the command catalog as JSON"},
+	}
+}
+
+// Arguments описывает аргументы команды
+func (c *HelpCommand) Arguments() []command.Argument {
+	return []command.Argument{
+		{Name: "query", Type: command.ArgumentString, Description: "Command name or search <term>, optionally with --page N and --format text|json|markdown", Variadic: true},
+	}
+}
+
 // Execute выполняет команду с заданным контекстом и возвращает ответ
 func (c *HelpCommand) Execute(ctx context.Context, cmdCtx command.CommandContext) (string, error) {
+	opts, err := parseHelpOptions(cmdCtx.Arguments)
+	if err != nil {
+		return "", err
+	}
+
+	// Поиск по имени, псевдонимам и описанию
+	if len(opts.args) > 0 && strings.ToLower(opts.args[0]) == "search" {
+		term := strings.Join(opts.args[1:], " ")
+		if strings.TrimSpace(term) == "" {
+			return "", command.NewUsageError("help search <term>", "search term is empty")
+		}
+
+		var found []command.Command
+		for _, cmd := range c.visibleCommands(cmdCtx) {
+			if command.Matches(cmd, term) {
+				found = append(found, cmd)
+			}
+		}
+
+		return c.renderList(found, term, opts)
+	}
+
 	// Если запрошена конкретная команда, показываем справку для этой команды
-	if len(cmdCtx.Arguments) > 0 {
-		cmdName := cmdCtx.Arguments[0]
+	if len(opts.args) > 0 {
+		cmdName := opts.args[0]
 		cmd, err := c.handler.GetCommand(cmdName)
+		if err == nil && !cmdCtx.CanExecute(cmd) {
+			// Не раскрываем команды, которые вызывающему недоступны
+			err = command.ErrCommandNotFound
+		}
 		if err != nil {
 			return "", fmt.Errorf("command '%s' not found: %w", cmdName, err)
 		}
 
-		return c.formatCommandHelp(cmd), nil
+		return c.renderCommand(cmd, opts.format)
 	}
 
 	// В противном случае, выводим список всех доступных команд
-	return c.listAllCommands(), nil
+	return c.renderList(c.visibleCommands(cmdCtx), "", opts)
+}
+
+// parseHelpOptions отделяет опции --page и --format от остальных аргументов
+func parseHelpOptions(args []string) (helpOptions, error) {
+	opts := helpOptions{format: helpFormatText, page: 1}
+
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--page" && name != "--format" {
+			opts.args = append(opts.args, args[i])
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("option %s requires a value: %w", name, command.ErrInvalidArguments)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "--page":
+			page, err := strconv.Atoi(value)
+			if err != nil || page < 1 {
+				return opts, fmt.Errorf("invalid page %q: %w", value, command.ErrInvalidArguments)
+			}
+			opts.page = page
+		case "--format":
+			switch format := strings.ToLower(value); format {
+			case helpFormatText, helpFormatJSON, helpFormatMarkdown:
+				opts.format = format
+			case "md":
+				opts.format = helpFormatMarkdown
+			default:
+				return opts, fmt.Errorf("unknown format %q (supported: text, json, markdown): %w", value, command.ErrInvalidArguments)
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+// visibleCommands возвращает команды, которые вызывающий может выполнить,
+// упорядоченные по категориям и именам
+func (c *HelpCommand) visibleCommands(cmdCtx command.CommandContext) []command.Command {
+	categories := make(map[string][]command.Command)
+	for _, cmd := range c.handler.ListCommands() {
+		if cmdCtx.CanExecute(cmd) {
+			category := command.CategoryOf(cmd)
+			categories[category] = append(categories[category], cmd)
+		}
+	}
+
+	var cmds []command.Command
+	for _, category := range command.SortedCategories(categories) {
+		command.SortByName(categories[category])
+		cmds = append(cmds, categories[category]...)
+	}
+
+	return cmds
+}
+
+// renderCommand выводит справку по одной команде в заданном формате
+func (c *HelpCommand) renderCommand(cmd command.Command, format string) (string, error) {
+	switch format {
+	case helpFormatJSON:
+		return encodeHelpJSON(command.Describe(cmd))
+	case helpFormatMarkdown:
+		return c.formatCommandMarkdown(command.Describe(cmd)), nil
+	default:
+		return c.formatCommandHelp(cmd), nil
+	}
+}
+
+// renderList выводит страницу списка команд в заданном формате
+func (c *HelpCommand) renderList(cmds []command.Command, query string, opts helpOptions) (string, error) {
+	pages := (len(cmds) + helpPageSize - 1) / helpPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	if opts.page > pages {
+		return "", fmt.Errorf("page %d is out of range, there are %d page(s): %w", opts.page, pages, command.ErrInvalidArguments)
+	}
+
+	start := (opts.page - 1) * helpPageSize
+	end := start + helpPageSize
+	if end > len(cmds) {
+		end = len(cmds)
+	}
+
+	page := helpPage{
+		Query:    query,
+		Page:     opts.page,
+		Pages:    pages,
+		Total:    len(cmds),
+		Commands: make([]command.Info, 0, end-start),
+	}
+	for _, cmd := range cmds[start:end] {
+		page.Commands = append(page.Commands, command.Describe(cmd))
+	}
+
+	switch opts.format {
+	case helpFormatJSON:
+		return encodeHelpJSON(page)
+	case helpFormatMarkdown:
+		return c.formatListMarkdown(page), nil
+	default:
+		return c.listAllCommands(page), nil
+	}
 }
 
 // formatCommandHelp форматирует подробную справку для конкретной команды
@@ -91,42 +275,37 @@ func (c *HelpCommand) formatCommandHelp(cmd command.Command) string {
 	// Описание команды
 	sb.WriteString(fmt.Sprintf("Description: %s\n\n", cmd.Description()))
 
+	// Категория команды
+	sb.WriteString(fmt.Sprintf("Category: %s\n\n", command.CategoryOf(cmd)))
+
 	// Использование команды
 	sb.WriteString(fmt.Sprintf("Usage: %s\n\n", cmd.Usage()))
 
 	// Примеры использования команды
 	sb.WriteString("Examples:\n")
 
-	// Добавляем примеры в зависимости от типа команды
-	switch cmd.Name() {
-	case "help":
-		sb.WriteString("  /help           - Shows list of all available commands\n")
-		sb.WriteString("  /help ping      - Shows detailed help for the ping command\n")
-	case "echo":
-		sb.WriteString("  /echo Hello World  - Bot responds with 'Hello World'\n")
-		sb.WriteString("  /echo I am a bot   - Bot responds with 'I am a bot'\n")
-	case "ping":
-		sb.WriteString("  /ping           - Bot responds with 'Pong!' and latency information\n")
-	case "time":
-		sb.WriteString("  /time           - Bot responds with the current date and time\n")
-	case "random":
-		sb.WriteString("  /random         - Generates a random number between 1 and 100\n")
-		sb.WriteString("  /random 50      - Generates a random number between 1 and 50\n")
-		sb.WriteString("  /random 10 20   - Generates a random number between 10 and 20\n")
-	case "weather":
-		sb.WriteString("  /weather Moscow    - Shows simulated weather for Moscow\n")
-		sb.WriteString("  /weather New York  - Shows simulated weather for New York\n")
-	case "calc":
-		sb.WriteString("  /calc 5 + 3     - Calculates 5 + 3 = 8\n")
-		sb.WriteString("  /calc 10 - 4    - Calculates 10 - 4 = 6\n")
-		sb.WriteString("  /calc 7 * 2     - Calculates 7 * 2 = 14\n")
-		sb.WriteString("  /calc 20 / 5    - Calculates 20 / 5 = 4\n")
-	case "quote":
-		sb.WriteString("  /quote          - Shows a random inspirational quote\n")
-	default:
+	// Примеры предоставляет сама команда, если реализует command.ExampleProvider
+	var examples []command.Example
+	if provider, ok := cmd.(command.ExampleProvider); ok {
+		examples = provider.Examples()
+	}
+
+	if len(examples) == 0 {
 		sb.WriteString("  No examples available for this command.\n")
 	}
 
+	// Выравниваем описания по самому длинному примеру
+	width := 0
+	for _, ex := range examples {
+		if n := len([]rune(c.prefix + ex.Input)); n > width {
+			width = n
+		}
+	}
+
+	for _, ex := range examples {
+		sb.WriteString(fmt.Sprintf("  %-*s - %s\n", width, c.prefix+ex.Input, ex.Description))
+	}
+
 	sb.WriteString("\n")
 
 	// Требуемые разрешения
@@ -140,66 +319,127 @@ func (c *HelpCommand) formatCommandHelp(cmd command.Command) string {
 	return sb.String()
 }
 
-// listAllCommands форматирует список всех доступных команд
-func (c *HelpCommand) listAllCommands() string {
+// listAllCommands форматирует страницу списка команд, сгруппированных по категориям
+func (c *HelpCommand) listAllCommands(page helpPage) string {
 	var sb strings.Builder
 
-	sb.WriteString("=== Available Commands ===\n\n")
-
-	// Получаем все команды
-	allCommands := c.handler.ListCommands()
-
-	// Создаем карту для быстрого доступа к командам по имени
-	cmdMap := make(map[string]command.Command)
-	for _, cmd := range allCommands {
-		cmdMap[cmd.Name()] = cmd
+	if page.Query != "" {
+		sb.WriteString(fmt.Sprintf("=== Commands matching %q ===\n\n", page.Query))
+	} else {
+		sb.WriteString("=== Available Commands ===\n\n")
 	}
 
-	// Отслеживаем команды, которые уже были отображены в категориях
-	displayedCommands := make(map[string]bool)
-
-	// Отображаем команды по категориям
-	for category, cmdNames := range c.categories {
-		sb.WriteString(fmt.Sprintf("== %s ==\n", category))
-
-		// Сортируем имена команд в категории
-		sort.Strings(cmdNames)
+	if page.Total == 0 {
+		sb.WriteString("No commands found.\n\n")
+	}
 
-		for _, cmdName := range cmdNames {
-			if cmd, exists := cmdMap[cmdName]; exists {
-				sb.WriteString(fmt.Sprintf("  %-15s %s\n", cmd.Name(), cmd.Description()))
-				displayedCommands[cmdName] = true
+	// Команды уже упорядочены по категориям, поэтому заголовок выводится при смене категории
+	category := ""
+	for _, info := range page.Commands {
+		if info.Category != category {
+			if category != "" {
+				sb.WriteString("\n")
 			}
+			category = info.Category
+			sb.WriteString(fmt.Sprintf("== %s ==\n", category))
 		}
+		sb.WriteString(fmt.Sprintf("  %-15s %s\n", info.Name, info.Description))
+	}
 
+	if len(page.Commands) > 0 {
 		sb.WriteString("\n")
 	}
 
-	// Проверяем, есть ли команды, которые не попали ни в одну категорию
-	var uncategorizedCmds []command.Command
-	for _, cmd := range allCommands {
-		if !displayedCommands[cmd.Name()] {
-			uncategorizedCmds = append(uncategorizedCmds, cmd)
+	if page.Pages > 1 {
+		sb.WriteString(fmt.Sprintf("Page %d of %d.", page.Page, page.Pages))
+		if page.Page < page.Pages {
+			// Подсказка сохраняет условие поиска, иначе следующая страница была бы из полного списка
+			next := fmt.Sprintf("help --page %d", page.Page+1)
+			if page.Query != "" {
+				next = fmt.Sprintf("help search %s --page %d", page.Query, page.Page+1)
+			}
+			sb.WriteString(fmt.Sprintf(" Type '%s' for more.", next))
 		}
+		sb.WriteString("\n")
 	}
 
-	// Если есть некатегоризированные команды, отображаем их отдельно
-	if len(uncategorizedCmds) > 0 {
-		sb.WriteString("== Other ==\n")
+	sb.WriteString("Type 'help <command>' for more information about a specific command.")
+
+	return sb.String()
+}
+
+// formatListMarkdown форматирует страницу списка команд в Markdown
+func (c *HelpCommand) formatListMarkdown(page helpPage) string {
+	var sb strings.Builder
 
-		// Сортируем некатегоризированные команды по имени
-		sort.Slice(uncategorizedCmds, func(i, j int) bool {
-			return uncategorizedCmds[i].Name() < uncategorizedCmds[j].Name()
-		})
+	if page.Query != "" {
+		sb.WriteString(fmt.Sprintf("# Commands matching %q\n\n", page.Query))
+	} else {
+		sb.WriteString("# Available Commands\n\n")
+	}
 
-		for _, cmd := range uncategorizedCmds {
-			sb.WriteString(fmt.Sprintf("  %-15s %s\n", cmd.Name(), cmd.Description()))
+	category := ""
+	for _, info := range page.Commands {
+		if info.Category != category {
+			if category != "" {
+				sb.WriteString("\n")
+			}
+			category = info.Category
+			sb.WriteString(fmt.Sprintf("## %s\n\n", category))
+			sb.WriteString("| Command | Aliases | Description |\n")
+			sb.WriteString("|---------|---------|-------------|\n")
 		}
+		sb.WriteString(fmt.Sprintf("| `%s%s` | %s | %s |\n",
+			c.prefix, info.Name, strings.Join(info.Aliases, ", "), escapeMarkdownCell(info.Description)))
+	}
 
-		sb.WriteString("\n")
+	if page.Pages > 1 {
+		sb.WriteString(fmt.Sprintf("\n_Page %d of %d_\n", page.Page, page.Pages))
 	}
 
-	sb.WriteString("Type 'help <command>' for more information about a specific command.")
+	return sb.String()
+}
+
+// formatCommandMarkdown форматирует справку по одной команде в Markdown
+func (c *HelpCommand) formatCommandMarkdown(info command.Info) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("## %s%s\n\n", c.prefix, info.Name))
+	sb.WriteString(info.Description + "\n\n")
+	sb.WriteString(fmt.Sprintf("- **Usage:** `%s%s`\n", c.prefix, info.Usage))
+	sb.WriteString(fmt.Sprintf("- **Category:** %s\n", info.Category))
+
+	if len(info.Aliases) > 0 {
+		sb.WriteString(fmt.Sprintf("- **Aliases:** %s\n", strings.Join(info.Aliases, ", ")))
+	}
+
+	if len(info.Permissions) > 0 {
+		sb.WriteString(fmt.Sprintf("- **Required permissions:** %s\n", strings.Join(info.Permissions, ", ")))
+	} else {
+		sb.WriteString("- **Required permissions:** none\n")
+	}
+
+	if len(info.Examples) > 0 {
+		sb.WriteString("\n### Examples\n\n")
+		for _, ex := range info.Examples {
+			sb.WriteString(fmt.Sprintf("- `%s%s` — %s\n", c.prefix, ex.Input, ex.Description))
+		}
+	}
 
 	return sb.String()
 }
+
+// encodeHelpJSON кодирует справку в JSON с отступами
+func encodeHelpJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode help: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// escapeMarkdownCell экранирует символы, ломающие ячейку таблицы Markdown
+func escapeMarkdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
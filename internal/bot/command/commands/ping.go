@@ -4,17 +4,61 @@ package commands
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"command-bot/pkg/command"
 )
 
-// PingCommand отвечает сообщением pong и информацией о задержке
-type PingCommand struct{}
+// defaultPingTimeout ограничивает время проверки доступности цели
+const defaultPingTimeout = 5 * time.Second
 
-// NewPingCommand создает новую команду ping
+// PingCommand отвечает сообщением pong с реальным временем обработки
+// и проверяет доступность целей из разрешенного списка
+type PingCommand struct {
+	// Разрешенные цели: "host" или "host:port"
+	allowed []string
+	timeout time.Duration
+	client  *http.Client
+	dialer  *net.Dialer
+}
+
+// NewPingCommand создает новую команду ping без разрешенных целей
 func NewPingCommand() *PingCommand {
-	return &PingCommand{}
+	return NewPingCommandWithTargets(nil)
+}
+
+// NewPingCommandWithTargets создает команду ping, которой разрешено
+// проверять доступность перечисленных хостов
+func NewPingCommandWithTargets(allowed []string) *PingCommand {
+	normalized := make([]string, 0, len(allowed))
+	for _, target := range allowed {
+		if target = strings.ToLower(strings.TrimSpace(target)); target != "" {
+			normalized = append(normalized, target)
+		}
+	}
+
+	return &PingCommand{
+		allowed: normalized,
+		timeout: defaultPingTimeout,
+		client: &http.Client{
+			// Измеряем время ответа самой цели, не следуя перенаправлениям
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		dialer: &net.Dialer{},
+	}
+}
+
+// SetTimeout задает ограничение времени проверки цели
+func (c *PingCommand) SetTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		c.timeout = timeout
+	}
 }
 
 // Name возвращает основное имя команды
@@ -34,7 +78,7 @@ func (c *PingCommand) Description() string {
 
 // Usage возвращает строку, показывающую, как использовать команду
 func (c *PingCommand) Usage() string {
-	return "ping"
+	return "ping [host:port | http(s)://url]"
 }
 
 // RequiredPermissions возвращает список разрешений, необходимых для выполнения этой команды
@@ -42,16 +86,134 @@ func (c *PingCommand) RequiredPermissions() []string {
 	return []string{} // Специальные разрешения не требуются
 }
 
+// Category возвращает категорию команды в справке
+func (c *PingCommand) Category() string {
+	return "Information"
+}
+
+// Examples возвращает примеры использования команды
+func (c *PingCommand) Examples() []command.Example {
+	return []command.Example{
+		{Input: "ping", Description: "Bot responds with 'Pong!' and the real processing time"},
+		{Input: "ping db.internal:5432", Description: "Measures TCP connect time to an allowed target"},
+		{Input: "ping https://example.com", Description: "Measures HTTP response time of an allowed target"},
+	}
+}
+
+// Arguments описывает аргументы команды
+func (c *PingCommand) Arguments() []command.Argument {
+	return []command.Argument{
+		{Name: "target", Type: command.ArgumentString, Description: "host:port or http(s) URL from the ping allowlist"},
+	}
+}
+
 // Execute выполняет команду с заданным контекстом и возвращает ответ
 func (c *PingCommand) Execute(ctx context.Context, cmdCtx command.CommandContext) (string, error) {
-	// Записываем время начала
+	if len(cmdCtx.Arguments) > 0 {
+		return c.pingTarget(ctx, cmdCtx.Arguments[0])
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Pong!")
+
+	receivedAt, ok := cmdCtx.MetadataTime(command.MetadataReceivedAt)
+	if !ok {
+		sb.WriteString(" (processing time unavailable: transport did not record receipt time)")
+		return sb.String(), nil
+	}
+
+	sb.WriteString(fmt.Sprintf("\nProcessing time: %v", time.Since(receivedAt)))
+
+	// Задержка доставки известна, только если клиент сообщил время отправки
+	if sentAt, ok := cmdCtx.MetadataTime(command.MetadataSentAt); ok {
+		sb.WriteString(fmt.Sprintf("\nTransport delay: %v", receivedAt.Sub(sentAt)))
+	}
+
+	return sb.String(), nil
+}
+
+// pingTarget проверяет доступность цели по TCP или HTTP и измеряет время ответа
+func (c *PingCommand) pingTarget(ctx context.Context, target string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		u, err := url.Parse(target)
+		if err != nil || u.Host == "" {
+			return "", fmt.Errorf("invalid URL %q: %w", target, command.ErrInvalidArguments)
+		}
+		port := u.Port()
+		if port == "" {
+			port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+		}
+		if !c.isAllowed(u.Hostname(), port) {
+			return "", fmt.Errorf("target %s is not in the ping allowlist: %w", u.Host, command.ErrPermissionDenied)
+		}
+		return c.pingHTTP(ctx, u.String())
+	}
+
+	host, port, err := net.SplitHostPort(target)
+	if err != nil || port == "" {
+		return "", fmt.Errorf("expected host:port or URL, got %q: %w", target, command.ErrInvalidArguments)
+	}
+	if !c.isAllowed(host, port) {
+		return "", fmt.Errorf("target %s is not in the ping allowlist: %w", target, command.ErrPermissionDenied)
+	}
+
+	return c.pingTCP(ctx, target)
+}
+
+// pingTCP измеряет время установки TCP-соединения
+func (c *PingCommand) pingTCP(ctx context.Context, address string) (string, error) {
+	start := time.Now()
+
+	conn, err := c.dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Sprintf("TCP %s is unreachable after %v: %v", address, time.Since(start), err), nil
+	}
+	elapsed := time.Since(start)
+	conn.Close()
+
+	return fmt.Sprintf("TCP %s is reachable, connect time: %v", address, elapsed), nil
+}
+
+// pingHTTP измеряет время получения ответа на HEAD-запрос
+func (c *PingCommand) pingHTTP(ctx context.Context, target string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", target, command.ErrInvalidArguments)
+	}
+
 	start := time.Now()
 
-	// Имитируем некоторое время обработки
-	time.Sleep(10 * time.Millisecond)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Sprintf("HTTP %s is unreachable after %v: %v", target, time.Since(start), err), nil
+	}
+	elapsed := time.Since(start)
+	resp.Body.Close()
+
+	return fmt.Sprintf("HTTP %s responded %s in %v", target, resp.Status, elapsed), nil
+}
+
+// isAllowed проверяет цель по разрешенному списку: запись "host" разрешает
+// любой порт хоста, запись "host:port" — только указанный порт
+func (c *PingCommand) isAllowed(host, port string) bool {
+	host = strings.ToLower(host)
+
+	for _, entry := range c.allowed {
+		allowedHost, allowedPort, err := net.SplitHostPort(entry)
+		if err != nil {
+			allowedHost, allowedPort = strings.Trim(entry, "[]"), ""
+		}
 
-	// Вычисляем задержку
-	latency := time.Since(start)
+		if allowedHost != host {
+			continue
+		}
+		if allowedPort == "" || allowedPort == port {
+			return true
+		}
+	}
 
-	return fmt.Sprintf("Pong! Latency: %v", latency), nil
+	return false
 }
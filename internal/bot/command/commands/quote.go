@@ -3,52 +3,83 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"command-bot/internal/bot/quote"
 	"command-bot/pkg/command"
 )
 
-// QuoteCommand предоставляет случайные вдохновляющие цитаты
+const (
+	// maxQuoteResults ограничивает количество цитат в ответах search и by
+	maxQuoteResults = 10
+	// recentQuoteLimit задает, сколько последних показанных цитат избегать в чате
+	recentQuoteLimit = 5
+)
+
+// errSharedCollection возвращается add и remove без чата: общая коллекция
+// задается в конфигурации и командами не изменяется
+var errSharedCollection = fmt.Errorf("the shared quote collection is read-only, use a chat: %w", command.ErrPermissionDenied)
+
+// QuoteCommand предоставляет цитаты из общей коллекции и коллекций чатов
 type QuoteCommand struct {
-	quotes []Quote
-	rng    *rand.Rand
-}
+	store    quote.Store
+	rng      *rand.Rand
+	location *time.Location
+	now      func() time.Time
 
-// Quote представляет цитату с автором
-type Quote struct {
-	Text   string
-	Author string
+	// Последние показанные цитаты по чатам
+	recent map[string][]int
+	mu     sync.Mutex
 }
 
-// NewQuoteCommand создает новую команду quote
+// NewQuoteCommand создает новую команду quote с цитатами по умолчанию в памяти
 func NewQuoteCommand() *QuoteCommand {
+	return NewQuoteCommandWithStore(quote.NewMemoryStore(quote.DefaultQuotes()))
+}
+
+// NewQuoteCommandWithStore создает новую команду quote поверх заданного хранилища
+func NewQuoteCommandWithStore(store quote.Store) *QuoteCommand {
 	source := rand.NewSource(time.Now().UnixNano())
 
-	// Предопределенный список цитат
-	quotes := []Quote{
-		{Text: "The only way to do great work is to love what you do.", Author: "Steve Jobs"},
-		{Text: "Life is what happens when you're busy making other plans.", Author: "John Lennon"},
-		{Text: "The future belongs to those who believe in the beauty of their dreams.", Author: "Eleanor Roosevelt"},
-		{Text: "In the middle of difficulty lies opportunity.", Author: "Albert Einstein"},
-		{Text: "Success is not final, failure is not fatal: It is the courage to continue that counts.", Author: "Winston Churchill"},
-		{Text: "The best way to predict the future is to create it.", Author: "Peter Drucker"},
-		{Text: "Believe you can and you're halfway there.", Author: "Theodore Roosevelt"},
-		{Text: "It does not matter how slowly you go as long as you do not stop.", Author: "Confucius"},
-		{Text: "The only limit to our realization of tomorrow will be our doubts of today.", Author: "Franklin D. Roosevelt"},
-		{Text: "The journey of a thousand miles begins with one step.", Author: "Lao Tzu"},
-		{Text: "Don't watch the clock; do what it does. Keep going.", Author: "Sam Levenson"},
-		{Text: "The only person you are destined to become is the person you decide to be.", Author: "Ralph Waldo Emerson"},
-		{Text: "The best revenge is massive success.", Author: "Frank Sinatra"},
-		{Text: "The purpose of our lives is to be happy.", Author: "Dalai Lama"},
-		{Text: "You miss 100% of the shots you don't take.", Author: "Wayne Gretzky"},
+	return &QuoteCommand{
+		store:    store,
+		rng:      rand.New(source),
+		location: time.Local,
+		now:      time.Now,
+		recent:   make(map[string][]int),
 	}
+}
 
-	return &QuoteCommand{
-		quotes: quotes,
-		rng:    rand.New(source),
+// SetLocation задает часовой пояс, в котором определяется смена дня для цитаты дня
+func (c *QuoteCommand) SetLocation(location *time.Location) {
+	c.location = location
+}
+
+// InheritHistory переносит недавно показанные цитаты из prev, например
+// при перезагрузке конфигурации, чтобы random не повторял их сразу
+func (c *QuoteCommand) InheritHistory(prev *QuoteCommand) {
+	prev.mu.Lock()
+	recent := make(map[string][]int, len(prev.recent))
+	for chatID, ids := range prev.recent {
+		recent[chatID] = slices.Clone(ids)
 	}
+	prev.mu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recent = recent
+}
+
+// Store возвращает хранилище цитат команды
+func (c *QuoteCommand) Store() quote.Store {
+	return c.store
 }
 
 // Name возвращает основное имя команды
@@ -63,12 +94,12 @@ func (c *QuoteCommand) Aliases() []string {
 
 // Description возвращает краткое описание того, что делает команда
 func (c *QuoteCommand) Description() string {
-	return "Provides a random inspirational quote"
+	return "Provides a random inspirational quote and manages the chat's quote collection"
 }
 
 // Usage возвращает строку, показывающую, как использовать команду
 func (c *QuoteCommand) Usage() string {
-	return "quote"
+	return `quote [<id> | today | add "text" -- author | remove <id> | search <term> | by <author> | subscribe | unsubscribe]`
 }
 
 // RequiredPermissions возвращает список разрешений, необходимых для выполнения этой команды
@@ -76,10 +107,277 @@ func (c *QuoteCommand) RequiredPermissions() []string {
 	return []string{} // Специальные разрешения не требуются
 }
 
+// Category возвращает категорию команды в справке
+func (c *QuoteCommand) Category() string {
+	return "Fun"
+}
+
+// Examples возвращает примеры использования команды
+func (c *QuoteCommand) Examples() []command.Example {
+	return []command.Example{
+		{Input: "quote", Description: "Shows a random inspirational quote"},
+		{Input: "quote 3", Description: "Shows quote #3"},
+		{Input: "quote today", Description: "Shows the quote of the day for this chat"},
+		{Input: "quote subscribe", Description: "Posts the quote of the day to this chat every day"},
+		{Input: `quote add "Ship it" -- Alice`, Description: "Adds a quote to this chat's collection"},
+		{Input: "quote remove 16", Description: "Removes quote #16 from this chat's collection"},
+		{Input: "quote search future", Description: "Finds quotes mentioning 'future'"},
+		{Input: "quote by Einstein", Description: "Lists quotes by Einstein"},
+	}
+}
+
+// Arguments описывает аргументы команды
+func (c *QuoteCommand) Arguments() []command.Argument {
+	return []command.Argument{
+		{Name: "subcommand", Type: command.ArgumentString, Description: "Quote ID or one of today, add, remove, search, by, subscribe, unsubscribe"},
+		{Name: "args", Type: command.ArgumentString, Description: "Subcommand arguments", Variadic: true},
+	}
+}
+
 // Execute выполняет команду с заданным контекстом и возвращает ответ
 func (c *QuoteCommand) Execute(ctx context.Context, cmdCtx command.CommandContext) (string, error) {
-	// Выбираем случайную цитату из списка
-	quote := c.quotes[c.rng.Intn(len(c.quotes))]
+	if len(cmdCtx.Arguments) == 0 {
+		return c.random(cmdCtx.ChatID)
+	}
+
+	sub := strings.ToLower(cmdCtx.Arguments[0])
+	rest := strings.Join(cmdCtx.Arguments[1:], " ")
+
+	switch sub {
+	case "today", "daily":
+		return c.DailyMessage(cmdCtx.ChatID, c.now())
+	case "subscribe":
+		return c.subscribe(cmdCtx.ChatID, true)
+	case "unsubscribe":
+		return c.subscribe(cmdCtx.ChatID, false)
+	case "add":
+		return c.add(cmdCtx, rest)
+	case "remove", "rm", "delete":
+		return c.remove(cmdCtx.ChatID, rest)
+	case "search", "find":
+		return c.search(cmdCtx.ChatID, rest)
+	case "by", "author":
+		return c.byAuthor(cmdCtx.ChatID, rest)
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(sub, "#"))
+	if err != nil {
+		return "", command.NewUsageError(c.Usage(), "unknown subcommand %q", sub)
+	}
+
+	q, err := c.store.Get(cmdCtx.ChatID, id)
+	if err != nil {
+		return "", fmt.Errorf("quote #%d: %w", id, err)
+	}
+
+	return formatQuote(q), nil
+}
+
+// random выбирает случайную цитату, видимую в чате
+func (c *QuoteCommand) random(chatID string) (string, error) {
+	quotes, err := c.store.List(chatID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load quotes: %w", err)
+	}
+	if len(quotes) == 0 {
+		return "No quotes yet. Add one with: quote add \"text\" -- author", nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Избегаем недавно показанных цитат, пока есть из чего выбирать
+	candidates := excludeQuotes(quotes, c.recent[chatID])
+	if len(candidates) == 0 {
+		candidates = quotes
+	}
+
+	q := candidates[c.rng.Intn(len(candidates))]
+	c.remember(chatID, q.ID)
+
+	return formatQuote(q), nil
+}
+
+// DailyMessage возвращает цитату дня для чата.
+// Выбор детерминирован датой и чатом, поэтому весь день все участники видят одну цитату.
+func (c *QuoteCommand) DailyMessage(chatID string, day time.Time) (string, error) {
+	quotes, err := c.store.List(chatID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load quotes: %w", err)
+	}
+
+	day = day.In(c.location)
+	q, ok := quote.PickDaily(quotes, chatID, day)
+	if !ok {
+		return "No quotes yet. Add one with: quote add \"text\" -- author", nil
+	}
+
+	c.mu.Lock()
+	c.remember(chatID, q.ID)
+	c.mu.Unlock()
+
+	return fmt.Sprintf("Quote of the day (%s):\n%s", day.Format("2006-01-02"), formatQuote(q)), nil
+}
+
+// subscribe включает или отключает ежедневную рассылку цитаты в чат
+func (c *QuoteCommand) subscribe(chatID string, enable bool) (string, error) {
+	subs, ok := c.store.(quote.SubscriptionStore)
+	if !ok {
+		return "", fmt.Errorf("daily quote subscriptions are not supported by this quote store")
+	}
+
+	if !enable {
+		if err := subs.Unsubscribe(chatID); err != nil {
+			return "", fmt.Errorf("failed to unsubscribe: %w", err)
+		}
+		return "This chat will no longer receive the daily quote", nil
+	}
+
+	if err := subs.Subscribe(chatID); err != nil {
+		return "", fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	return "This chat will receive the quote of the day", nil
+}
+
+// remember запоминает показанную цитату; вызывается под c.mu
+func (c *QuoteCommand) remember(chatID string, id int) {
+	recent := append(c.recent[chatID], id)
+	if len(recent) > recentQuoteLimit {
+		recent = recent[len(recent)-recentQuoteLimit:]
+	}
+	c.recent[chatID] = recent
+}
+
+// excludeQuotes возвращает цитаты, идентификаторы которых не входят в ids
+func excludeQuotes(quotes []quote.Quote, ids []int) []quote.Quote {
+	excluded := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		excluded[id] = true
+	}
+
+	var result []quote.Quote
+	for _, q := range quotes {
+		if !excluded[q.ID] {
+			result = append(result, q)
+		}
+	}
+
+	return result
+}
+
+// add добавляет цитату в коллекцию текущего чата
+func (c *QuoteCommand) add(cmdCtx command.CommandContext, input string) (string, error) {
+	if cmdCtx.ChatID == "" {
+		return "", errSharedCollection
+	}
+
+	text, author := parseQuoteInput(input)
+	if text == "" {
+		return "", command.NewUsageError(`quote add "text" -- author`, "quote text is empty")
+	}
+
+	q, err := c.store.Add(quote.Quote{
+		Text:    text,
+		Author:  author,
+		ChatID:  cmdCtx.ChatID,
+		AddedBy: cmdCtx.UserID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to add quote: %w", err)
+	}
+
+	return fmt.Sprintf("Added quote #%d:\n%s", q.ID, formatQuote(q)), nil
+}
+
+// remove удаляет цитату из коллекции текущего чата
+func (c *QuoteCommand) remove(chatID string, input string) (string, error) {
+	if chatID == "" {
+		return "", errSharedCollection
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(input), "#"))
+	if err != nil {
+		return "", command.NewUsageError("quote remove <id>", "invalid quote id %q", strings.TrimSpace(input))
+	}
+
+	if err := c.store.Remove(chatID, id); err != nil {
+		if errors.Is(err, quote.ErrForbidden) {
+			return "", fmt.Errorf("quote #%d is not part of this chat's collection: %w", id, command.ErrPermissionDenied)
+		}
+		return "", fmt.Errorf("failed to remove quote #%d: %w", id, err)
+	}
+
+	return fmt.Sprintf("Removed quote #%d", id), nil
+}
+
+// search ищет цитаты по тексту и автору
+func (c *QuoteCommand) search(chatID string, term string) (string, error) {
+	if strings.TrimSpace(term) == "" {
+		return "", command.NewUsageError("quote search <term>", "search term is empty")
+	}
+
+	quotes, err := c.store.List(chatID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load quotes: %w", err)
+	}
+
+	return formatQuoteList(quote.Search(quotes, term), fmt.Sprintf("matching %q", term)), nil
+}
+
+// byAuthor выводит цитаты указанного автора
+func (c *QuoteCommand) byAuthor(chatID string, author string) (string, error) {
+	if strings.TrimSpace(author) == "" {
+		return "", command.NewUsageError("quote by <author>", "author is empty")
+	}
+
+	quotes, err := c.store.List(chatID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load quotes: %w", err)
+	}
+
+	return formatQuoteList(quote.ByAuthor(quotes, author), fmt.Sprintf("by %q", author)), nil
+}
+
+// parseQuoteInput разбирает ввод вида "text" -- author.
+// Если автор не указан, цитата записывается как анонимная.
+func parseQuoteInput(input string) (text, author string) {
+	text = strings.TrimSpace(input)
+	author = "Anonymous"
+
+	if i := strings.LastIndex(text, "--"); i >= 0 {
+		if a := strings.TrimSpace(text[i+2:]); a != "" {
+			author = a
+		}
+		text = strings.TrimSpace(text[:i])
+	}
+
+	text = strings.Trim(text, "\"'“”«»")
+
+	return strings.TrimSpace(text), author
+}
+
+// formatQuote форматирует одну цитату
+func formatQuote(q quote.Quote) string {
+	return fmt.Sprintf("\"%s\"\n— %s", q.Text, q.Author)
+}
+
+// formatQuoteList форматирует список найденных цитат с идентификаторами
+func formatQuoteList(quotes []quote.Quote, criteria string) string {
+	if len(quotes) == 0 {
+		return fmt.Sprintf("No quotes found %s", criteria)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d quote(s) %s:\n", len(quotes), criteria))
+
+	for i, q := range quotes {
+		if i == maxQuoteResults {
+			sb.WriteString(fmt.Sprintf("...and %d more\n", len(quotes)-maxQuoteResults))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("#%d \"%s\" — %s\n", q.ID, q.Text, q.Author))
+	}
 
-	return fmt.Sprintf("\"%s\"\n— %s", quote.Text, quote.Author), nil
+	return strings.TrimRight(sb.String(), "\n")
 }
@@ -48,6 +48,29 @@ func (c *RandomCommand) RequiredPermissions() []string {
 	return []string{} // Специальные разрешения не требуются
 }
 
+// Category возвращает категорию команды в справке
+func (c *RandomCommand) Category() string {
+	return "Fun"
+}
+
+// Examples возвращает примеры использования команды
+func (c *RandomCommand) Examples() []command.Example {
+	return []command.Example{
+		{Input: "random", Description: "Generates a random number between 1 and 100"},
+		{Input: "random 50", Description: "Generates a random number between 1 and 50"},
+		{Input: "random 10 20", Description: "Generates a random number between 10 and 20"},
+	}
+}
+
+// Arguments описывает аргументы команды
+func (c *RandomCommand) Arguments() []command.Argument {
+	// С одним аргументом он считается верхней границей
+	return []command.Argument{
+		{Name: "min", Type: command.ArgumentInteger, Description: "Lower bound, 1 by default"},
+		{Name: "max", Type: command.ArgumentInteger, Description: "Upper bound, 100 by default"},
+	}
+}
+
 // Execute выполняет команду с заданным контекстом и возвращает ответ
 func (c *RandomCommand) Execute(ctx context.Context, cmdCtx command.CommandContext) (string, error) {
 	var min, max int
@@ -61,25 +84,25 @@ func (c *RandomCommand) Execute(ctx context.Context, cmdCtx command.CommandConte
 		// Только максимальное значение указано
 		max, err = strconv.Atoi(cmdCtx.Arguments[0])
 		if err != nil {
-			return "", fmt.Errorf("invalid maximum value: %w", err)
+			return "", fmt.Errorf("invalid maximum value %q: %w", cmdCtx.Arguments[0], command.ErrInvalidArguments)
 		}
 		min = 1
 	case 2:
 		// Указаны и минимальное, и максимальное значения
 		min, err = strconv.Atoi(cmdCtx.Arguments[0])
 		if err != nil {
-			return "", fmt.Errorf("invalid minimum value: %w", err)
+			return "", fmt.Errorf("invalid minimum value %q: %w", cmdCtx.Arguments[0], command.ErrInvalidArguments)
 		}
 		max, err = strconv.Atoi(cmdCtx.Arguments[1])
 		if err != nil {
-			return "", fmt.Errorf("invalid maximum value: %w", err)
+			return "", fmt.Errorf("invalid maximum value %q: %w", cmdCtx.Arguments[1], command.ErrInvalidArguments)
 		}
 	default:
-		return "", fmt.Errorf("too many arguments: expected 0-2, got %d", len(cmdCtx.Arguments))
+		return "", fmt.Errorf("too many arguments: expected 0-2, got %d: %w", len(cmdCtx.Arguments), command.ErrInvalidArguments)
 	}
 
 	if min >= max {
-		return "", fmt.Errorf("minimum value must be less than maximum value")
+		return "", fmt.Errorf("minimum value must be less than maximum value: %w", command.ErrInvalidArguments)
 	}
 
 	randomNum := c.rng.Intn(max-min+1) + min
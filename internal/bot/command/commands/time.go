@@ -41,6 +41,18 @@ func (c *TimeCommand) RequiredPermissions() []string {
 	return []string{} // Специальные разрешения не требуются
 }
 
+// Category возвращает категорию команды в справке
+func (c *TimeCommand) Category() string {
+	return "Information"
+}
+
+// Examples возвращает примеры использования команды
+func (c *TimeCommand) Examples() []command.Example {
+	return []command.Example{
+		{Input: "time", Description: "Bot responds with the current date and time"},
+	}
+}
+
 // Execute выполняет команду с заданным контекстом и возвращает ответ
 func (c *TimeCommand) Execute(ctx context.Context, cmdCtx command.CommandContext) (string, error) {
 	now := time.Now()
@@ -7,17 +7,75 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"command-bot/pkg/command"
 )
 
+// WeatherOptions задает адреса API Open-Meteo и параметры запросов
+type WeatherOptions struct {
+	// GeocodingURL — адрес API геокодирования
+	GeocodingURL string
+	// ForecastURL — адрес API прогноза погоды
+	ForecastURL string
+	// Timezone — часовой пояс времени в ответе, "auto" — пояс локации
+	Timezone string
+	// Timeout ограничивает время обоих запросов
+	Timeout time.Duration
+	// Transport выполняет HTTP-запросы, например с учетом метрик; при nil используется http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// DefaultWeatherOptions возвращает параметры публичного API Open-Meteo
+func DefaultWeatherOptions() WeatherOptions {
+	return WeatherOptions{
+		GeocodingURL: "https://geocoding-api.open-meteo.com/v1/search",
+		ForecastURL:  "https://api.open-meteo.com/v1/forecast",
+		Timezone:     "Europe/Warsaw",
+		Timeout:      10 * time.Second,
+	}
+}
+
 // WeatherCommand получает информацию о текущей погоде из Open-Meteo
 // и не требует API-ключа
-type WeatherCommand struct{}
+type WeatherCommand struct {
+	opts   WeatherOptions
+	client *http.Client
+
+	// mu защищает сведения о последних запросах к API для проверки состояния
+	mu          sync.Mutex
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+}
 
 // NewWeatherCommand создает новую команду weather
 func NewWeatherCommand() *WeatherCommand {
-	return &WeatherCommand{}
+	return NewWeatherCommandWithOptions(DefaultWeatherOptions())
+}
+
+// NewWeatherCommandWithOptions создает команду weather с заданными параметрами;
+// незаданные поля берутся из DefaultWeatherOptions
+func NewWeatherCommandWithOptions(opts WeatherOptions) *WeatherCommand {
+	defaults := DefaultWeatherOptions()
+	if opts.GeocodingURL == "" {
+		opts.GeocodingURL = defaults.GeocodingURL
+	}
+	if opts.ForecastURL == "" {
+		opts.ForecastURL = defaults.ForecastURL
+	}
+	if opts.Timezone == "" {
+		opts.Timezone = defaults.Timezone
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaults.Timeout
+	}
+
+	return &WeatherCommand{
+		opts:   opts,
+		client: &http.Client{Transport: opts.Transport},
+	}
 }
 
 // Name возвращает основное имя команды
@@ -45,27 +103,51 @@ func (c *WeatherCommand) RequiredPermissions() []string {
 	return []string{}
 }
 
+// Category возвращает категорию команды в справке
+func (c *WeatherCommand) Category() string {
+	return "Information"
+}
+
+// Examples возвращает примеры использования команды
+func (c *WeatherCommand) Examples() []command.Example {
+	return []command.Example{
+		{Input: "weather Moscow", Description: "Shows current weather for Moscow"},
+		{Input: "weather New York", Description: "Shows current weather for New York"},
+	}
+}
+
+// Arguments описывает аргументы команды
+func (c *WeatherCommand) Arguments() []command.Argument {
+	return []command.Argument{
+		{Name: "location", Type: command.ArgumentString, Description: "City or place name", Required: true, Variadic: true},
+	}
+}
+
 // Execute выполняет команду с заданным контекстом и возвращает ответ
 func (c *WeatherCommand) Execute(ctx context.Context, cmdCtx command.CommandContext) (string, error) {
 	if len(cmdCtx.Arguments) == 0 {
-		return "", fmt.Errorf("please specify a location")
+		return "", command.NewUsageError(c.Usage(), "please specify a location")
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
 	// Собираем название локации
 	location := strings.Join(cmdCtx.Arguments, " ")
-	// Кодируем параметр
-	q := url.QueryEscape(location)
 
 	// Шаг 1: геокодирование через Open-Meteo Geocoding API
-	geoURL := fmt.Sprintf("https://geocoding-api.open-meteo.com/v1/search?name=%s&count=1", q)
-	resp, err := http.Get(geoURL)
+	geoURL := c.opts.GeocodingURL + "?" + url.Values{
+		"name":  {location},
+		"count": {"1"},
+	}.Encode()
+	resp, err := c.get(ctx, geoURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch geocoding data: %v", err)
+		return "", c.unavailable(fmt.Errorf("failed to fetch geocoding data: %w", err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("geocoding API returned status %d", resp.StatusCode)
+		return "", c.unavailable(fmt.Errorf("geocoding API returned status %d", resp.StatusCode))
 	}
 
 	var geoData struct {
@@ -77,29 +159,36 @@ func (c *WeatherCommand) Execute(ctx context.Context, cmdCtx command.CommandCont
 		} `json:"results"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&geoData); err != nil {
-		return "", fmt.Errorf("failed to parse geocoding response: %v", err)
+		return "", c.unavailable(fmt.Errorf("failed to parse geocoding response: %w", err))
 	}
 	if len(geoData.Results) == 0 {
-		return "", fmt.Errorf("location not found: %s", location)
+		return "", fmt.Errorf("location not found: %s: %w", location, command.ErrInvalidArguments)
 	}
 	city := geoData.Results[0].Name
 	country := geoData.Results[0].Country
 	lat := geoData.Results[0].Latitude
 	lon := geoData.Results[0].Longitude
 
+	// Место известно раньше погоды; потоковый транспорт покажет его сразу
+	if err := cmdCtx.Emit(fmt.Sprintf("Weather for %s, %s:\n", city, country)); err != nil {
+		return "", err
+	}
+
 	// Шаг 2: запрос текущей погоды
-	weatherURL := fmt.Sprintf(
-		"https://api.open-meteo.com/v1/forecast?latitude=%.4f&longitude=%.4f&current_weather=true&timezone=Europe/Warsaw",
-		lat, lon,
-	)
-	resp2, err := http.Get(weatherURL)
+	weatherURL := c.opts.ForecastURL + "?" + url.Values{
+		"latitude":        {fmt.Sprintf("%.4f", lat)},
+		"longitude":       {fmt.Sprintf("%.4f", lon)},
+		"current_weather": {"true"},
+		"timezone":        {c.opts.Timezone},
+	}.Encode()
+	resp2, err := c.get(ctx, weatherURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch weather data: %v", err)
+		return "", c.unavailable(fmt.Errorf("failed to fetch weather data: %w", err))
 	}
 	defer resp2.Body.Close()
 
 	if resp2.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("weather API returned status %d", resp2.StatusCode)
+		return "", c.unavailable(fmt.Errorf("weather API returned status %d", resp2.StatusCode))
 	}
 
 	var weatherData struct {
@@ -112,18 +201,16 @@ func (c *WeatherCommand) Execute(ctx context.Context, cmdCtx command.CommandCont
 		} `json:"current_weather"`
 	}
 	if err := json.NewDecoder(resp2.Body).Decode(&weatherData); err != nil {
-		return "", fmt.Errorf("failed to parse weather response: %v", err)
+		return "", c.unavailable(fmt.Errorf("failed to parse weather response: %w", err))
 	}
 
 	cw := weatherData.CurrentWeather
 
 	return fmt.Sprintf(
-		"Weather for %s, %s:\n"+
-			"Time: %s\n"+
+		"Time: %s\n"+
 			"Temperature: %.1f°C\n"+
 			"Wind Speed: %.1f m/s (direction %d°)\n"+
 			"Weather Code: %d",
-		city, country,
 		cw.Time,
 		cw.Temperature,
 		cw.Windspeed,
@@ -131,3 +218,77 @@ func (c *WeatherCommand) Execute(ctx context.Context, cmdCtx command.CommandCont
 		cw.Weathercode,
 	), nil
 }
+
+// unavailable помечает ошибку как сбой Open-Meteo
+func (c *WeatherCommand) unavailable(err error) error {
+	return &command.UnavailableError{Service: "Open-Meteo", Err: err}
+}
+
+// get выполняет GET-запрос с учетом контекста команды
+func (c *WeatherCommand) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	switch {
+	case err != nil:
+		c.observe(err)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		c.observe(fmt.Errorf("status %d", resp.StatusCode))
+	default:
+		c.observe(nil)
+	}
+
+	return resp, err
+}
+
+// observe запоминает результат запроса к API; ошибки клиента, например
+// неизвестная локация, не говорят о недоступности API и считаются успехом
+func (c *WeatherCommand) observe(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		c.lastFailure = time.Now()
+		c.lastError = err.Error()
+		return
+	}
+	c.lastSuccess = time.Now()
+}
+
+// InheritHealth переносит сведения о последних запросах к API из prev, например
+// при перезагрузке конфигурации, чтобы проверка состояния их не теряла
+func (c *WeatherCommand) InheritHealth(prev *WeatherCommand) {
+	prev.mu.Lock()
+	lastSuccess, lastFailure, lastError := prev.lastSuccess, prev.lastFailure, prev.lastError
+	prev.mu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastSuccess, c.lastFailure, c.lastError = lastSuccess, lastFailure, lastError
+}
+
+// HealthCheck сообщает о доступности Open-Meteo по последним запросам,
+// не отправляя новых. Недоступность API не делает сервис неготовым.
+func (c *WeatherCommand) HealthCheck(ctx context.Context) command.HealthReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	details := map[string]any{}
+	if !c.lastSuccess.IsZero() {
+		details["last_success"] = c.lastSuccess.UTC().Format(time.RFC3339)
+	}
+	if !c.lastFailure.IsZero() {
+		details["last_failure"] = c.lastFailure.UTC().Format(time.RFC3339)
+		details["last_error"] = c.lastError
+	}
+
+	report := command.HealthReport{Details: details}
+	if c.lastFailure.After(c.lastSuccess) {
+		report.Err = fmt.Errorf("last request to Open-Meteo failed: %s", c.lastError)
+	}
+
+	return report
+}
@@ -2,17 +2,20 @@ package command
 
 import (
 	"context"
+	"maps"
 	"strings"
 	"sync"
 
 	"command-bot/pkg/command"
+	"command-bot/pkg/transport"
 )
 
 type Handler struct {
-	commands map[string]command.Command
-	aliases  map[string]string
-	prefix   string
-	mu       sync.RWMutex
+	commands   map[string]command.Command
+	aliases    map[string]string
+	prefix     string
+	middleware []command.Middleware
+	mu         sync.RWMutex
 }
 
 // NewHandler создает новый обработчик команд с заданным префиксом
@@ -24,6 +27,20 @@ func NewHandler(prefix string) *Handler {
 	}
 }
 
+// Prefix возвращает префикс, с которого начинаются команды
+func (h *Handler) Prefix() string {
+	return h.prefix
+}
+
+// Use добавляет промежуточные обработчики выполнения команд.
+// Первый добавленный обработчик оказывается внешним.
+func (h *Handler) Use(middleware ...command.Middleware) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.middleware = append(h.middleware, middleware...)
+}
+
 // RegisterCommand добавляет команду в обработчик
 func (h *Handler) RegisterCommand(cmd command.Command) error {
 	h.mu.Lock()
@@ -133,10 +150,54 @@ func (h *Handler) ExecuteCommand(ctx context.Context, cmdCtx command.CommandCont
 
 	cmdName := parts[0]
 
-	cmd, err := h.GetCommand(cmdName)
+	// Ненайденная команда тоже проходит через промежуточные обработчики с cmd == nil
+	cmd, _ := h.GetCommand(cmdName)
+
+	h.mu.RLock()
+	run := command.ExecuteFunc(h.execute)
+	for i := len(h.middleware) - 1; i >= 0; i-- {
+		run = h.middleware[i](run)
+	}
+	h.mu.RUnlock()
+
+	// Если транспорт не передает ответ по частям, части собираются перед итоговым ответом
+	if cmdCtx.Stream() != nil {
+		return run(ctx, cmd, cmdCtx)
+	}
+	// Метаданные копируются, чтобы буфер не остался в контексте вызывающего
+	cmdCtx.Metadata = maps.Clone(cmdCtx.Metadata)
+	chunks := &command.ChunkBuffer{}
+	cmdCtx.SetStream(chunks)
+	response, err := run(ctx, cmd, cmdCtx)
+	return chunks.String() + response, err
+}
+
+// HandleMessage разбирает и выполняет команду из входящего сообщения транспорта.
+// Метаданные сообщения переходят в контекст команды.
+func (h *Handler) HandleMessage(ctx context.Context, msg transport.Message) (string, error) {
+	cmdCtx, err := h.ParseCommand(msg.Text, msg.UserID, msg.ChatID)
 	if err != nil {
 		return "", err
 	}
 
+	maps.Copy(cmdCtx.Metadata, msg.Metadata)
+	if _, ok := cmdCtx.Metadata[command.MetadataReceivedAt]; !ok && !msg.Time.IsZero() {
+		cmdCtx.Metadata[command.MetadataReceivedAt] = msg.Time
+	}
+
+	return h.ExecuteCommand(ctx, cmdCtx)
+}
+
+// execute выполняет команду. Для ненайденной команды
+// возвращает *command.NotFoundError с похожими именами.
+func (h *Handler) execute(ctx context.Context, cmd command.Command, cmdCtx command.CommandContext) (string, error) {
+	if cmd == nil {
+		var name string
+		if parts := strings.Fields(cmdCtx.RawInput); len(parts) > 0 {
+			name = parts[0]
+		}
+		return "", &command.NotFoundError{Name: name, Suggestions: h.Suggest(cmdCtx, name)}
+	}
+
 	return cmd.Execute(ctx, cmdCtx)
 }
@@ -0,0 +1,91 @@
+package command
+
+import (
+	"sort"
+	"strings"
+
+	"command-bot/pkg/command"
+)
+
+// maxSuggestions — сколько похожих команд предлагается при опечатке
+const maxSuggestions = 3
+
+// Suggest возвращает имена команд, похожие на name: отличающиеся не более чем
+// на треть букв (но хотя бы на одну) или начинающиеся с name. Псевдонимы
+// заменяются основными именами команд. Команды, которые вызывающему из cmdCtx
+// недоступны, не предлагаются, как и в справке.
+func (h *Handler) Suggest(cmdCtx command.CommandContext, name string) []string {
+	name = strings.ToLower(name)
+	if name == "" {
+		return nil
+	}
+
+	h.mu.RLock()
+	candidates := make(map[string]string, len(h.commands)+len(h.aliases))
+	for primary, cmd := range h.commands {
+		if cmdCtx.CanExecute(cmd) {
+			candidates[primary] = primary
+		}
+	}
+	for alias, primary := range h.aliases {
+		if _, ok := candidates[primary]; ok {
+			candidates[alias] = primary
+		}
+	}
+	h.mu.RUnlock()
+
+	limit := max(len(name)/3, 1)
+	best := make(map[string]int)
+	for candidate, primary := range candidates {
+		distance := editDistance(name, candidate)
+		if strings.HasPrefix(candidate, name) {
+			distance = 0
+		}
+		if distance > limit {
+			continue
+		}
+		if d, ok := best[primary]; !ok || distance < d {
+			best[primary] = distance
+		}
+	}
+
+	suggestions := make([]string, 0, len(best))
+	for primary := range best {
+		suggestions = append(suggestions, primary)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if best[suggestions[i]] != best[suggestions[j]] {
+			return best[suggestions[i]] < best[suggestions[j]]
+		}
+		return suggestions[i] < suggestions[j]
+	})
+
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// editDistance возвращает расстояние Левенштейна между a и b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
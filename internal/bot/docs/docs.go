@@ -0,0 +1,220 @@
+// Пакет docs формирует справочник команд из зарегистрированных в обработчике команд.
+package docs
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"command-bot/pkg/command"
+)
+
+// Reference — справочник команд, сгруппированных по категориям
+type Reference struct {
+	Title      string
+	Prefix     string
+	Categories []Category
+}
+
+// Category — категория команд справочника
+type Category struct {
+	Name     string
+	Commands []command.Info
+}
+
+// Build собирает справочник из всех команд обработчика.
+// Категории и команды внутри них упорядочены так же, как в выводе /help.
+func Build(handler command.CommandHandler, prefix string) Reference {
+	grouped := make(map[string][]command.Command)
+	for _, cmd := range handler.ListCommands() {
+		category := command.CategoryOf(cmd)
+		grouped[category] = append(grouped[category], cmd)
+	}
+
+	ref := Reference{
+		Title:  "Command Reference",
+		Prefix: prefix,
+	}
+
+	for _, name := range command.SortedCategories(grouped) {
+		cmds := grouped[name]
+		command.SortByName(cmds)
+
+		category := Category{Name: name}
+		for _, cmd := range cmds {
+			category.Commands = append(category.Commands, command.Describe(cmd))
+		}
+		ref.Categories = append(ref.Categories, category)
+	}
+
+	return ref
+}
+
+// Write выводит справочник в указанном формате: markdown, html или man
+func Write(w io.Writer, ref Reference, format string) error {
+	switch strings.ToLower(format) {
+	case "markdown", "md":
+		return WriteMarkdown(w, ref)
+	case "html":
+		return WriteHTML(w, ref)
+	case "man":
+		return WriteMan(w, ref)
+	default:
+		return fmt.Errorf("unknown format %q (supported: markdown, html, man)", format)
+	}
+}
+
+// WriteMarkdown выводит справочник в Markdown
+func WriteMarkdown(w io.Writer, ref Reference) error {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("# %s\n\n", ref.Title))
+	sb.WriteString("<!-- Generated by cmd/docgen. Do not edit by hand. -->\n\n")
+
+	// Оглавление
+	for _, category := range ref.Categories {
+		sb.WriteString(fmt.Sprintf("- **%s**:", category.Name))
+		for i, info := range category.Commands {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(fmt.Sprintf(" [`%s%s`](#%s)", ref.Prefix, info.Name, info.Name))
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, category := range ref.Categories {
+		sb.WriteString(fmt.Sprintf("\n## %s\n", category.Name))
+
+		for _, info := range category.Commands {
+			sb.WriteString(fmt.Sprintf("\n### %s\n\n", info.Name))
+			sb.WriteString(info.Description + "\n\n")
+			sb.WriteString(fmt.Sprintf("**Usage:** `%s%s`\n\n", ref.Prefix, info.Usage))
+
+			if len(info.Aliases) > 0 {
+				aliases := make([]string, len(info.Aliases))
+				for i, alias := range info.Aliases {
+					aliases[i] = fmt.Sprintf("`%s%s`", ref.Prefix, alias)
+				}
+				sb.WriteString(fmt.Sprintf("**Aliases:** %s\n\n", strings.Join(aliases, ", ")))
+			}
+
+			sb.WriteString(fmt.Sprintf("**Required permissions:** %s\n", permissionList(info.Permissions)))
+
+			if len(info.Examples) > 0 {
+				sb.WriteString("\n| Example | Description |\n")
+				sb.WriteString("|---------|-------------|\n")
+				for _, ex := range info.Examples {
+					sb.WriteString(fmt.Sprintf("| `%s` | %s |\n",
+						escapeTableCell(ref.Prefix+ex.Input), escapeTableCell(ex.Description)))
+				}
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// htmlTemplate — шаблон HTML-страницы справочника
+var htmlTemplate = template.Must(template.New("reference").Funcs(template.FuncMap{
+	"permissions": permissionList,
+	"join":        strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="generator" content="command-bot docgen">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- $prefix := .Prefix}}
+{{- range .Categories}}
+<h2>{{.Name}}</h2>
+{{- range .Commands}}
+<section id="{{.Name}}">
+<h3>{{.Name}}</h3>
+<p>{{.Description}}</p>
+<dl>
+<dt>Usage</dt><dd><code>{{$prefix}}{{.Usage}}</code></dd>
+{{- if .Aliases}}
+<dt>Aliases</dt><dd>{{join .Aliases ", "}}</dd>
+{{- end}}
+<dt>Required permissions</dt><dd>{{permissions .Permissions}}</dd>
+</dl>
+{{- if .Examples}}
+<table>
+<tr><th>Example</th><th>Description</th></tr>
+{{- range .Examples}}
+<tr><td><code>{{$prefix}}{{.Input}}</code></td><td>{{.Description}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</section>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML выводит справочник в виде HTML-страницы
+func WriteHTML(w io.Writer, ref Reference) error {
+	return htmlTemplate.Execute(w, ref)
+}
+
+// WriteMan выводит справочник в формате man-страницы (groff)
+func WriteMan(w io.Writer, ref Reference) error {
+	var sb strings.Builder
+
+	sb.WriteString(".\\\" Generated by cmd/docgen. Do not edit by hand.\n")
+	sb.WriteString(".TH COMMAND-BOT 7 \"\" \"command-bot\" \"Command Bot Manual\"\n")
+	sb.WriteString(".SH NAME\ncommand-bot \\- chat bot commands\n")
+	sb.WriteString(".SH DESCRIPTION\n")
+	sb.WriteString(fmt.Sprintf("Commands start with the \\fB%s\\fR prefix.\n", manEscape(ref.Prefix)))
+
+	for _, category := range ref.Categories {
+		sb.WriteString(fmt.Sprintf(".SH %s\n", strings.ToUpper(manEscape(category.Name))))
+
+		for _, info := range category.Commands {
+			sb.WriteString(fmt.Sprintf(".TP\n\\fB%s\\fR\n", manEscape(ref.Prefix+info.Usage)))
+			sb.WriteString(manEscape(info.Description) + "\n")
+
+			if len(info.Aliases) > 0 {
+				sb.WriteString(fmt.Sprintf(".br\nAliases: %s\n", manEscape(strings.Join(info.Aliases, ", "))))
+			}
+			sb.WriteString(fmt.Sprintf(".br\nRequired permissions: %s\n", manEscape(permissionList(info.Permissions))))
+
+			for _, ex := range info.Examples {
+				sb.WriteString(fmt.Sprintf(".br\n\\fB%s\\fR \\- %s\n", manEscape(ref.Prefix+ex.Input), manEscape(ex.Description)))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// permissionList форматирует список разрешений для вывода
+func permissionList(permissions []string) string {
+	if len(permissions) == 0 {
+		return "none"
+	}
+	return strings.Join(permissions, ", ")
+}
+
+// escapeTableCell экранирует символы, ломающие ячейку таблицы Markdown
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// manEscape экранирует обратную косую черту и управляющие символы в начале строки groff
+func manEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\e")
+	s = strings.ReplaceAll(s, "-", "\\-")
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
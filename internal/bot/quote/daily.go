@@ -0,0 +1,81 @@
+package quote
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// PickDaily детерминированно выбирает цитату дня для чата.
+// Цитаты чата перемешиваются заново в каждом цикле из len(quotes) дней,
+// поэтому одна и та же цитата не повторяется, пока не будут показаны все остальные.
+// Цитаты, добавленные в течение day, участвуют в выборе со следующего дня,
+// чтобы добавление не меняло уже показанную цитату дня.
+func PickDaily(quotes []Quote, chatID string, day time.Time) (Quote, bool) {
+	sorted := dailyCandidates(quotes, day)
+	n := len(sorted)
+	if n == 0 {
+		return Quote{}, false
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	dayNumber := daysSinceEpoch(day)
+	cycle := dayNumber / int64(n)
+	position := int(dayNumber % int64(n))
+
+	order := dailyOrder(n, chatID, cycle)
+	switch {
+	case n == 2:
+		// Две цитаты просто чередуются: цикл в обратном порядке
+		// повторил бы цитату на стыке с предыдущим
+		order = []int{0, 1}
+	case n > 2:
+		// Не допускаем повтора на стыке циклов: последний день прошлого цикла
+		// и первый день нового не должны показывать одну и ту же цитату.
+		// Обмен не затрагивает последний элемент, поэтому прошлый цикл
+		// заканчивается той же цитатой, что и без проверки.
+		if dailyOrder(n, chatID, cycle-1)[n-1] == order[0] {
+			order[0], order[1] = order[1], order[0]
+		}
+	}
+
+	return sorted[order[position]], true
+}
+
+// dailyCandidates возвращает копию цитат, добавленных до начала day.
+// Если таких нет, например в новой коллекции, участвуют все цитаты.
+func dailyCandidates(quotes []Quote, day time.Time) []Quote {
+	y, m, d := day.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, day.Location())
+
+	var result []Quote
+	for _, q := range quotes {
+		if q.AddedAt.Before(start) {
+			result = append(result, q)
+		}
+	}
+	if len(result) == 0 {
+		result = append(result, quotes...)
+	}
+
+	return result
+}
+
+// dailyOrder возвращает перестановку индексов для чата и номера цикла
+func dailyOrder(n int, chatID string, cycle int64) []int {
+	h := fnv.New64a()
+	h.Write([]byte(chatID))
+
+	seed := int64(h.Sum64()) ^ cycle
+	return rand.New(rand.NewSource(seed)).Perm(n)
+}
+
+// daysSinceEpoch возвращает номер календарного дня в часовом поясе day
+func daysSinceEpoch(day time.Time) int64 {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / int64(24*time.Hour/time.Second)
+}
@@ -0,0 +1,232 @@
+package quote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// fileData описывает формат файла с цитатами
+type fileData struct {
+	NextID        int      `json:"next_id"`
+	Quotes        []Quote  `json:"quotes"`
+	Subscriptions []string `json:"subscriptions,omitempty"`
+}
+
+// FileStore хранит цитаты в JSON-файле и сохраняет его после каждого изменения
+type FileStore struct {
+	*MemoryStore
+	path string
+}
+
+// NewFileStore открывает хранилище цитат в файле path.
+// Если файл еще не существует, хранилище начинается с цитат seed,
+// а сам файл создается при первом изменении.
+func NewFileStore(path string, seed []Quote) (*FileStore, error) {
+	s := &FileStore{
+		MemoryStore: NewMemoryStore(nil),
+		path:        path,
+	}
+
+	fd, ok, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.load(seed)
+		return s, nil
+	}
+
+	s.apply(fd)
+	return s, nil
+}
+
+// Reload перечитывает файл хранилища, например после его изменения вручную.
+// Если файла нет, текущее содержимое сохраняется.
+func (s *FileStore) Reload() error {
+	fd, ok, err := readFile(s.path)
+	if err != nil || !ok {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apply(fd)
+	return nil
+}
+
+// readFile читает и разбирает файл хранилища; ok равно false, если файла нет
+func readFile(path string) (fd fileData, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileData{}, false, nil
+	}
+	if err != nil {
+		return fileData{}, false, fmt.Errorf("failed to read quote file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &fd); err != nil {
+		return fileData{}, false, fmt.Errorf("failed to parse quote file %s: %w", path, err)
+	}
+
+	return fd, true, nil
+}
+
+// apply заменяет содержимое хранилища данными из файла
+func (s *FileStore) apply(fd fileData) {
+	s.load(fd.Quotes)
+	if fd.NextID > s.nextID {
+		s.nextID = fd.NextID
+	}
+
+	s.subscribers = make(map[string]bool, len(fd.Subscriptions))
+	for _, chatID := range fd.Subscriptions {
+		s.subscribers[chatID] = true
+	}
+}
+
+// Check проверяет, что файл хранилища можно прочитать, а его каталог существует,
+// чтобы изменения удалось сохранить
+func (s *FileStore) Check() error {
+	dir := filepath.Dir(s.path)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("quote store directory is not available: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("quote store directory %s is not a directory", dir)
+	}
+
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Файл создается при первом изменении
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("quote file is not readable: %w", err)
+	}
+	return f.Close()
+}
+
+// Path возвращает путь к файлу хранилища
+func (s *FileStore) Path() string {
+	return s.path
+}
+
+// Add добавляет цитату и сохраняет файл
+func (s *FileStore) Add(q Quote) (Quote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added, err := s.add(q)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	if err := s.save(); err != nil {
+		s.quotes = s.quotes[:len(s.quotes)-1]
+		s.nextID--
+		return Quote{}, err
+	}
+
+	return added, nil
+}
+
+// Remove удаляет цитату и сохраняет файл
+func (s *FileStore) Remove(chatID string, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.snapshot()
+	if err := s.remove(chatID, id); err != nil {
+		return err
+	}
+
+	if err := s.save(); err != nil {
+		s.quotes = previous
+		return err
+	}
+
+	return nil
+}
+
+// Subscribe подписывает чат на ежедневную цитату и сохраняет файл
+func (s *FileStore) Subscribe(chatID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribers[chatID] {
+		return nil
+	}
+
+	s.subscribers[chatID] = true
+	if err := s.save(); err != nil {
+		delete(s.subscribers, chatID)
+		return err
+	}
+
+	return nil
+}
+
+// Unsubscribe отменяет подписку чата и сохраняет файл
+func (s *FileStore) Unsubscribe(chatID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.subscribers[chatID] {
+		return nil
+	}
+
+	delete(s.subscribers, chatID)
+	if err := s.save(); err != nil {
+		s.subscribers[chatID] = true
+		return err
+	}
+
+	return nil
+}
+
+// save атомарно записывает содержимое хранилища во временный файл и переименовывает его
+func (s *FileStore) save() error {
+	data, err := json.MarshalIndent(fileData{
+		NextID:        s.nextID,
+		Quotes:        s.quotes,
+		Subscriptions: s.subscriberList(),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode quotes: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create quote directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary quote file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write quote file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write quote file: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to set quote file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace quote file: %w", err)
+	}
+
+	return nil
+}
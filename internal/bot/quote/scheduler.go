@@ -0,0 +1,130 @@
+package quote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"command-bot/internal/logging"
+	"command-bot/pkg/transport"
+)
+
+// PostFunc отправляет текст в чат
+type PostFunc func(ctx context.Context, chatID, text string) error
+
+// PostTo возвращает PostFunc, которая отправляет текст через каждый из транспортов.
+// Ошибка одного транспорта не мешает отправке через остальные.
+func PostTo(senders ...transport.Sender) PostFunc {
+	return func(ctx context.Context, chatID, text string) error {
+		var errs []error
+		for _, sender := range senders {
+			if _, err := sender.Send(ctx, chatID, text); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// ComposeFunc формирует сообщение с цитатой дня для чата
+type ComposeFunc func(chatID string, day time.Time) (string, error)
+
+// Scheduler ежедневно в заданное время рассылает цитату дня подписанным чатам
+type Scheduler struct {
+	subscriptions SubscriptionStore
+	compose       ComposeFunc
+	post          PostFunc
+	at            time.Duration
+	location      *time.Location
+	logger        *slog.Logger
+	now           func() time.Time
+}
+
+// NewScheduler создает планировщик ежедневной рассылки.
+// Время рассылки at задается в формате "15:04" в часовом поясе location.
+// Сбои рассылки пишутся в logger; при nil используется slog.Default().
+func NewScheduler(subscriptions SubscriptionStore, compose ComposeFunc, post PostFunc, at string, location *time.Location, logger *slog.Logger) (*Scheduler, error) {
+	offset, err := ParseClock(at)
+	if err != nil {
+		return nil, err
+	}
+
+	if location == nil {
+		location = time.Local
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Scheduler{
+		subscriptions: subscriptions,
+		compose:       compose,
+		post:          post,
+		at:            offset,
+		location:      location,
+		logger:        logger,
+		now:           time.Now,
+	}, nil
+}
+
+// ParseClock разбирает время суток в формате "15:04" и возвращает смещение от полуночи
+func ParseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM: %w", value, err)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Next возвращает ближайший момент рассылки после now
+func (s *Scheduler) Next(now time.Time) time.Time {
+	now = now.In(s.location)
+	y, m, d := now.Date()
+
+	next := time.Date(y, m, d, 0, 0, 0, 0, s.location).Add(s.at)
+	if !next.After(now) {
+		next = time.Date(y, m, d+1, 0, 0, 0, 0, s.location).Add(s.at)
+	}
+
+	return next
+}
+
+// Run выполняет рассылку каждый день до отмены контекста
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		next := s.Next(s.now())
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.PostAll(ctx, next)
+		}
+	}
+}
+
+// PostAll отправляет цитату дня всем подписанным чатам
+func (s *Scheduler) PostAll(ctx context.Context, day time.Time) {
+	chats, err := s.subscriptions.Subscribers()
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load daily quote subscribers", logging.KeyError, err)
+		return
+	}
+
+	for _, chatID := range chats {
+		text, err := s.compose(chatID, day)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to compose daily quote", logging.KeyChat, chatID, logging.KeyError, err)
+			continue
+		}
+
+		if err := s.post(ctx, chatID, text); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to post daily quote", logging.KeyChat, chatID, logging.KeyError, err)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Пакет quote предоставляет хранилище цитат для команды quote.
+package quote
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ошибки хранилища цитат
+var (
+	ErrNotFound  = errors.New("quote not found")
+	ErrForbidden = errors.New("quote belongs to another collection")
+	ErrEmptyText = errors.New("quote text is empty")
+)
+
+// Quote представляет цитату с автором.
+// Пустой ChatID означает, что цитата входит в общую коллекцию и видна во всех чатах.
+type Quote struct {
+	ID      int       `json:"id"`
+	Text    string    `json:"text"`
+	Author  string    `json:"author"`
+	ChatID  string    `json:"chat_id,omitempty"`
+	AddedBy string    `json:"added_by,omitempty"`
+	AddedAt time.Time `json:"added_at,omitempty"`
+}
+
+// Store описывает хранилище цитат с коллекциями по чатам
+type Store interface {
+	// List возвращает цитаты, видимые в чате: общую коллекцию и коллекцию чата
+	List(chatID string) ([]Quote, error)
+	// Get возвращает цитату по идентификатору, если она видна в чате
+	Get(chatID string, id int) (Quote, error)
+	// Add добавляет цитату и возвращает ее с присвоенным идентификатором
+	Add(q Quote) (Quote, error)
+	// Remove удаляет цитату из коллекции чата
+	Remove(chatID string, id int) error
+}
+
+// SubscriptionStore хранит список чатов, подписанных на ежедневную цитату
+type SubscriptionStore interface {
+	Subscribe(chatID string) error
+	Unsubscribe(chatID string) error
+	Subscribers() ([]string, error)
+}
+
+// DefaultQuotes возвращает общую коллекцию цитат, с которой начинает новое хранилище
+func DefaultQuotes() []Quote {
+	return []Quote{
+		{Text: "The only way to do great work is to love what you do.", Author: "Steve Jobs"},
+		{Text: "Life is what happens when you're busy making other plans.", Author: "John Lennon"},
+		{Text: "The future belongs to those who believe in the beauty of their dreams.", Author: "Eleanor Roosevelt"},
+		{Text: "In the middle of difficulty lies opportunity.", Author: "Albert Einstein"},
+		{Text: "Success is not final, failure is not fatal: It is the courage to continue that counts.", Author: "Winston Churchill"},
+		{Text: "The best way to predict the future is to create it.", Author: "Peter Drucker"},
+		{Text: "Believe you can and you're halfway there.", Author: "Theodore Roosevelt"},
+		{Text: "It does not matter how slowly you go as long as you do not stop.", Author: "Confucius"},
+		{Text: "The only limit to our realization of tomorrow will be our doubts of today.", Author: "Franklin D. Roosevelt"},
+		{Text: "The journey of a thousand miles begins with one step.", Author: "Lao Tzu"},
+		{Text: "Don't watch the clock; do what it does. Keep going.", Author: "Sam Levenson"},
+		{Text: "The only person you are destined to become is the person you decide to be.", Author: "Ralph Waldo Emerson"},
+		{Text: "The best revenge is massive success.", Author: "Frank Sinatra"},
+		{Text: "The purpose of our lives is to be happy.", Author: "Dalai Lama"},
+		{Text: "You miss 100% of the shots you don't take.", Author: "Wayne Gretzky"},
+	}
+}
+
+// MemoryStore хранит цитаты в памяти процесса
+type MemoryStore struct {
+	quotes      []Quote
+	nextID      int
+	subscribers map[string]bool
+	mu          sync.RWMutex
+}
+
+// NewMemoryStore создает хранилище в памяти с заданными начальными цитатами.
+// Начальным цитатам без идентификатора присваиваются последовательные номера.
+func NewMemoryStore(seed []Quote) *MemoryStore {
+	s := &MemoryStore{nextID: 1, subscribers: make(map[string]bool)}
+	s.load(seed)
+	return s
+}
+
+// load заменяет содержимое хранилища, присваивая недостающие идентификаторы
+func (s *MemoryStore) load(quotes []Quote) {
+	s.quotes = make([]Quote, 0, len(quotes))
+	s.nextID = 1
+
+	for _, q := range quotes {
+		if q.ID >= s.nextID {
+			s.nextID = q.ID + 1
+		}
+	}
+
+	for _, q := range quotes {
+		if q.ID == 0 {
+			q.ID = s.nextID
+			s.nextID++
+		}
+		s.quotes = append(s.quotes, q)
+	}
+
+	sort.Slice(s.quotes, func(i, j int) bool {
+		return s.quotes[i].ID < s.quotes[j].ID
+	})
+}
+
+// snapshot возвращает копию всех цитат хранилища
+func (s *MemoryStore) snapshot() []Quote {
+	quotes := make([]Quote, len(s.quotes))
+	copy(quotes, s.quotes)
+	return quotes
+}
+
+// List возвращает цитаты общей коллекции и коллекции чата
+func (s *MemoryStore) List(chatID string) ([]Quote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var quotes []Quote
+	for _, q := range s.quotes {
+		if visible(q, chatID) {
+			quotes = append(quotes, q)
+		}
+	}
+
+	return quotes, nil
+}
+
+// Get возвращает цитату по идентификатору
+func (s *MemoryStore) Get(chatID string, id int) (Quote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, q := range s.quotes {
+		if q.ID == id && visible(q, chatID) {
+			return q, nil
+		}
+	}
+
+	return Quote{}, ErrNotFound
+}
+
+// Add добавляет цитату в коллекцию чата, указанного в q.ChatID
+func (s *MemoryStore) Add(q Quote) (Quote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.add(q)
+}
+
+func (s *MemoryStore) add(q Quote) (Quote, error) {
+	q.Text = strings.TrimSpace(q.Text)
+	q.Author = strings.TrimSpace(q.Author)
+	if q.Text == "" {
+		return Quote{}, ErrEmptyText
+	}
+
+	q.ID = s.nextID
+	if q.AddedAt.IsZero() {
+		q.AddedAt = time.Now()
+	}
+	s.nextID++
+	s.quotes = append(s.quotes, q)
+
+	return q, nil
+}
+
+// Remove удаляет цитату из коллекции чата.
+// Цитаты общей коллекции и других чатов удалить нельзя.
+func (s *MemoryStore) Remove(chatID string, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.remove(chatID, id)
+}
+
+func (s *MemoryStore) remove(chatID string, id int) error {
+	for i, q := range s.quotes {
+		if q.ID != id {
+			continue
+		}
+		if !visible(q, chatID) {
+			return ErrNotFound
+		}
+		if q.ChatID != chatID {
+			return ErrForbidden
+		}
+		s.quotes = append(s.quotes[:i], s.quotes[i+1:]...)
+		return nil
+	}
+
+	return ErrNotFound
+}
+
+// Subscribe подписывает чат на ежедневную цитату
+func (s *MemoryStore) Subscribe(chatID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscribers[chatID] = true
+	return nil
+}
+
+// Unsubscribe отменяет подписку чата на ежедневную цитату
+func (s *MemoryStore) Unsubscribe(chatID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscribers, chatID)
+	return nil
+}
+
+// Subscribers возвращает отсортированный список подписанных чатов
+func (s *MemoryStore) Subscribers() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.subscriberList(), nil
+}
+
+func (s *MemoryStore) subscriberList() []string {
+	chats := make([]string, 0, len(s.subscribers))
+	for chatID := range s.subscribers {
+		chats = append(chats, chatID)
+	}
+	sort.Strings(chats)
+
+	return chats
+}
+
+// visible сообщает, видна ли цитата в указанном чате
+func visible(q Quote, chatID string) bool {
+	return q.ChatID == "" || q.ChatID == chatID
+}
+
+// Search возвращает цитаты, текст или автор которых содержит term без учета регистра
+func Search(quotes []Quote, term string) []Quote {
+	term = strings.ToLower(strings.TrimSpace(term))
+
+	var found []Quote
+	for _, q := range quotes {
+		if strings.Contains(strings.ToLower(q.Text), term) || strings.Contains(strings.ToLower(q.Author), term) {
+			found = append(found, q)
+		}
+	}
+
+	return found
+}
+
+// ByAuthor возвращает цитаты, автор которых содержит author без учета регистра
+func ByAuthor(quotes []Quote, author string) []Quote {
+	author = strings.ToLower(strings.TrimSpace(author))
+
+	var found []Quote
+	for _, q := range quotes {
+		if strings.Contains(strings.ToLower(q.Author), author) {
+			found = append(found, q)
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,102 @@
+// Пакет ratelimit ограничивает частоту команд отдельных пользователей.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// maxIdleBuckets — число корзин, после которого полностью восстановившиеся корзины удаляются
+const maxIdleBuckets = 10000
+
+// bucket — корзина токенов одного пользователя
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter ограничивает частоту запросов по ключу (обычно идентификатору пользователя)
+// по алгоритму token bucket. Нулевой указатель на Limiter пропускает все запросы.
+type Limiter struct {
+	// Скорость восстановления в токенах в секунду и емкость корзины
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewLimiter создает ограничитель на perMinute запросов в минуту с запасом burst.
+// При perMinute <= 0 возвращает nil, то есть ограничение отключено.
+func NewLimiter(perMinute, burst int) *Limiter {
+	return NewLimiterWithClock(perMinute, burst, time.Now)
+}
+
+// NewLimiterWithClock создает ограничитель с заданным источником времени
+func NewLimiterWithClock(perMinute, burst int, now func() time.Time) *Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Limiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		now:     now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow расходует токен ключа key. Если токенов нет, возвращает false
+// и время, через которое появится следующий токен.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.prune(now)
+		}
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = l.refill(b, now)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// refill возвращает число токенов корзины на момент now
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed <= 0 {
+		return b.tokens
+	}
+
+	return min(l.burst, b.tokens+elapsed*l.rate)
+}
+
+// prune удаляет полностью восстановившиеся корзины: их состояние совпадает с новой
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
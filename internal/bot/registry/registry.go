@@ -0,0 +1,180 @@
+// Пакет registry собирает обработчик команд из декларативного списка команд,
+// общего для всех исполняемых файлов бота.
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"command-bot/internal/bot/command"
+	"command-bot/internal/bot/command/commands"
+	"command-bot/internal/bot/quote"
+	"command-bot/internal/config"
+	pkgcommand "command-bot/pkg/command"
+)
+
+// Options задает параметры сборки реестра команд
+type Options struct {
+	// Prefix — префикс команд, по умолчанию "/"
+	Prefix string
+	// Disabled — имена команд, которые не нужно регистрировать
+	Disabled []string
+	// QuoteStore — хранилище цитат; если не задано, используется хранилище в памяти
+	QuoteStore quote.Store
+	// PingTargets — цели, доступность которых разрешено проверять командой ping
+	PingTargets []string
+	// PingTimeout ограничивает время проверки цели командой ping
+	PingTimeout time.Duration
+	// Weather — параметры команды weather; незаданные поля берутся по умолчанию
+	Weather commands.WeatherOptions
+	// QuoteLocation — часовой пояс смены цитаты дня; nil означает местное время
+	QuoteLocation *time.Location
+	// Middleware — промежуточные обработчики выполнения команд, например метрики
+	Middleware []pkgcommand.Middleware
+}
+
+// Bot — собранный обработчик команд и команды, к которым нужен прямой доступ
+type Bot struct {
+	Handler *command.Handler
+	// Quote равен nil, если команда quote отключена
+	Quote *commands.QuoteCommand
+	// Weather равен nil, если команда weather отключена
+	Weather *commands.WeatherCommand
+	// Names — имена зарегистрированных команд в порядке регистрации
+	Names []string
+}
+
+// Spec описывает команду реестра и способ ее создания
+type Spec struct {
+	Name string
+	New  func(bot *Bot, opts Options) pkgcommand.Command
+}
+
+// Specs возвращает список всех команд бота в порядке регистрации.
+// help регистрируется последней, чтобы видеть остальные команды.
+func Specs() []Spec {
+	return []Spec{
+		{Name: "ping", New: func(bot *Bot, opts Options) pkgcommand.Command {
+			ping := commands.NewPingCommandWithTargets(opts.PingTargets)
+			ping.SetTimeout(opts.PingTimeout)
+			return ping
+		}},
+		{Name: "echo", New: func(bot *Bot, opts Options) pkgcommand.Command {
+			return commands.NewEchoCommand()
+		}},
+		{Name: "time", New: func(bot *Bot, opts Options) pkgcommand.Command {
+			return commands.NewTimeCommand()
+		}},
+		{Name: "random", New: func(bot *Bot, opts Options) pkgcommand.Command {
+			return commands.NewRandomCommand()
+		}},
+		{Name: "weather", New: func(bot *Bot, opts Options) pkgcommand.Command {
+			bot.Weather = commands.NewWeatherCommandWithOptions(opts.Weather)
+			return bot.Weather
+		}},
+		{Name: "calc", New: func(bot *Bot, opts Options) pkgcommand.Command {
+			return commands.NewCalcCommand()
+		}},
+		{Name: "quote", New: func(bot *Bot, opts Options) pkgcommand.Command {
+			store := opts.QuoteStore
+			if store == nil {
+				store = quote.NewMemoryStore(quote.DefaultQuotes())
+			}
+			bot.Quote = commands.NewQuoteCommandWithStore(store)
+			if opts.QuoteLocation != nil {
+				bot.Quote.SetLocation(opts.QuoteLocation)
+			}
+			return bot.Quote
+		}},
+		{Name: "help", New: func(bot *Bot, opts Options) pkgcommand.Command {
+			return commands.NewHelpCommand(bot.Handler)
+		}},
+	}
+}
+
+// Names возвращает имена всех команд реестра
+func Names() []string {
+	specs := Specs()
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+	return names
+}
+
+// Build создает обработчик и регистрирует в нем все включенные команды
+func Build(opts Options) (*Bot, error) {
+	if opts.Prefix == "" {
+		opts.Prefix = "/"
+	}
+
+	disabled, err := disabledSet(opts.Disabled)
+	if err != nil {
+		return nil, err
+	}
+
+	bot := &Bot{Handler: command.NewHandler(opts.Prefix)}
+	bot.Handler.Use(opts.Middleware...)
+
+	for _, spec := range Specs() {
+		if disabled[spec.Name] {
+			continue
+		}
+
+		if err := bot.Handler.RegisterCommand(spec.New(bot, opts)); err != nil {
+			return nil, fmt.Errorf("failed to register %s command: %w", spec.Name, err)
+		}
+		bot.Names = append(bot.Names, spec.Name)
+	}
+
+	return bot, nil
+}
+
+// disabledSet проверяет имена отключаемых команд и возвращает их множество
+func disabledSet(names []string) (map[string]bool, error) {
+	known := make(map[string]bool)
+	for _, name := range Names() {
+		known[name] = true
+	}
+
+	set := make(map[string]bool, len(names))
+	var unknown []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !known[name] {
+			unknown = append(unknown, name)
+			continue
+		}
+		set[name] = true
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown commands in disabled list: %s (known: %s)",
+			strings.Join(unknown, ", "), strings.Join(Names(), ", "))
+	}
+
+	return set, nil
+}
+
+// OptionsFromConfig переносит настройки команд из конфигурации в параметры реестра
+func OptionsFromConfig(cfg config.Config, store quote.Store) Options {
+	weather := cfg.Commands.Weather
+
+	return Options{
+		Prefix:      cfg.Bot.Prefix,
+		Disabled:    cfg.Bot.DisabledCommands,
+		QuoteStore:  store,
+		PingTargets: cfg.Commands.Ping.Targets,
+		PingTimeout: cfg.Commands.Ping.Timeout.Std(),
+		Weather: commands.WeatherOptions{
+			GeocodingURL: weather.GeocodingURL,
+			ForecastURL:  weather.ForecastURL,
+			Timezone:     weather.Timezone,
+			Timeout:      weather.Timeout.Std(),
+		},
+		QuoteLocation: cfg.Commands.Quote.Location(),
+	}
+}
@@ -0,0 +1,160 @@
+// Пакет certs загружает сертификаты TLS сервиса и подхватывает их
+// обновление на диске без перезапуска.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"command-bot/internal/logging"
+)
+
+// DefaultCheckInterval — как часто при новых соединениях проверяется, не изменились ли файлы
+const DefaultCheckInterval = 10 * time.Second
+
+// Options задает файлы сертификатов и параметры TLS
+type Options struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile — сертификаты центров, которыми подписаны клиентские сертификаты (mTLS)
+	ClientCAFile string
+	// ClientAuth — требование к клиентскому сертификату
+	ClientAuth tls.ClientAuthType
+	// MinVersion — минимальная версия TLS, например tls.VersionTLS12
+	MinVersion uint16
+	// CheckInterval — период проверки файлов; при нуле используется DefaultCheckInterval
+	CheckInterval time.Duration
+	// Logger получает сообщения о перезагрузке; при nil используется slog.Default()
+	Logger *slog.Logger
+}
+
+// Manager хранит текущие сертификаты и перечитывает их, когда файлы меняются.
+// Если новые файлы не загружаются, например записаны не полностью,
+// продолжают действовать прежние сертификаты.
+type Manager struct {
+	opts Options
+	now  func() time.Time
+
+	mu        sync.Mutex
+	config    *tls.Config
+	modTimes  []time.Time
+	lastCheck time.Time
+}
+
+// NewManager загружает сертификаты; ошибка означает, что сервис не сможет принимать соединения
+func NewManager(opts Options) (*Manager, error) {
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = DefaultCheckInterval
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	m := &Manager{opts: opts, now: time.Now}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TLSConfig возвращает конфигурацию для tls.NewListener или http.Server.TLSConfig.
+// Каждое соединение получает актуальные сертификаты.
+func (m *Manager) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: m.opts.MinVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return m.current(), nil
+		},
+	}
+}
+
+// Reload перечитывает файлы сертификатов
+func (m *Manager) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.reload()
+}
+
+// current возвращает действующую конфигурацию, перед этим проверяя файлы не чаще CheckInterval
+func (m *Manager) current() *tls.Config {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.Sub(m.lastCheck) >= m.opts.CheckInterval {
+		m.lastCheck = now
+		if m.changed() {
+			if err := m.reload(); err != nil {
+				m.opts.Logger.Error("Failed to reload TLS certificates, keeping the previous ones", logging.KeyError, err)
+			} else {
+				m.opts.Logger.Info("TLS certificates reloaded", "cert_file", m.opts.CertFile)
+			}
+		}
+	}
+
+	return m.config
+}
+
+// reload загружает файлы; вызывается под m.mu
+func (m *Manager) reload() error {
+	modTimes := m.statFiles()
+
+	cert, err := tls.LoadX509KeyPair(m.opts.CertFile, m.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   m.opts.MinVersion,
+		ClientAuth:   m.opts.ClientAuth,
+	}
+
+	if m.opts.ClientCAFile != "" {
+		data, err := os.ReadFile(m.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificates found in client CA file %s", m.opts.ClientCAFile)
+		}
+		config.ClientCAs = pool
+	}
+
+	m.config = config
+	m.modTimes = modTimes
+	m.lastCheck = m.now()
+	return nil
+}
+
+// changed сообщает, изменился ли какой-либо из файлов после последней загрузки
+func (m *Manager) changed() bool {
+	for i, modTime := range m.statFiles() {
+		if !modTime.Equal(m.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// statFiles возвращает время изменения файлов; недоступный файл дает нулевое время
+func (m *Manager) statFiles() []time.Time {
+	files := []string{m.opts.CertFile, m.opts.KeyFile, m.opts.ClientCAFile}
+	modTimes := make([]time.Time, len(files))
+	for i, file := range files {
+		if file == "" {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil {
+			modTimes[i] = info.ModTime()
+		}
+	}
+	return modTimes
+}
@@ -0,0 +1,410 @@
+// Пакет config описывает конфигурацию бота и загружает ее из файла,
+// переменных окружения и флагов командной строки.
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"time"
+
+	"command-bot/internal/auth"
+	"command-bot/internal/certs"
+	"command-bot/internal/logging"
+)
+
+// Config — полная конфигурация бота.
+// Теги задают имена ключей в файлах JSON, YAML и TOML; тот же путь ключа
+// используется для переменных окружения и флагов (см. Load).
+type Config struct {
+	Server   ServerConfig   `json:"server" yaml:"server" toml:"server"`
+	Log      LogConfig      `json:"log" yaml:"log" toml:"log"`
+	Bot      BotConfig      `json:"bot" yaml:"bot" toml:"bot"`
+	CLI      CLIConfig      `json:"cli" yaml:"cli" toml:"cli"`
+	Commands CommandsConfig `json:"commands" yaml:"commands" toml:"commands"`
+	// Permissions и RateLimit применяются сервисом к каждому запросу
+	Permissions PermissionsConfig `json:"permissions" yaml:"permissions" toml:"permissions"`
+	RateLimit   RateLimitConfig   `json:"rate_limit" yaml:"rate_limit" toml:"rate_limit"`
+	Tracing     TracingConfig     `json:"tracing" yaml:"tracing" toml:"tracing"`
+	Auth        AuthConfig        `json:"auth" yaml:"auth" toml:"auth"`
+}
+
+// ServerConfig — параметры HTTP API сервиса
+type ServerConfig struct {
+	Listen        string `json:"listen" yaml:"listen" toml:"listen"`
+	DefaultUserID string `json:"default_user_id" yaml:"default_user_id" toml:"default_user_id"`
+	DefaultChatID string `json:"default_chat_id" yaml:"default_chat_id" toml:"default_chat_id"`
+	// AdminToken включает административные эндпоинты, доступные по заголовку
+	// "Authorization: Bearer <token>"; пустая строка их отключает
+	AdminToken string `json:"admin_token" yaml:"admin_token" toml:"admin_token" config:"secret"`
+	// ShutdownTimeout — сколько ждать завершения выполняющихся команд при остановке
+	ShutdownTimeout Duration        `json:"shutdown_timeout" yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	TLS             TLSConfig       `json:"tls" yaml:"tls" toml:"tls"`
+	Batch           BatchConfig     `json:"batch" yaml:"batch" toml:"batch"`
+	WebSocket       WebSocketConfig `json:"websocket" yaml:"websocket" toml:"websocket"`
+	GRPC            GRPCConfig      `json:"grpc" yaml:"grpc" toml:"grpc"`
+}
+
+// GRPCConfig — параметры gRPC API; изменения применяются после перезапуска.
+// gRPC-сервер использует сертификаты из server.tls.
+type GRPCConfig struct {
+	// Listen — адрес gRPC-сервера; пустая строка его отключает
+	Listen string `json:"listen" yaml:"listen" toml:"listen"`
+	// Reflection позволяет клиентам вроде grpcurl получать описание сервиса
+	Reflection bool `json:"reflection" yaml:"reflection" toml:"reflection"`
+}
+
+// WebSocketConfig — параметры сессий /ws; изменения применяются к новым сессиям
+type WebSocketConfig struct {
+	// MaxMessageBytes — наибольший размер сообщения клиента
+	MaxMessageBytes int `json:"max_message_bytes" yaml:"max_message_bytes" toml:"max_message_bytes"`
+	// SendBuffer — сколько сообщений может ждать отправки клиенту; клиент,
+	// который не успевает их читать, отключается
+	SendBuffer int `json:"send_buffer" yaml:"send_buffer" toml:"send_buffer"`
+	// MaxInFlight — сколько команд одной сессии выполняются одновременно
+	MaxInFlight int `json:"max_in_flight" yaml:"max_in_flight" toml:"max_in_flight"`
+	// PingInterval — как часто сервер проверяет, что клиент на связи
+	PingInterval Duration `json:"ping_interval" yaml:"ping_interval" toml:"ping_interval"`
+	// PongTimeout — сколько ждать ответа на ping, а также сообщения auth после подключения
+	PongTimeout Duration `json:"pong_timeout" yaml:"pong_timeout" toml:"pong_timeout"`
+	// AllowedOrigins — шаблоны узлов заголовка Origin, например "app.example.com"
+	// или "*.example.com", с которых разрешено подключаться. Если список пуст,
+	// Origin должен совпадать с узлом запроса; клиенты без Origin допускаются всегда.
+	AllowedOrigins []string `json:"allowed_origins" yaml:"allowed_origins" toml:"allowed_origins"`
+}
+
+// BatchConfig — ограничения /commands/batch
+type BatchConfig struct {
+	// MaxCommands — наибольшее число команд в одном запросе
+	MaxCommands int `json:"max_commands" yaml:"max_commands" toml:"max_commands"`
+	// Parallelism — сколько команд одного запроса выполняются одновременно
+	Parallelism int `json:"parallelism" yaml:"parallelism" toml:"parallelism"`
+	// MaxCommandBytes — наибольший размер тела запроса /command; тело
+	// /commands/batch ограничено MaxCommands * MaxCommandBytes
+	MaxCommandBytes int `json:"max_command_bytes" yaml:"max_command_bytes" toml:"max_command_bytes"`
+}
+
+// TLSConfig — параметры TLS сервиса. Файлы сертификатов перечитываются
+// при их изменении, остальные параметры — только при перезапуске.
+type TLSConfig struct {
+	// CertFile и KeyFile включают TLS; пустые строки — обычный HTTP
+	CertFile string `json:"cert_file" yaml:"cert_file" toml:"cert_file"`
+	KeyFile  string `json:"key_file" yaml:"key_file" toml:"key_file"`
+	// MinVersion — минимальная версия TLS: "1.2" или "1.3"
+	MinVersion string `json:"min_version" yaml:"min_version" toml:"min_version"`
+	// ClientAuth — проверка клиентских сертификатов (mTLS): "none", "optional" или "required"
+	ClientAuth string `json:"client_auth" yaml:"client_auth" toml:"client_auth"`
+	// ClientCAFile — сертификаты центров, которыми подписаны клиентские сертификаты
+	ClientCAFile string `json:"client_ca_file" yaml:"client_ca_file" toml:"client_ca_file"`
+}
+
+// Enabled сообщает, включен ли TLS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// Options возвращает параметры загрузки сертификатов
+func (c TLSConfig) Options() certs.Options {
+	opts := certs.Options{
+		CertFile:     c.CertFile,
+		KeyFile:      c.KeyFile,
+		ClientCAFile: c.ClientCAFile,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.MinVersion == "1.3" {
+		opts.MinVersion = tls.VersionTLS13
+	}
+	switch c.ClientAuth {
+	case "optional":
+		opts.ClientAuth = tls.VerifyClientCertIfGiven
+	case "required":
+		opts.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return opts
+}
+
+// LogConfig — параметры журнала сервиса
+type LogConfig struct {
+	// File — путь к файлу журнала; пустая строка означает stdout, откуда журнал забирает journald
+	File string `json:"file" yaml:"file" toml:"file"`
+	// Format — формат записей: "text" или "json"
+	Format string `json:"format" yaml:"format" toml:"format"`
+	// Level — минимальный уровень записей: debug, info, warn или error
+	Level string `json:"level" yaml:"level" toml:"level"`
+	// MaxSizeMB — размер файла в мегабайтах, после которого он ротируется; 0 отключает ротацию по размеру
+	MaxSizeMB int `json:"max_size_mb" yaml:"max_size_mb" toml:"max_size_mb"`
+	// RotateEvery — период ротации по времени, например "24h"; 0 отключает ее
+	RotateEvery Duration `json:"rotate_every" yaml:"rotate_every" toml:"rotate_every"`
+	// MaxBackups — сколько архивных копий хранить; 0 хранит все
+	MaxBackups int `json:"max_backups" yaml:"max_backups" toml:"max_backups"`
+	// Compress сжимает архивные копии gzip
+	Compress bool `json:"compress" yaml:"compress" toml:"compress"`
+}
+
+// RotateOptions возвращает параметры ротации файла журнала
+func (c LogConfig) RotateOptions() logging.RotateOptions {
+	return logging.RotateOptions{
+		MaxSize:    int64(c.MaxSizeMB) << 20,
+		Interval:   c.RotateEvery.Std(),
+		MaxBackups: c.MaxBackups,
+		Compress:   c.Compress,
+	}
+}
+
+// BotConfig — общие параметры обработчика команд
+type BotConfig struct {
+	Prefix           string   `json:"prefix" yaml:"prefix" toml:"prefix"`
+	DisabledCommands []string `json:"disabled_commands" yaml:"disabled_commands" toml:"disabled_commands"`
+}
+
+// CLIConfig — параметры интерактивного режима
+type CLIConfig struct {
+	UserID string `json:"user_id" yaml:"user_id" toml:"user_id"`
+	ChatID string `json:"chat_id" yaml:"chat_id" toml:"chat_id"`
+}
+
+// CommandsConfig — разделы настроек отдельных команд
+type CommandsConfig struct {
+	Ping    PingConfig    `json:"ping" yaml:"ping" toml:"ping"`
+	Weather WeatherConfig `json:"weather" yaml:"weather" toml:"weather"`
+	Quote   QuoteConfig   `json:"quote" yaml:"quote" toml:"quote"`
+}
+
+// PingConfig — настройки команды ping
+type PingConfig struct {
+	// Targets — цели "host" или "host:port", которые разрешено проверять
+	Targets []string `json:"targets" yaml:"targets" toml:"targets"`
+	Timeout Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+}
+
+// WeatherConfig — настройки команды weather
+type WeatherConfig struct {
+	GeocodingURL string   `json:"geocoding_url" yaml:"geocoding_url" toml:"geocoding_url"`
+	ForecastURL  string   `json:"forecast_url" yaml:"forecast_url" toml:"forecast_url"`
+	Timezone     string   `json:"timezone" yaml:"timezone" toml:"timezone"`
+	Timeout      Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+}
+
+// QuoteConfig — настройки команды quote
+type QuoteConfig struct {
+	File string `json:"file" yaml:"file" toml:"file"`
+	// DailyTime — время рассылки цитаты дня в формате "15:04"; пустая строка отключает рассылку
+	DailyTime string `json:"daily_time" yaml:"daily_time" toml:"daily_time"`
+	// Timezone — часовой пояс для смены дня и рассылки; пустая строка означает местное время
+	Timezone string `json:"timezone" yaml:"timezone" toml:"timezone"`
+}
+
+// PermissionsConfig — таблица разрешений пользователей
+type PermissionsConfig struct {
+	// Default — разрешения любого пользователя
+	Default []string `json:"default" yaml:"default" toml:"default"`
+	// Users — дополнительные разрешения по идентификатору пользователя.
+	// Задается только в файле: переменные окружения и флаги для нее не создаются.
+	Users map[string][]string `json:"users" yaml:"users" toml:"users"`
+}
+
+// For возвращает все разрешения пользователя
+func (c PermissionsConfig) For(userID string) []string {
+	permissions := make([]string, 0, len(c.Default)+len(c.Users[userID]))
+	permissions = append(permissions, c.Default...)
+	return append(permissions, c.Users[userID]...)
+}
+
+// RateLimitConfig — ограничение частоты команд одного пользователя
+type RateLimitConfig struct {
+	// PerMinute — среднее число команд в минуту; 0 отключает ограничение
+	PerMinute int `json:"per_minute" yaml:"per_minute" toml:"per_minute"`
+	// Burst — сколько команд можно выполнить подряд без ожидания
+	Burst int `json:"burst" yaml:"burst" toml:"burst"`
+}
+
+// TracingConfig — параметры трассировки
+type TracingConfig struct {
+	// Exporter — куда записывать спаны: "none", "stdout" или "file"
+	Exporter string `json:"exporter" yaml:"exporter" toml:"exporter"`
+	// File — файл спанов для экспортера "file", по одному JSON-объекту в строке
+	File string `json:"file" yaml:"file" toml:"file"`
+}
+
+// AuthConfig — аутентификация клиентов HTTP API. Она включается, если задан
+// хотя бы один API-ключ, клиентский сертификат или секрет JWT; тогда user_id
+// берется из учетных данных.
+type AuthConfig struct {
+	// APIKeys — API-ключи по имени клиента.
+	// Задается только в файле: переменные окружения и флаги для нее не создаются.
+	APIKeys map[string]APIKeyConfig `json:"api_keys" yaml:"api_keys" toml:"api_keys" config:"secret"`
+	JWT     JWTConfig               `json:"jwt" yaml:"jwt" toml:"jwt"`
+	// ClientCerts — пользователи по имени из клиентского сертификата (CN или SAN) при mTLS.
+	// Задается только в файле.
+	ClientCerts map[string]ClientCertConfig `json:"client_certs" yaml:"client_certs" toml:"client_certs"`
+}
+
+// ClientCertConfig — пользователь, которому соответствует клиентский сертификат
+type ClientCertConfig struct {
+	UserID string `json:"user_id" yaml:"user_id" toml:"user_id"`
+	// Chats — разрешенные чаты; пустой список — любые
+	Chats []string `json:"chats" yaml:"chats" toml:"chats"`
+}
+
+// APIKeyConfig — API-ключ клиента
+type APIKeyConfig struct {
+	// SHA256 — SHA-256 ключа в шестнадцатеричном виде: сам ключ в конфигурации не хранится
+	SHA256 string `json:"sha256" yaml:"sha256" toml:"sha256" config:"secret"`
+	UserID string `json:"user_id" yaml:"user_id" toml:"user_id"`
+	// Chats — разрешенные чаты; пустой список — любые
+	Chats []string `json:"chats" yaml:"chats" toml:"chats"`
+}
+
+// JWTConfig — проверка токенов JWT, подписанных HS256
+type JWTConfig struct {
+	// Secret — общий ключ подписи не короче 32 байт; пустая строка отключает токены
+	Secret   string   `json:"secret" yaml:"secret" toml:"secret" config:"secret"`
+	Issuer   string   `json:"issuer" yaml:"issuer" toml:"issuer"`
+	Audience string   `json:"audience" yaml:"audience" toml:"audience"`
+	Leeway   Duration `json:"leeway" yaml:"leeway" toml:"leeway"`
+}
+
+// Options возвращает параметры аутентификации; ключи упорядочены по имени клиента
+func (c AuthConfig) Options() auth.Options {
+	names := make([]string, 0, len(c.APIKeys))
+	for name := range c.APIKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	opts := auth.Options{
+		JWT: auth.JWTOptions{
+			Secret:   []byte(c.JWT.Secret),
+			Issuer:   c.JWT.Issuer,
+			Audience: c.JWT.Audience,
+			Leeway:   c.JWT.Leeway.Std(),
+		},
+	}
+	for _, name := range names {
+		key := c.APIKeys[name]
+		opts.APIKeys = append(opts.APIKeys, auth.APIKey{SHA256: key.SHA256, UserID: key.UserID, Chats: key.Chats})
+	}
+
+	subjects := make([]string, 0, len(c.ClientCerts))
+	for subject := range c.ClientCerts {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+	for _, subject := range subjects {
+		cert := c.ClientCerts[subject]
+		opts.ClientCerts = append(opts.ClientCerts, auth.ClientCert{Subject: subject, UserID: cert.UserID, Chats: cert.Chats})
+	}
+
+	return opts
+}
+
+// Default возвращает конфигурацию по умолчанию
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Listen:          ":8080",
+			DefaultUserID:   "api-user",
+			DefaultChatID:   "api-chat",
+			ShutdownTimeout: Duration(15 * time.Second),
+			TLS: TLSConfig{
+				MinVersion: "1.2",
+				ClientAuth: "none",
+			},
+			Batch: BatchConfig{
+				MaxCommands:     50,
+				Parallelism:     4,
+				MaxCommandBytes: 16 << 10,
+			},
+			WebSocket: WebSocketConfig{
+				MaxMessageBytes: 64 << 10,
+				SendBuffer:      64,
+				MaxInFlight:     8,
+				PingInterval:    Duration(30 * time.Second),
+				PongTimeout:     Duration(10 * time.Second),
+			},
+			GRPC: GRPCConfig{
+				Reflection: true,
+			},
+		},
+		Log: LogConfig{
+			Format:     "text",
+			Level:      "info",
+			MaxSizeMB:  100,
+			MaxBackups: 7,
+			Compress:   true,
+		},
+		Bot: BotConfig{
+			Prefix: "/",
+		},
+		CLI: CLIConfig{
+			UserID: "user123",
+			ChatID: "chat456",
+		},
+		Commands: CommandsConfig{
+			Ping: PingConfig{
+				Timeout: Duration(5 * time.Second),
+			},
+			Weather: WeatherConfig{
+				GeocodingURL: "https://geocoding-api.open-meteo.com/v1/search",
+				ForecastURL:  "https://api.open-meteo.com/v1/forecast",
+				Timezone:     "Europe/Warsaw",
+				Timeout:      Duration(10 * time.Second),
+			},
+			Quote: QuoteConfig{
+				File:      "quotes.json",
+				DailyTime: "09:00",
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Burst: 5,
+		},
+		Tracing: TracingConfig{
+			Exporter: "none",
+			File:     "traces.jsonl",
+		},
+	}
+}
+
+// Location возвращает часовой пояс рассылки цитаты дня
+func (c QuoteConfig) Location() *time.Location {
+	if c.Timezone == "" {
+		return time.Local
+	}
+
+	// Корректность часового пояса проверяется в Validate
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.Local
+	}
+
+	return location
+}
+
+// Duration — длительность, которая в файлах записывается строкой вида "5s" или "1m30s"
+type Duration time.Duration
+
+// Std возвращает значение как time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String возвращает длительность в формате time.Duration
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText кодирует длительность строкой
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText разбирает длительность из строки
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", text, err)
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
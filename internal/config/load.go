@@ -0,0 +1,327 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix — префикс переменных окружения, переопределяющих конфигурацию
+const EnvPrefix = "COMMAND_BOT_"
+
+// Flags — флаги командной строки, не относящиеся к самой конфигурации
+type Flags struct {
+	// ConfigFile — путь к файлу конфигурации, если он задан
+	ConfigFile string
+	// PrintConfig требует вывести итоговую конфигурацию и завершиться
+	PrintConfig bool
+}
+
+// field — лист структуры конфигурации с путем ключа вида "server.listen"
+type field struct {
+	path   string
+	value  reflect.Value
+	secret bool
+}
+
+// Load собирает конфигурацию по приоритету: значения по умолчанию, файл,
+// переменные окружения COMMAND_BOT_*, флаги командной строки.
+// Каждый ключ доступен как флаг -server.listen и как переменная COMMAND_BOT_SERVER_LISTEN.
+// Путь к файлу задается флагом -config или переменной COMMAND_BOT_CONFIG.
+func Load(program string, args []string, lookupEnv func(string) (string, bool)) (Config, Flags, error) {
+	cfg := Default()
+	var flags Flags
+
+	// Карты задаются только в файле
+	var fields []field
+	for _, f := range collectFields(reflect.ValueOf(&cfg).Elem(), "") {
+		if f.value.Kind() != reflect.Map {
+			fields = append(fields, f)
+		}
+	}
+
+	fs := flag.NewFlagSet(program, flag.ContinueOnError)
+	fs.StringVar(&flags.ConfigFile, "config", "", "path to a JSON, YAML or TOML config file (env "+EnvPrefix+"CONFIG)")
+	fs.BoolVar(&flags.PrintConfig, "print-config", false, "print the effective configuration and exit")
+
+	// Значения флагов применяются после файла и окружения, поэтому пока только запоминаются
+	overrides := make(map[string]string)
+	for _, f := range fields {
+		path := f.path
+		fs.Func(path, fmt.Sprintf("override %s (env %s, default %q)", path, envName(path), formatValue(f.value)), func(value string) error {
+			overrides[path] = value
+			return nil
+		})
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, flags, err
+	}
+	if fs.NArg() > 0 {
+		return cfg, flags, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if flags.ConfigFile == "" {
+		flags.ConfigFile, _ = lookupEnv(EnvPrefix + "CONFIG")
+	}
+
+	if flags.ConfigFile != "" {
+		if err := loadFile(flags.ConfigFile, &cfg); err != nil {
+			return cfg, flags, err
+		}
+	}
+
+	for _, f := range fields {
+		if value, ok := lookupEnv(envName(f.path)); ok {
+			if err := setValue(f.value, value); err != nil {
+				return cfg, flags, fmt.Errorf("environment variable %s: %w", envName(f.path), err)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if value, ok := overrides[f.path]; ok {
+			if err := setValue(f.value, value); err != nil {
+				return cfg, flags, fmt.Errorf("flag -%s: %w", f.path, err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, flags, err
+	}
+
+	return cfg, flags, nil
+}
+
+// LoadFile читает конфигурацию из файла поверх значений по умолчанию и проверяет ее
+func LoadFile(path string) (Config, error) {
+	cfg := Default()
+	if err := loadFile(path, &cfg); err != nil {
+		return cfg, err
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// loadFile декодирует файл в cfg, выбирая формат по расширению.
+// Неизвестные ключи считаются ошибкой, чтобы опечатки не оставались незамеченными.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+		if errors.Is(err, io.EOF) {
+			err = nil // пустой файл
+		}
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), cfg)
+		if err == nil {
+			if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+				keys := make([]string, len(undecoded))
+				for i, key := range undecoded {
+					keys[i] = key.String()
+				}
+				err = fmt.Errorf("unknown keys: %s", strings.Join(keys, ", "))
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q (use .json, .yaml, .yml or .toml)", ext)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// redacted заменяет значения секретных ключей в выводе Dump
+const redacted = "<redacted>"
+
+// Dump выводит конфигурацию в YAML.
+// Значения секретных ключей заменяются на "<redacted>".
+func Dump(w io.Writer, cfg Config) error {
+	redact(reflect.ValueOf(&cfg).Elem(), false)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	return enc.Close()
+}
+
+// redact заменяет непустые значения секретных ключей в v. Карты копируются,
+// чтобы не изменить конфигурацию вызывающего; в значениях карт скрываются
+// ключи, секретные сами по себе, например api_keys.*.sha256.
+func redact(v reflect.Value, secret bool) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			redact(v.Field(i), t.Field(i).Tag.Get("config") == "secret")
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			redact(elem, secret)
+			copied.SetMapIndex(iter.Key(), elem)
+		}
+		v.Set(copied)
+	case reflect.String:
+		if secret && v.String() != "" {
+			v.SetString(redacted)
+		}
+	}
+}
+
+// Diff перечисляет изменившиеся ключи в виде "path: old -> new".
+// Значения секретных ключей не выводятся.
+func Diff(old, updated Config) []string {
+	oldFields := collectFields(reflect.ValueOf(&old).Elem(), "")
+	newFields := collectFields(reflect.ValueOf(&updated).Elem(), "")
+
+	var changes []string
+	for i, f := range oldFields {
+		if reflect.DeepEqual(f.value.Interface(), newFields[i].value.Interface()) {
+			continue
+		}
+
+		if f.secret {
+			changes = append(changes, f.path+": changed")
+			continue
+		}
+
+		changes = append(changes, fmt.Sprintf("%s: %q -> %q", f.path, formatValue(f.value), formatValue(newFields[i].value)))
+	}
+
+	return changes
+}
+
+// collectFields обходит структуру и возвращает ее листья с путями по yaml-тегам
+func collectFields(v reflect.Value, prefix string) []field {
+	var fields []field
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fv := v.Field(i)
+		secret := t.Field(i).Tag.Get("config") == "secret"
+		if fv.Kind() == reflect.Struct {
+			fields = append(fields, collectFields(fv, path)...)
+			continue
+		}
+
+		fields = append(fields, field{path: path, value: fv, secret: secret})
+	}
+
+	return fields
+}
+
+// envName возвращает имя переменной окружения для пути ключа
+func envName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// setValue записывает строковое значение в поле конфигурации
+func setValue(v reflect.Value, raw string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", v.Type())
+		}
+		// Списки задаются через запятую
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// formatValue форматирует значение поля для справки по флагам
+func formatValue(v reflect.Value) string {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, _ := m.MarshalText()
+		return string(text)
+	}
+
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	}
+
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,287 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"command-bot/internal/logging"
+)
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки сразу
+func (c Config) Validate() error {
+	var errs []error
+	check := func(path string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+
+	check("server.listen", validateListen(c.Server.Listen))
+	check("server.default_user_id", required(c.Server.DefaultUserID))
+	check("server.default_chat_id", required(c.Server.DefaultChatID))
+	check("server.shutdown_timeout", positive(c.Server.ShutdownTimeout))
+	validateTLS(c.Server.TLS, check)
+	if c.Server.Batch.MaxCommands < 1 {
+		check("server.batch.max_commands", fmt.Errorf("must be at least 1, got %d", c.Server.Batch.MaxCommands))
+	}
+	if c.Server.Batch.Parallelism < 1 {
+		check("server.batch.parallelism", fmt.Errorf("must be at least 1, got %d", c.Server.Batch.Parallelism))
+	}
+	if c.Server.Batch.MaxCommandBytes < 1 {
+		check("server.batch.max_command_bytes", fmt.Errorf("must be at least 1, got %d", c.Server.Batch.MaxCommandBytes))
+	}
+	ws := c.Server.WebSocket
+	if ws.MaxMessageBytes < 1 {
+		check("server.websocket.max_message_bytes", fmt.Errorf("must be at least 1, got %d", ws.MaxMessageBytes))
+	}
+	if ws.SendBuffer < 1 {
+		check("server.websocket.send_buffer", fmt.Errorf("must be at least 1, got %d", ws.SendBuffer))
+	}
+	if ws.MaxInFlight < 1 {
+		check("server.websocket.max_in_flight", fmt.Errorf("must be at least 1, got %d", ws.MaxInFlight))
+	}
+	check("server.websocket.ping_interval", positive(ws.PingInterval))
+	check("server.websocket.pong_timeout", positive(ws.PongTimeout))
+	for i, origin := range ws.AllowedOrigins {
+		check(fmt.Sprintf("server.websocket.allowed_origins[%d]", i), validateOriginPattern(origin))
+	}
+	if c.Server.GRPC.Listen != "" {
+		check("server.grpc.listen", validateListen(c.Server.GRPC.Listen))
+		if c.Server.GRPC.Listen == c.Server.Listen {
+			check("server.grpc.listen", errors.New("must differ from server.listen"))
+		}
+	}
+
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		check("log.format", fmt.Errorf("expected text or json, got %q", c.Log.Format))
+	}
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		check("log.level", fmt.Errorf("expected debug, info, warn or error, got %q", c.Log.Level))
+	}
+
+	if c.Log.MaxSizeMB < 0 {
+		check("log.max_size_mb", fmt.Errorf("must not be negative, got %d", c.Log.MaxSizeMB))
+	}
+	if c.Log.RotateEvery < 0 {
+		check("log.rotate_every", fmt.Errorf("must not be negative, got %s", c.Log.RotateEvery))
+	}
+	if c.Log.MaxBackups < 0 {
+		check("log.max_backups", fmt.Errorf("must not be negative, got %d", c.Log.MaxBackups))
+	}
+
+	check("bot.prefix", validatePrefix(c.Bot.Prefix))
+
+	check("cli.user_id", required(c.CLI.UserID))
+	check("cli.chat_id", required(c.CLI.ChatID))
+
+	check("commands.ping.timeout", positive(c.Commands.Ping.Timeout))
+	for i, target := range c.Commands.Ping.Targets {
+		check(fmt.Sprintf("commands.ping.targets[%d]", i), required(target))
+	}
+
+	check("commands.weather.geocoding_url", validateURL(c.Commands.Weather.GeocodingURL))
+	check("commands.weather.forecast_url", validateURL(c.Commands.Weather.ForecastURL))
+	check("commands.weather.timezone", validateTimezone(c.Commands.Weather.Timezone, true))
+	check("commands.weather.timeout", positive(c.Commands.Weather.Timeout))
+
+	check("commands.quote.file", required(c.Commands.Quote.File))
+	if c.Commands.Quote.DailyTime != "" {
+		if _, err := time.Parse("15:04", c.Commands.Quote.DailyTime); err != nil {
+			check("commands.quote.daily_time", fmt.Errorf("expected HH:MM, got %q", c.Commands.Quote.DailyTime))
+		}
+	}
+	check("commands.quote.timezone", validateTimezone(c.Commands.Quote.Timezone, false))
+
+	users := make([]string, 0, len(c.Permissions.Users))
+	for user := range c.Permissions.Users {
+		users = append(users, user)
+	}
+	sort.Strings(users) // порядок ошибок не должен зависеть от порядка обхода карты
+
+	for _, user := range users {
+		permissions := c.Permissions.Users[user]
+		if strings.TrimSpace(user) == "" {
+			check("permissions.users", errors.New("user ID must not be empty"))
+		}
+		for i, permission := range permissions {
+			check(fmt.Sprintf("permissions.users.%s[%d]", user, i), required(permission))
+		}
+	}
+	for i, permission := range c.Permissions.Default {
+		check(fmt.Sprintf("permissions.default[%d]", i), required(permission))
+	}
+
+	if c.RateLimit.PerMinute < 0 {
+		check("rate_limit.per_minute", fmt.Errorf("must not be negative, got %d", c.RateLimit.PerMinute))
+	}
+	if c.RateLimit.PerMinute > 0 && c.RateLimit.Burst < 1 {
+		check("rate_limit.burst", fmt.Errorf("must be at least 1 when the limit is enabled, got %d", c.RateLimit.Burst))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "file":
+		check("tracing.file", required(c.Tracing.File))
+	default:
+		check("tracing.exporter", fmt.Errorf("expected none, stdout or file, got %q", c.Tracing.Exporter))
+	}
+
+	names := make([]string, 0, len(c.Auth.APIKeys))
+	for name := range c.Auth.APIKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hashes := make(map[string]string, len(names))
+	for _, name := range names {
+		key := c.Auth.APIKeys[name]
+		path := "auth.api_keys." + name
+		hash := strings.ToLower(key.SHA256)
+		if len(hash) != 64 || strings.Trim(hash, "0123456789abcdef") != "" {
+			check(path+".sha256", errors.New("expected 64 hex characters of the key's SHA-256"))
+		} else if other, ok := hashes[hash]; ok {
+			check(path+".sha256", fmt.Errorf("same key as auth.api_keys.%s", other))
+		}
+		hashes[hash] = name
+		check(path+".user_id", required(key.UserID))
+		for i, chat := range key.Chats {
+			check(fmt.Sprintf("%s.chats[%d]", path, i), required(chat))
+		}
+	}
+	subjects := make([]string, 0, len(c.Auth.ClientCerts))
+	for subject := range c.Auth.ClientCerts {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+
+	for _, subject := range subjects {
+		path := "auth.client_certs." + subject
+		check(path+".user_id", required(c.Auth.ClientCerts[subject].UserID))
+		for i, chat := range c.Auth.ClientCerts[subject].Chats {
+			check(fmt.Sprintf("%s.chats[%d]", path, i), required(chat))
+		}
+	}
+	if len(subjects) > 0 && c.Server.TLS.ClientAuth == "none" {
+		check("auth.client_certs", errors.New("requires server.tls.client_auth optional or required"))
+	}
+	if c.Auth.JWT.Secret != "" && len(c.Auth.JWT.Secret) < 32 {
+		check("auth.jwt.secret", fmt.Errorf("must be at least 32 bytes, got %d", len(c.Auth.JWT.Secret)))
+	}
+	if c.Auth.JWT.Leeway < 0 {
+		check("auth.jwt.leeway", fmt.Errorf("must not be negative, got %s", c.Auth.JWT.Leeway))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("invalid configuration:\n  %w", joinErrors(errs))
+}
+
+// validateTLS проверяет параметры TLS; файлы проверяются при запуске сервиса
+func validateTLS(c TLSConfig, check func(path string, err error)) {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		check("server.tls", errors.New("cert_file and key_file must be set together"))
+	}
+	if c.MinVersion != "1.2" && c.MinVersion != "1.3" {
+		check("server.tls.min_version", fmt.Errorf("expected 1.2 or 1.3, got %q", c.MinVersion))
+	}
+
+	switch c.ClientAuth {
+	case "none":
+	case "optional", "required":
+		if !c.Enabled() {
+			check("server.tls.client_auth", errors.New("client certificates require cert_file and key_file"))
+		}
+		check("server.tls.client_ca_file", required(c.ClientCAFile))
+	default:
+		check("server.tls.client_auth", fmt.Errorf("expected none, optional or required, got %q", c.ClientAuth))
+	}
+}
+
+// joinErrors объединяет ошибки по одной на строку
+func joinErrors(errs []error) error {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+
+	return errors.New(strings.Join(lines, "\n  "))
+}
+
+func required(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("must not be empty")
+	}
+	return nil
+}
+
+func positive(d Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("must be positive, got %s", d)
+	}
+	return nil
+}
+
+func validateListen(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("expected [host]:port, got %q", addr)
+	}
+
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+
+	return nil
+}
+
+func validatePrefix(prefix string) error {
+	if prefix == "" {
+		return errors.New("must not be empty")
+	}
+	if strings.ContainsAny(prefix, " \t\r\n") {
+		return fmt.Errorf("must not contain whitespace, got %q", prefix)
+	}
+	return nil
+}
+
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("expected an absolute http(s) URL, got %q", raw)
+	}
+	return nil
+}
+
+// validateOriginPattern проверяет шаблон узла Origin в синтаксисе path.Match
+func validateOriginPattern(pattern string) error {
+	if err := required(pattern); err != nil {
+		return err
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// validateTimezone проверяет имя часового пояса; для погоды допустимо значение "auto"
+func validateTimezone(name string, allowAuto bool) error {
+	if name == "" || (allowAuto && name == "auto") {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown time zone %q", name)
+	}
+	return nil
+}
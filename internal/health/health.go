@@ -0,0 +1,217 @@
+// Пакет health проверяет состояние компонентов сервиса для эндпоинтов
+// живости и готовности.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status — итоговое состояние компонента или сервиса
+type Status string
+
+const (
+	// StatusOK — компонент исправен
+	StatusOK Status = "ok"
+	// StatusDegraded — неисправны только некритичные компоненты
+	StatusDegraded Status = "degraded"
+	// StatusFail — неисправен критичный компонент или сервис останавливается
+	StatusFail Status = "fail"
+)
+
+// DefaultTimeout ограничивает время одной проверки
+const DefaultTimeout = 2 * time.Second
+
+// Result — результат проверки компонента
+type Result struct {
+	Err error
+	// Critical — неисправность делает сервис неготовым
+	Critical bool
+	Details  map[string]any
+}
+
+// Check проверяет компонент
+type Check func(ctx context.Context) Result
+
+// NamedCheck — проверка с именем компонента
+type NamedCheck struct {
+	Name  string
+	Check Check
+}
+
+// Component — состояние компонента в отчете
+type Component struct {
+	Status     Status         `json:"status"`
+	Critical   bool           `json:"critical"`
+	Error      string         `json:"error,omitempty"`
+	DurationMS float64        `json:"duration_ms"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+// Report — отчет о состоянии сервиса
+type Report struct {
+	Status     Status               `json:"status"`
+	Reason     string               `json:"reason,omitempty"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// Ready сообщает, готов ли сервис принимать запросы
+func (r Report) Ready() bool {
+	return r.Status != StatusFail
+}
+
+// Run выполняет проверки параллельно, ограничивая каждую временем timeout
+func Run(ctx context.Context, timeout time.Duration, checks []NamedCheck) Report {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	report := Report{Status: StatusOK, Components: make(map[string]Component, len(checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check NamedCheck) {
+			defer wg.Done()
+
+			component := runCheck(ctx, timeout, check.Check)
+
+			mu.Lock()
+			report.Components[check.Name] = component
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	for _, component := range report.Components {
+		switch {
+		case component.Status == StatusOK:
+		case component.Critical:
+			report.Status = StatusFail
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+// runCheck выполняет одну проверку; зависшая проверка считается неисправностью
+func runCheck(ctx context.Context, timeout time.Duration, check Check) Component {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	var result Result
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = Result{Err: fmt.Errorf("health check timed out after %s", timeout), Critical: true}
+	}
+
+	component := Component{
+		Status:     StatusOK,
+		Critical:   result.Critical,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		Details:    result.Details,
+	}
+	if result.Err != nil {
+		component.Status = StatusFail
+		component.Error = result.Err.Error()
+	}
+
+	return component
+}
+
+// Registry хранит проверки, которые регистрируются один раз, например транспортов
+type Registry struct {
+	mu     sync.Mutex
+	checks map[string]Check
+}
+
+// NewRegistry создает пустой реестр проверок
+func NewRegistry() *Registry {
+	return &Registry{checks: make(map[string]Check)}
+}
+
+// Register добавляет или заменяет проверку компонента name
+func (r *Registry) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks[name] = check
+}
+
+// Checks возвращает зарегистрированные проверки, упорядоченные по имени
+func (r *Registry) Checks() []NamedCheck {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	checks := make([]NamedCheck, 0, len(r.checks))
+	for name, check := range r.checks {
+		checks = append(checks, NamedCheck{Name: name, Check: check})
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+
+	return checks
+}
+
+// Flag — состояние, о котором компонент сообщает сам, например подключение транспорта
+type Flag struct {
+	mu       sync.Mutex
+	err      error
+	critical bool
+	since    time.Time
+}
+
+// NewFlag создает флаг с начальной ошибкой err (nil — исправен)
+func NewFlag(err error, critical bool) *Flag {
+	return &Flag{err: err, critical: critical, since: time.Now()}
+}
+
+// Set обновляет состояние компонента
+func (f *Flag) Set(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.err = err
+	f.since = time.Now()
+}
+
+// Check возвращает текущее состояние флага как результат проверки
+func (f *Flag) Check(ctx context.Context) Result {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return Result{
+		Err:      f.err,
+		Critical: f.critical,
+		Details:  map[string]any{"since": f.since.UTC().Format(time.RFC3339)},
+	}
+}
+
+// WriteReport отправляет отчет в JSON: 200, если сервис готов, иначе 503
+func WriteReport(w http.ResponseWriter, report Report) {
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,84 @@
+// Пакет logging настраивает структурированный журнал сервиса на основе log/slog
+// и передает идентификатор запроса через контекст.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Форматы журнала
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Имена полей записей журнала
+const (
+	KeyRequestID = "request_id"
+	KeyTraceID   = "trace_id"
+	KeyUser      = "user"
+	KeyChat      = "chat"
+	KeyCommand   = "command"
+	KeyDuration  = "duration"
+	KeyOutcome   = "outcome"
+	KeyError     = "error"
+)
+
+// New создает журнал в формате format ("text" или "json"), уровень которого
+// берется из level и может меняться во время работы
+func New(w io.Writer, format string, level *slog.LevelVar) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (use text or json)", format)
+	}
+}
+
+// ParseLevel разбирает уровень журнала: debug, info, warn или error
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", name)
+	}
+	return level, nil
+}
+
+// requestIDKey — ключ идентификатора запроса в контексте
+type requestIDKey struct{}
+
+// NewRequestID генерирует случайный идентификатор запроса
+func NewRequestID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// WithRequestID возвращает контекст с идентификатором запроса
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает идентификатор запроса из контекста
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// FromContext возвращает logger, дополненный идентификатором запроса из контекста
+func FromContext(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	if id, ok := RequestID(ctx); ok {
+		return logger.With(KeyRequestID, id)
+	}
+	return logger
+}
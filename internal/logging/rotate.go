@@ -0,0 +1,380 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileMode — права файлов журнала: журнал может содержать личные данные,
+// поэтому он недоступен посторонним пользователям
+const FileMode = 0o640
+
+// reopenDelay — через сколько после сбоя записи снова пробовать открыть файл
+const reopenDelay = time.Minute
+
+// backupTimeFormat — формат метки времени в имени архивной копии
+const backupTimeFormat = "20060102-150405"
+
+// RotateOptions задает ротацию файла журнала
+type RotateOptions struct {
+	// MaxSize — размер файла в байтах, после которого он ротируется; 0 отключает ротацию по размеру
+	MaxSize int64
+	// Interval — период ротации по времени; 0 отключает ее
+	Interval time.Duration
+	// MaxBackups — сколько архивных копий хранить; 0 хранит все
+	MaxBackups int
+	// Compress сжимает архивные копии gzip
+	Compress bool
+}
+
+// RotatingFile — файл журнала с ротацией по размеру и времени.
+// Если запись в файл не удалась, записи попадают в fallback, чтобы не потеряться.
+type RotatingFile struct {
+	path     string
+	opts     RotateOptions
+	fallback io.Writer
+	now      func() time.Time
+
+	mu           sync.Mutex
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+	failed       bool
+	retryAt      time.Time
+	closed       bool
+
+	// wg ожидает фоновое сжатие архивных копий
+	wg sync.WaitGroup
+}
+
+// OpenRotatingFile открывает файл журнала path с ротацией opts.
+// Каталог файла создается при необходимости. Если файл открыть не удалось,
+// вместе с ошибкой возвращается рабочий RotatingFile: он пишет в fallback
+// и пробует открыть файл снова раз в минуту и при Reopen.
+func OpenRotatingFile(path string, opts RotateOptions, fallback io.Writer) (*RotatingFile, error) {
+	return OpenRotatingFileWithClock(path, opts, fallback, time.Now)
+}
+
+// OpenRotatingFileWithClock открывает файл журнала с заданным источником времени
+func OpenRotatingFileWithClock(path string, opts RotateOptions, fallback io.Writer, now func() time.Time) (*RotatingFile, error) {
+	if fallback == nil {
+		fallback = io.Discard
+	}
+
+	f := &RotatingFile{
+		path:     path,
+		opts:     opts,
+		fallback: fallback,
+		now:      now,
+	}
+
+	// О первой ошибке сообщает вызывающий код, поэтому fail здесь не вызывается
+	if err := f.open(); err != nil {
+		f.failed = true
+		f.retryAt = now().Add(reopenDelay)
+		return f, err
+	}
+
+	return f, nil
+}
+
+// Write записывает данные в файл, предварительно ротируя его при необходимости
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil && f.shouldRotate(len(p)) {
+		if err := f.rotate(); err != nil {
+			f.fail(err)
+		}
+	}
+
+	// После сбоя файл периодически открывается снова, например когда освободилось место на диске
+	if f.file == nil && !f.closed && !f.now().Before(f.retryAt) {
+		if err := f.open(); err != nil {
+			f.fail(err)
+		}
+	}
+
+	if f.file == nil {
+		return f.fallback.Write(p)
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		f.fail(err)
+		// Файл закрыт в fail, поэтому запись уходит в fallback целиком
+		return f.fallback.Write(p)
+	}
+
+	if f.failed {
+		f.failed = false
+		fmt.Fprintf(f.fallback, "log file %s is writable again\n", f.path)
+	}
+
+	return n, nil
+}
+
+// Reopen закрывает и снова открывает файл журнала, например после того,
+// как внешний logrotate переименовал его
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closeFile()
+	if err := f.open(); err != nil {
+		f.fail(err)
+		return err
+	}
+
+	return nil
+}
+
+// Close закрывает файл и дожидается сжатия архивных копий
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	f.closed = true
+	err := f.closeFile()
+	f.mu.Unlock()
+
+	f.wg.Wait()
+	return err
+}
+
+// open открывает файл журнала; вызывается под f.mu
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, FileMode)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	if f.opts.Interval > 0 {
+		f.nextRotation = f.now().Truncate(f.opts.Interval).Add(f.opts.Interval)
+	}
+
+	return nil
+}
+
+// closeFile закрывает текущий файл; вызывается под f.mu
+func (f *RotatingFile) closeFile() error {
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// fail переключает запись на fallback и сообщает о причине; вызывается под f.mu
+func (f *RotatingFile) fail(err error) {
+	f.closeFile()
+	f.retryAt = f.now().Add(reopenDelay)
+
+	if !f.failed {
+		f.failed = true
+		fmt.Fprintf(f.fallback, "log file %s is not writable, logging to fallback: %v\n", f.path, err)
+	}
+}
+
+// shouldRotate сообщает, пора ли ротировать файл перед записью n байт
+func (f *RotatingFile) shouldRotate(n int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.opts.MaxSize > 0 && f.size+int64(n) > f.opts.MaxSize {
+		return true
+	}
+	return f.opts.Interval > 0 && !f.now().Before(f.nextRotation)
+}
+
+// rotate переименовывает текущий файл в архивную копию и открывает новый; вызывается под f.mu
+func (f *RotatingFile) rotate() error {
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+
+	backup := f.backupName()
+	if err := os.Rename(f.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+
+		if f.opts.Compress {
+			if err := compressFile(backup); err != nil {
+				fmt.Fprintf(f.fallback, "failed to compress log backup %s: %v\n", backup, err)
+			}
+		}
+		f.removeOldBackups()
+	}()
+
+	return nil
+}
+
+// backupName возвращает свободное имя архивной копии вида command-bot.log.20240501-120000
+func (f *RotatingFile) backupName() string {
+	base := f.path + "." + f.now().Format(backupTimeFormat)
+
+	name := base
+	for i := 1; exists(name) || exists(name+".gz"); i++ {
+		name = fmt.Sprintf("%s.%d", base, i)
+	}
+
+	return name
+}
+
+// Backups возвращает архивные копии журнала от старых к новым. Учитываются только
+// копии с именами, которые дает backupName, возможно сжатые: остальные файлы
+// рядом с журналом, например копии внешнего logrotate, не трогаются.
+func (f *RotatingFile) Backups() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Base(f.path) + "."
+	var backups []backup
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if b, ok := parseBackup(name); ok {
+			b.path = filepath.Join(filepath.Dir(f.path), entry.Name())
+			backups = append(backups, b)
+		}
+	}
+
+	// Номер .N отличает копии, созданные в одну секунду, и сравнивается как число
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].time.Equal(backups[j].time) {
+			return backups[i].time.Before(backups[j].time)
+		}
+		return backups[i].index < backups[j].index
+	})
+
+	paths := make([]string, len(backups))
+	for i, b := range backups {
+		paths[i] = b.path
+	}
+	return paths, nil
+}
+
+// backup — архивная копия журнала
+type backup struct {
+	path  string
+	time  time.Time
+	index int
+}
+
+// parseBackup разбирает суффикс имени архивной копии после имени журнала:
+// метку времени, необязательный номер .N и необязательное расширение .gz
+func parseBackup(suffix string) (backup, bool) {
+	suffix = strings.TrimSuffix(suffix, ".gz")
+	stamp, index, indexed := strings.Cut(suffix, ".")
+
+	t, err := time.Parse(backupTimeFormat, stamp)
+	if err != nil {
+		return backup{}, false
+	}
+
+	b := backup{time: t}
+	if indexed {
+		// Номер без ведущих нулей и знака, как его пишет backupName
+		n, err := strconv.Atoi(index)
+		if err != nil || n < 1 || strconv.Itoa(n) != index {
+			return backup{}, false
+		}
+		b.index = n
+	}
+
+	return b, true
+}
+
+// removeOldBackups удаляет копии сверх MaxBackups, начиная с самых старых
+func (f *RotatingFile) removeOldBackups() {
+	if f.opts.MaxBackups <= 0 {
+		return
+	}
+
+	backups, err := f.Backups()
+	if err != nil {
+		return
+	}
+
+	for len(backups) > f.opts.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(f.fallback, "failed to remove log backup %s: %v\n", backups[0], err)
+		}
+		backups = backups[1:]
+	}
+}
+
+// compressFile сжимает файл в path.gz и удаляет исходный
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, FileMode)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
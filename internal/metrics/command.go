@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"command-bot/pkg/command"
+)
+
+// Итоги выполнения команды, используемые как значения метки outcome
+const (
+	OutcomeOK               = "ok"
+	OutcomeNotFound         = "not_found"
+	OutcomeInvalidArgs      = "invalid_args"
+	OutcomePermissionDenied = "permission_denied"
+	OutcomeTimeout          = "timeout"
+	OutcomeCanceled         = "canceled"
+	OutcomeUnavailable      = "unavailable"
+	OutcomeError            = "error"
+)
+
+// UnknownCommand — значение метки command для ненайденных команд:
+// произвольный ввод пользователей не должен порождать новые серии
+const UnknownCommand = "unknown"
+
+// Metrics — метрики бота
+type Metrics struct {
+	Registry *Registry
+
+	Executions       *CounterVec
+	Duration         *HistogramVec
+	InFlight         *GaugeVec
+	RateLimited      *CounterVec
+	ExternalRequests *CounterVec
+	ExternalDuration *HistogramVec
+	// WebSocketSessions — открытые сессии /ws
+	WebSocketSessions *GaugeVec
+	// GRPCRequests и GRPCDuration — вызовы gRPC API по методу и коду статуса
+	GRPCRequests *CounterVec
+	GRPCDuration *HistogramVec
+}
+
+// New создает и регистрирует метрики бота в новом реестре
+func New() *Metrics {
+	reg := NewRegistry()
+
+	return &Metrics{
+		Registry: reg,
+		Executions: reg.NewCounter("command_bot_command_executions_total",
+			"Command executions by command and outcome.", "command", "outcome"),
+		Duration: reg.NewHistogram("command_bot_command_duration_seconds",
+			"Command execution latency in seconds.", nil, "command"),
+		InFlight: reg.NewGauge("command_bot_commands_in_flight",
+			"Commands currently being executed.", "command"),
+		RateLimited: reg.NewCounter("command_bot_rate_limited_total",
+			"Requests rejected by the per-user rate limit.", "transport"),
+		ExternalRequests: reg.NewCounter("command_bot_external_requests_total",
+			"Requests to external services by service and result (HTTP status code or error).", "service", "result"),
+		ExternalDuration: reg.NewHistogram("command_bot_external_request_duration_seconds",
+			"Latency of requests to external services in seconds.", nil, "service"),
+		WebSocketSessions: reg.NewGauge("command_bot_websocket_sessions",
+			"Open WebSocket sessions."),
+		GRPCRequests: reg.NewCounter("command_bot_grpc_requests_total",
+			"gRPC calls by method and status code.", "method", "code"),
+		GRPCDuration: reg.NewHistogram("command_bot_grpc_request_duration_seconds",
+			"gRPC call latency in seconds.", nil, "method"),
+	}
+}
+
+// Outcome классифицирует результат выполнения команды
+func Outcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, command.ErrCommandNotFound):
+		return OutcomeNotFound
+	case errors.Is(err, command.ErrInvalidArguments):
+		return OutcomeInvalidArgs
+	case errors.Is(err, command.ErrPermissionDenied):
+		return OutcomePermissionDenied
+	case errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	case errors.Is(err, context.Canceled):
+		return OutcomeCanceled
+	case errors.Is(err, command.ErrUnavailable):
+		return OutcomeUnavailable
+	default:
+		return OutcomeError
+	}
+}
+
+// Middleware возвращает промежуточный обработчик, учитывающий каждое выполнение команды
+func (m *Metrics) Middleware() command.Middleware {
+	return func(next command.ExecuteFunc) command.ExecuteFunc {
+		return func(ctx context.Context, cmd command.Command, cmdCtx command.CommandContext) (string, error) {
+			name := UnknownCommand
+			if cmd != nil {
+				name = strings.ToLower(cmd.Name())
+			}
+
+			m.InFlight.Inc(name)
+			start := time.Now()
+
+			response, err := next(ctx, cmd, cmdCtx)
+
+			m.InFlight.Dec(name)
+			m.Duration.Observe(time.Since(start).Seconds(), name)
+			m.Executions.Inc(name, Outcome(err))
+
+			return response, err
+		}
+	}
+}
+
+// Transport оборачивает http.RoundTripper и учитывает запросы к внешнему сервису service.
+// При next == nil используется http.DefaultTransport.
+func (m *Metrics) Transport(service string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		m.ExternalDuration.Observe(time.Since(start).Seconds(), service)
+
+		result := "error"
+		if err == nil {
+			result = strconv.Itoa(resp.StatusCode)
+		}
+		m.ExternalRequests.Inc(service, result)
+
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,360 @@
+// Пакет metrics собирает метрики сервиса и отдает их в текстовом формате Prometheus.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType — тип содержимого текстового формата Prometheus
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets — границы гистограмм длительности в секундах
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metric — семейство метрик с одним именем
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Registry хранит семейства метрик в порядке регистрации
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+// NewRegistry создает пустой реестр метрик
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register добавляет семейство в реестр; повторное имя — ошибка программиста
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", name))
+	}
+	r.names[name] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteText записывает все метрики в текстовом формате Prometheus
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler возвращает HTTP-обработчик, отдающий метрики
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteText(w)
+	})
+}
+
+// family — общая часть семейств: имя, описание, метки и серии по значениям меток
+type family[T any] struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*entry[T]
+	create func() *T
+}
+
+// entry — серия с конкретными значениями меток
+type entry[T any] struct {
+	values []string
+	value  *T
+}
+
+func newFamily[T any](name, help, kind string, labels []string, create func() *T) *family[T] {
+	return &family[T]{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		series: make(map[string]*entry[T]),
+		create: create,
+	}
+}
+
+// get возвращает серию для значений меток, создавая ее при первом обращении
+func (f *family[T]) get(values []string) *T {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, ok := f.series[key]
+	if !ok {
+		e = &entry[T]{values: append([]string(nil), values...), value: f.create()}
+		f.series[key] = e
+	}
+	return e.value
+}
+
+// sorted возвращает серии, упорядоченные по значениям меток; вызывается под f.mu
+func (f *family[T]) sorted() []*entry[T] {
+	entries := make([]*entry[T], 0, len(f.series))
+	for _, e := range f.series {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.Join(entries[i].values, "\xff") < strings.Join(entries[j].values, "\xff")
+	})
+	return entries
+}
+
+func (f *family[T]) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+}
+
+// value — число с плавающей точкой, изменяемое под мьютексом
+type value struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (v *value) add(delta float64) {
+	v.mu.Lock()
+	v.v += delta
+	v.mu.Unlock()
+}
+
+func (v *value) set(x float64) {
+	v.mu.Lock()
+	v.v = x
+	v.mu.Unlock()
+}
+
+func (v *value) get() float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.v
+}
+
+// CounterVec — монотонно растущие счетчики с метками
+type CounterVec struct {
+	*family[value]
+}
+
+// NewCounter регистрирует семейство счетчиков
+func (r *Registry) NewCounter(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newFamily(name, help, "counter", labels, func() *value { return &value{} })}
+	r.register(name, c)
+	return c
+}
+
+// Inc увеличивает счетчик с заданными значениями меток на 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.get(labelValues).add(1)
+}
+
+// Value возвращает значение счетчика
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	return c.get(labelValues).get()
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeHeader(w)
+	for _, e := range c.sorted() {
+		writeSample(w, c.name, c.labels, e.values, "", "", e.value.get())
+	}
+}
+
+// GaugeVec — произвольно изменяемые значения с метками
+type GaugeVec struct {
+	*family[value]
+}
+
+// NewGauge регистрирует семейство измерителей
+func (r *Registry) NewGauge(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{newFamily(name, help, "gauge", labels, func() *value { return &value{} })}
+	r.register(name, g)
+	return g
+}
+
+// Inc увеличивает значение на 1
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.get(labelValues).add(1)
+}
+
+// Dec уменьшает значение на 1
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.get(labelValues).add(-1)
+}
+
+// Set задает значение
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.get(labelValues).set(v)
+}
+
+// Value возвращает текущее значение
+func (g *GaugeVec) Value(labelValues ...string) float64 {
+	return g.get(labelValues).get()
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.writeHeader(w)
+	for _, e := range g.sorted() {
+		writeSample(w, g.name, g.labels, e.values, "", "", e.value.get())
+	}
+}
+
+// histogram — распределение наблюдений по корзинам
+type histogram struct {
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec — гистограммы с метками
+type HistogramVec struct {
+	*family[histogram]
+	buckets []float64
+}
+
+// NewHistogram регистрирует семейство гистограмм с границами buckets
+// (по возрастанию); при nil используются DefaultBuckets
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	h := &HistogramVec{
+		family: newFamily(name, help, "histogram", labels, func() *histogram {
+			return &histogram{counts: make([]uint64, len(buckets))}
+		}),
+		buckets: buckets,
+	}
+	r.register(name, h)
+	return h
+}
+
+// Observe добавляет наблюдение
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	hist := h.get(labelValues)
+
+	hist.mu.Lock()
+	defer hist.mu.Unlock()
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += v
+}
+
+// Count возвращает число наблюдений
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	hist := h.get(labelValues)
+
+	hist.mu.Lock()
+	defer hist.mu.Unlock()
+	return hist.count
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w)
+	for _, e := range h.sorted() {
+		hist := e.value
+		hist.mu.Lock()
+		for i, bound := range h.buckets {
+			writeSample(w, h.name+"_bucket", h.labels, e.values, "le", formatFloat(bound), float64(hist.counts[i]))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, e.values, "le", "+Inf", float64(hist.count))
+		writeSample(w, h.name+"_sum", h.labels, e.values, "", "", hist.sum)
+		writeSample(w, h.name+"_count", h.labels, e.values, "", "", float64(hist.count))
+		hist.mu.Unlock()
+	}
+}
+
+// writeSample записывает строку серии; extraName/extraValue — дополнительная метка, например le
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string, v float64) {
+	w.WriteString(name)
+
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", label, escapeLabel(values[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
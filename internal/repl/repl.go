@@ -0,0 +1,128 @@
+// Пакет repl реализует транспорт для работы с ботом в терминале: команды
+// читаются построчно, ответы и сообщения для чатов печатаются по мере готовности.
+package repl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"command-bot/pkg/command"
+	"command-bot/pkg/transport"
+)
+
+// ExitCommand завершает сеанс
+const ExitCommand = "exit"
+
+// Options задает параметры сеанса
+type Options struct {
+	// UserID и ChatID — пользователь и чат всех введенных команд
+	UserID string
+	ChatID string
+	// Prompt печатается перед вводом; по умолчанию "> "
+	Prompt string
+}
+
+// REPL — транспорт терминала
+type REPL struct {
+	in   *bufio.Scanner
+	opts Options
+
+	// mu упорядочивает вывод ответов и сообщений, отправленных из других горутин
+	mu     sync.Mutex
+	out    io.Writer
+	lastID int
+}
+
+// New создает сеанс, который читает команды из in и печатает в out
+func New(in io.Reader, out io.Writer, opts Options) *REPL {
+	if opts.Prompt == "" {
+		opts.Prompt = "> "
+	}
+
+	return &REPL{in: bufio.NewScanner(in), out: out, opts: opts}
+}
+
+// Name возвращает "repl"
+func (r *REPL) Name() string {
+	return "repl"
+}
+
+// Capabilities сообщает, что терминал показывает части ответа и сообщения
+// для чатов, но не может изменить уже напечатанное
+func (r *REPL) Capabilities() transport.Capabilities {
+	return transport.Capabilities{Push: true, Stream: true}
+}
+
+// Receive выполняет введенные команды до команды exit, конца ввода или отмены ctx.
+// Отмена ctx проверяется между командами.
+func (r *REPL) Receive(ctx context.Context, handler transport.Handler) error {
+	for ctx.Err() == nil {
+		r.print(r.opts.Prompt)
+		if !r.in.Scan() {
+			return r.in.Err()
+		}
+
+		input := strings.TrimSpace(r.in.Text())
+		if input == ExitCommand {
+			r.print("Goodbye!\n")
+			return nil
+		}
+
+		// Части ответа печатаются сразу, не дожидаясь завершения команды
+		msg := transport.Message{
+			ChatID: r.opts.ChatID,
+			UserID: r.opts.UserID,
+			Text:   input,
+			Time:   time.Now(),
+			Metadata: map[string]any{
+				command.MetadataStream: command.StreamFunc(func(chunk string) error {
+					r.print(chunk)
+					return nil
+				}),
+			},
+		}
+
+		response, err := handler.HandleMessage(ctx, msg)
+		if err != nil {
+			r.print(fmt.Sprintf("Error: %v\n", err))
+			continue
+		}
+		r.print(response + "\n")
+	}
+
+	return ctx.Err()
+}
+
+// Send печатает сообщение для чата с его идентификатором в квадратных скобках
+func (r *REPL) Send(ctx context.Context, chatID, text string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	// Сообщение может прервать ввод, поэтому приглашение печатается заново
+	_, err := fmt.Fprintf(r.out, "\n[%s] %s\n%s", chatID, text, r.opts.Prompt)
+	return strconv.Itoa(r.lastID), err
+}
+
+// Edit не поддерживается: напечатанный текст не изменить
+func (r *REPL) Edit(ctx context.Context, chatID, messageID, text string) error {
+	return fmt.Errorf("repl: edit: %w", transport.ErrUnsupported)
+}
+
+// Delete не поддерживается: напечатанный текст не удалить
+func (r *REPL) Delete(ctx context.Context, chatID, messageID string) error {
+	return fmt.Errorf("repl: delete: %w", transport.ErrUnsupported)
+}
+
+func (r *REPL) print(s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	io.WriteString(r.out, s)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"command-bot/internal/logging"
+	"command-bot/internal/tracing"
+)
+
+// BatchRequest — тело запроса к /commands/batch
+type BatchRequest struct {
+	Commands []CommandRequest `json:"commands"`
+	// Sequential — выполнять команды по одной в порядке списка
+	Sequential bool `json:"sequential,omitempty"`
+}
+
+// BatchItemResult — результат одной команды пакета
+type BatchItemResult struct {
+	// Index — позиция команды в запросе
+	Index int `json:"index"`
+	// Status — код HTTP, который получила бы команда, отправленная на /command
+	Status int `json:"status"`
+	CommandResponse
+	// RetryAfter — через сколько секунд повторить команду, отклоненную ограничителем
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// BatchResponse — ответ /commands/batch; результаты идут в порядке команд запроса
+type BatchResponse struct {
+	Results []BatchItemResult `json:"results"`
+	Error   *Error            `json:"error,omitempty"`
+}
+
+// handleBatch выполняет несколько команд одного клиента. Каждая команда проходит
+// те же проверки, что и на /command, и расходует лимит своего пользователя.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	ctx, call, span, ok := s.startRequest(w, r, http.MethodPost, "POST /commands/batch", true)
+	defer span.End()
+	if !ok {
+		return
+	}
+
+	limits := call.rt.Config.Server.Batch
+
+	var req BatchRequest
+	if status, apiErr := decodeBody(w, r, int64(limits.MaxCommands)*int64(limits.MaxCommandBytes), &req); apiErr != nil {
+		call.logger.Warn("Invalid batch request", logging.KeyError, apiErr.Message)
+		writeJSON(w, status, BatchResponse{Error: apiErr})
+		return
+	}
+
+	switch {
+	case len(req.Commands) == 0:
+		writeJSON(w, http.StatusBadRequest, BatchResponse{Error: newError(CodeInvalidRequest, "Batch must contain at least one command")})
+		return
+	case len(req.Commands) > limits.MaxCommands:
+		apiErr := newError(CodeBatchTooLarge, "Batch contains %d commands, at most %d are allowed", len(req.Commands), limits.MaxCommands).
+			withDetail("max_commands", limits.MaxCommands)
+		writeJSON(w, http.StatusRequestEntityTooLarge, BatchResponse{Error: apiErr})
+		return
+	}
+
+	parallelism := limits.Parallelism
+	if req.Sequential {
+		parallelism = 1
+	}
+	span.SetAttributes(tracing.Int("batch.size", len(req.Commands)), tracing.Int("batch.parallelism", parallelism))
+
+	results := make([]BatchItemResult, len(req.Commands))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, item := range req.Commands {
+		// Команды начинаются в порядке списка; при parallelism == 1 следующая ждет предыдущую
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			itemCtx, itemSpan := s.tracer.Start(ctx, "batch.command", tracing.Int("batch.index", i))
+			result := s.runCommand(itemCtx, call, item)
+			itemSpan.End()
+
+			results[i] = BatchItemResult{Index: i, Status: result.status, CommandResponse: result.resp}
+			if result.retryAfter > 0 {
+				results[i].RetryAfter = int(math.Ceil(result.retryAfter.Seconds()))
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Status != http.StatusOK {
+			failed++
+		}
+	}
+	call.logger.Info("Batch handled",
+		"commands", len(results),
+		"failed", failed,
+		"sequential", req.Sequential,
+		logging.KeyDuration, time.Since(call.receivedAt),
+	)
+
+	writeJSON(w, http.StatusOK, BatchResponse{Results: results})
+}
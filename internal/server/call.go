@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"command-bot/internal/auth"
+	"command-bot/internal/logging"
+	"command-bot/internal/tracing"
+	pkgcommand "command-bot/pkg/command"
+)
+
+// commandCall — параметры вызова API, общие для всех его команд
+type commandCall struct {
+	// rt — версия конфигурации, которой обрабатывается весь вызов
+	rt         *Runtime
+	identity   auth.Identity
+	requestID  string
+	receivedAt time.Time
+	logger     *slog.Logger
+	// stream получает части ответа, если клиент запросил потоковый ответ
+	stream pkgcommand.StreamWriter
+	// transport — метка метрик: http, ws или grpc
+	transport string
+}
+
+// commandResult — итог обработки одной команды
+type commandResult struct {
+	status int
+	// outcome — код ошибки API или "ok"
+	outcome string
+	resp    CommandResponse
+	err     error
+	// retryAfter — через сколько повторить команду, отклоненную ограничителем
+	retryAfter time.Duration
+}
+
+// newCall начинает вызов любого API: назначает идентификатор запроса, если
+// клиент не передал свой в header, и начинает спан name, продолжая трассу
+// клиента. header — заголовки HTTP или метаданные gRPC; у команд /ws он пуст.
+func (s *Server) newCall(ctx context.Context, transport, name string, header http.Header, attrs ...tracing.Attribute) (context.Context, commandCall, *tracing.Span) {
+	call := commandCall{receivedAt: time.Now(), transport: transport}
+
+	call.requestID = header.Get(RequestIDHeader)
+	if !validRequestID(call.requestID) {
+		call.requestID = logging.NewRequestID()
+	}
+
+	ctx = logging.WithRequestID(ctx, call.requestID)
+	attrs = append([]tracing.Attribute{tracing.String("request.id", call.requestID)}, attrs...)
+	ctx, span := s.tracer.Start(tracing.Extract(ctx, header), name, attrs...)
+
+	call.logger = logging.FromContext(ctx, s.logger)
+	if sc := span.SpanContext(); sc.IsValid() {
+		call.logger = call.logger.With(logging.KeyTraceID, sc.TraceID.String())
+	}
+
+	return ctx, call, span
+}
+
+// callerCredentials — учетные данные вызова
+type callerCredentials struct {
+	// header и tls — заголовки HTTP или метаданные gRPC и сертификат клиента
+	header http.Header
+	tls    *tls.ConnectionState
+	// identity задана, если клиент аутентифицирован раньше, например при открытии сессии /ws
+	identity *auth.Identity
+}
+
+// admitCall проверяет, что сервис принимает команды (если runsCommands), и
+// аутентифицирует клиента. Отказ возвращается итогом с ошибкой API, иначе
+// call получает Runtime и учетные данные.
+func (s *Server) admitCall(ctx context.Context, call *commandCall, span *tracing.Span, creds callerCredentials, runsCommands bool) *commandResult {
+	// После начала остановки новые команды не принимаются
+	if runsCommands && s.draining.Load() {
+		result := errorResult(http.StatusServiceUnavailable, newError(CodeShuttingDown, "Service is shutting down"), nil)
+		return &result
+	}
+
+	call.rt = s.Runtime()
+
+	// При включенной аутентификации пользователь определяется учетными данными, а не телом запроса
+	identity := creds.identity
+	if identity == nil {
+		id, err := call.rt.Auth.Authenticate(&http.Request{Header: creds.header, TLS: creds.tls})
+		if call.rt.Auth.Enabled() && err != nil {
+			result := errorResult(http.StatusUnauthorized, authError(err), err)
+			s.record(ctx, call.logger, call.receivedAt, result)
+			return &result
+		}
+		identity = &id
+	}
+
+	call.identity = *identity
+	if identity.Method != "" {
+		call.logger = call.logger.With("auth", identity.Method)
+		span.SetAttributes(tracing.String("auth.method", identity.Method))
+	}
+
+	return nil
+}
+
+// authError возвращает ошибку API для отклоненных учетных данных
+func authError(err error) *Error {
+	if errors.Is(err, auth.ErrNoCredentials) {
+		return newError(CodeUnauthenticated, "Authentication required")
+	}
+	return newError(CodeUnauthenticated, "Invalid credentials")
+}
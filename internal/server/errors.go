@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"command-bot/internal/bot/registry"
+	"command-bot/pkg/command"
+)
+
+// Коды ошибок API. Коды стабильны: клиенты могут ветвиться по ним, не разбирая сообщение.
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeUnauthenticated  = "unauthenticated"
+	CodeForbidden        = "forbidden"
+	CodeCommandNotFound  = "command_not_found"
+	CodeInvalidArguments = "invalid_arguments"
+	CodePermissionDenied = "permission_denied"
+	CodeRateLimited      = "rate_limited"
+	CodeTooManyInFlight  = "too_many_in_flight"
+	CodeTimeout          = "timeout"
+	CodeCanceled         = "canceled"
+	CodeUnavailable      = "upstream_unavailable"
+	CodeShuttingDown     = "shutting_down"
+	CodeAborted          = "aborted"
+	CodeBatchTooLarge    = "batch_too_large"
+	CodeRequestTooLarge  = "request_too_large"
+	CodeInternal         = "internal_error"
+)
+
+// errorCodes — все коды ошибок для документа OpenAPI
+var errorCodes = []string{
+	CodeInvalidRequest, CodeMethodNotAllowed, CodeUnauthenticated, CodeForbidden,
+	CodeCommandNotFound, CodeInvalidArguments, CodePermissionDenied, CodeRateLimited, CodeTooManyInFlight,
+	CodeTimeout, CodeCanceled, CodeUnavailable, CodeShuttingDown, CodeAborted, CodeBatchTooLarge, CodeRequestTooLarge, CodeInternal,
+}
+
+// StatusClientClosedRequest — нестандартный код nginx для команды, отмененной
+// клиентом: клиент отключился или отменил вызов gRPC, не дождавшись ответа
+const StatusClientClosedRequest = 499
+
+// Error — ошибка в ответе API
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Command — имя команды без аргументов, если ошибка относится к команде
+	Command string `json:"command,omitempty"`
+	// Details — дополнительные сведения: usage, suggestions, required_permissions, retry_after
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// newError создает ошибку API с сообщением по формату
+func newError(code, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// withDetail добавляет сведение к ошибке
+func (e *Error) withDetail(key string, value any) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// classify сопоставляет ошибку выполнения команды name коду HTTP и ошибке API
+func classify(bot *registry.Bot, name string, err error) (int, *Error) {
+	cmd, _ := bot.Handler.GetCommand(name)
+
+	var (
+		status  int
+		apiErr  *Error
+		usage   *command.UsageError
+		missing *command.NotFoundError
+	)
+
+	switch {
+	case errors.As(err, &missing):
+		status, apiErr = http.StatusNotFound, newError(CodeCommandNotFound, "Unknown command %q", missing.Name)
+		if len(missing.Suggestions) > 0 {
+			apiErr.withDetail("suggestions", missing.Suggestions)
+		}
+	case errors.Is(err, command.ErrCommandNotFound):
+		// Например, help <команда> для неизвестной команды
+		status, apiErr = http.StatusNotFound, newError(CodeCommandNotFound, "%v", err)
+	case errors.As(err, &usage):
+		status, apiErr = http.StatusBadRequest, newError(CodeInvalidArguments, "%s", usage.Reason)
+		apiErr.withDetail("usage", usage.Usage)
+	case errors.Is(err, command.ErrInvalidArguments):
+		status, apiErr = http.StatusBadRequest, newError(CodeInvalidArguments, "%v", err)
+		if cmd != nil {
+			apiErr.withDetail("usage", cmd.Usage())
+		}
+	case errors.Is(err, command.ErrPermissionDenied):
+		status, apiErr = http.StatusForbidden, newError(CodePermissionDenied, "%v", err)
+		if cmd != nil && len(cmd.RequiredPermissions()) > 0 {
+			apiErr.withDetail("required_permissions", cmd.RequiredPermissions())
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		status, apiErr = http.StatusGatewayTimeout, newError(CodeTimeout, "Command timed out: %v", err)
+	case errors.Is(err, context.Canceled):
+		// Отмену при остановке сервиса вызывающий код отвечает как aborted раньше
+		status, apiErr = StatusClientClosedRequest, newError(CodeCanceled, "Command canceled by the client")
+	case errors.Is(err, command.ErrUnavailable):
+		status, apiErr = http.StatusBadGateway, newError(CodeUnavailable, "%v", err)
+	default:
+		status, apiErr = http.StatusInternalServerError, newError(CodeInternal, "Error executing command: %v", err)
+	}
+
+	if cmd != nil {
+		apiErr.Command = cmd.Name()
+	} else {
+		apiErr.Command = name
+	}
+	return status, apiErr
+}
+
+// rateLimitError описывает отказ ограничителя
+func rateLimitError(wait time.Duration) *Error {
+	return newError(CodeRateLimited, "Rate limit exceeded, retry in %s", wait.Round(time.Second)).
+		withDetail("retry_after", int(math.Ceil(wait.Seconds())))
+}
@@ -0,0 +1,338 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"command-bot/internal/logging"
+	"command-bot/internal/tracing"
+	commandbotv1 "command-bot/pkg/api/commandbot/v1"
+	pkgcommand "command-bot/pkg/command"
+)
+
+// GRPCErrorDomain — домен google.rpc.ErrorInfo в ошибках gRPC API
+const GRPCErrorDomain = "command-bot"
+
+// grpcAuthMethods — методы, которые требуют аутентификации, как их аналоги в HTTP API.
+// true отмечает методы, выполняющие команды: во время остановки они не принимаются.
+var grpcAuthMethods = map[string]bool{
+	commandbotv1.CommandService_Execute_FullMethodName:       true,
+	commandbotv1.CommandService_ExecuteStream_FullMethodName: true,
+	commandbotv1.CommandService_ListCommands_FullMethodName:  false,
+	commandbotv1.CommandService_GetCommand_FullMethodName:    false,
+}
+
+// grpcCallKey — ключ контекста, в котором перехватчик передает методу commandCall
+type grpcCallKey struct{}
+
+// GRPCServer создает gRPC-сервер с сервисом CommandService. Перехватчики назначают
+// вызову идентификатор запроса, ведут журнал, спаны и метрики и проверяют учетные
+// данные так же, как HTTP API. opts дополняют параметры сервера, например TLS.
+func (s *Server) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	)
+
+	gs := grpc.NewServer(opts...)
+	commandbotv1.RegisterCommandServiceServer(gs, &grpcService{server: s})
+	if s.Runtime().Config.Server.GRPC.Reflection {
+		reflection.Register(gs)
+	}
+	return gs
+}
+
+func (s *Server) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, finish, err := s.startGRPC(ctx, info.FullMethod, grpc.SetHeader)
+	if err != nil {
+		finish(err)
+		return nil, err
+	}
+
+	resp, err := handler(ctx, req)
+	finish(err)
+	return resp, err
+}
+
+func (s *Server) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	setHeader := func(_ context.Context, md metadata.MD) error {
+		return ss.SetHeader(md)
+	}
+
+	ctx, finish, err := s.startGRPC(ss.Context(), info.FullMethod, setHeader)
+	if err == nil {
+		err = handler(srv, &grpcServerStream{ServerStream: ss, ctx: ctx})
+	}
+	finish(err)
+	return err
+}
+
+// grpcServerStream подменяет контекст потока контекстом вызова
+type grpcServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *grpcServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// startGRPC начинает вызов gRPC теми же шагами, что и запросы HTTP API: назначает
+// идентификатор запроса, начинает спан и для методов CommandService проверяет,
+// что клиент аутентифицирован, а для выполняющих команды — еще и что сервис
+// принимает команды. finish нужно вызвать с итогом вызова в любом случае.
+func (s *Server) startGRPC(ctx context.Context, method string, setHeader func(context.Context, metadata.MD) error) (context.Context, func(error), error) {
+	// Метаданные gRPC передаются заголовками HTTP/2, поэтому разбираются как заголовки HTTP API
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := http.Header{}
+	for key, values := range md {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	ctx, call, span := s.newCall(ctx, "grpc", "grpc "+method, header, tracing.String("rpc.method", method))
+	call.logger = call.logger.With("method", method)
+	setHeader(ctx, metadata.Pairs(strings.ToLower(RequestIDHeader), call.requestID))
+
+	finish := func(err error) {
+		code := status.Code(err)
+		if s.metrics != nil {
+			s.metrics.GRPCRequests.Inc(method, code.String())
+			s.metrics.GRPCDuration.Observe(time.Since(call.receivedAt).Seconds(), method)
+		}
+		span.SetAttributes(tracing.String("rpc.grpc.status_code", code.String()))
+		span.End()
+		call.logger.Debug("gRPC call handled", "code", code.String(), logging.KeyDuration, time.Since(call.receivedAt))
+	}
+
+	runsCommands, ok := grpcAuthMethods[method]
+	if !ok {
+		return ctx, finish, nil
+	}
+
+	// Клиентский сертификат проверяется так же, как в HTTP API
+	creds := callerCredentials{header: header}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			creds.tls = &info.State
+		}
+	}
+	if result := s.admitCall(ctx, &call, span, creds, runsCommands); result != nil {
+		return ctx, finish, grpcError(*result)
+	}
+
+	return context.WithValue(ctx, grpcCallKey{}, call), finish, nil
+}
+
+// grpcCode сопоставляет код HTTP ошибки API коду gRPC
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case StatusClientClosedRequest:
+		return codes.Canceled
+	default:
+		return codes.Internal
+	}
+}
+
+// grpcError возвращает ошибку API статусом gRPC с деталью ErrorInfo,
+// а для отказа ограничителя — и с RetryInfo
+func grpcError(result commandResult) error {
+	apiErr := result.resp.Error
+	st := status.New(grpcCode(result.status), apiErr.Message)
+
+	info := &errdetails.ErrorInfo{Reason: apiErr.Code, Domain: GRPCErrorDomain, Metadata: map[string]string{}}
+	if apiErr.Command != "" {
+		info.Metadata["command"] = apiErr.Command
+	}
+	for key, value := range apiErr.Details {
+		// Значения метаданных ErrorInfo — строки; списки передаются через запятую
+		if list, ok := value.([]string); ok {
+			info.Metadata[key] = strings.Join(list, ",")
+		} else {
+			info.Metadata[key] = fmt.Sprint(value)
+		}
+	}
+
+	details := []protoadapt.MessageV1{info}
+	if result.retryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(result.retryAfter)})
+	}
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// grpcService реализует CommandService поверх Server
+type grpcService struct {
+	commandbotv1.UnimplementedCommandServiceServer
+	server *Server
+}
+
+func (g *grpcService) Execute(ctx context.Context, req *commandbotv1.ExecuteRequest) (*commandbotv1.ExecuteResponse, error) {
+	call := ctx.Value(grpcCallKey{}).(commandCall)
+
+	result := g.server.runCommand(ctx, call, commandRequest(req))
+	if result.resp.Error != nil {
+		return nil, grpcError(result)
+	}
+	return &commandbotv1.ExecuteResponse{Response: result.resp.Response}, nil
+}
+
+func (g *grpcService) ExecuteStream(req *commandbotv1.ExecuteStreamRequest, stream grpc.ServerStreamingServer[commandbotv1.ExecuteStreamResponse]) error {
+	ctx := stream.Context()
+	call := ctx.Value(grpcCallKey{}).(commandCall)
+
+	writer := &grpcStreamWriter{stream: stream}
+	call.stream = writer
+
+	result := g.server.runCommand(ctx, call, commandRequest(req))
+	// Итоговый ответ команды продолжает уже отправленные части
+	if result.resp.Error == nil && result.resp.Response != "" {
+		writer.WriteChunk(result.resp.Response)
+	}
+	writer.close()
+
+	if result.resp.Error != nil {
+		return grpcError(result)
+	}
+	return nil
+}
+
+func (g *grpcService) ListCommands(ctx context.Context, req *commandbotv1.ListCommandsRequest) (*commandbotv1.ListCommandsResponse, error) {
+	call := ctx.Value(grpcCallKey{}).(commandCall)
+	list := catalog(call.rt, callerPermissions(call.rt, call.identity))
+
+	resp := &commandbotv1.ListCommandsResponse{Prefix: list.Prefix}
+	for _, info := range list.Commands {
+		resp.Commands = append(resp.Commands, commandProto(info))
+	}
+	return resp, nil
+}
+
+func (g *grpcService) GetCommand(ctx context.Context, req *commandbotv1.GetCommandRequest) (*commandbotv1.GetCommandResponse, error) {
+	call := ctx.Value(grpcCallKey{}).(commandCall)
+	handler := call.rt.Bot.Handler
+	// Недоступные вызывающему команды не раскрываются, как в справке
+	cmdCtx := pkgcommand.CommandContext{Metadata: map[string]any{
+		pkgcommand.MetadataPermissions: callerPermissions(call.rt, call.identity),
+	}}
+
+	name := strings.TrimPrefix(req.GetName(), handler.Prefix())
+	if name == "" {
+		return nil, grpcError(errorResult(http.StatusBadRequest, newError(CodeInvalidRequest, "Command name is required"), nil))
+	}
+
+	cmd, err := handler.GetCommand(name)
+	if err == nil && !cmdCtx.CanExecute(cmd) {
+		err = pkgcommand.ErrCommandNotFound
+	}
+	if err != nil {
+		apiErr := newError(CodeCommandNotFound, "Unknown command %q", name)
+		apiErr.Command = name
+		if suggestions := handler.Suggest(cmdCtx, name); len(suggestions) > 0 {
+			apiErr.withDetail("suggestions", suggestions)
+		}
+		return nil, grpcError(errorResult(http.StatusNotFound, apiErr, err))
+	}
+
+	return &commandbotv1.GetCommandResponse{Command: commandProto(pkgcommand.Describe(cmd))}, nil
+}
+
+// grpcStreamWriter передает части ответа команды сообщениями потока
+type grpcStreamWriter struct {
+	mu     sync.Mutex
+	stream grpc.ServerStreamingServer[commandbotv1.ExecuteStreamResponse]
+	closed bool
+}
+
+// WriteChunk отправляет часть ответа; после завершения вызова части не принимаются
+func (w *grpcStreamWriter) WriteChunk(chunk string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return errStreamClosed
+	}
+	return w.stream.Send(&commandbotv1.ExecuteStreamResponse{Chunk: chunk})
+}
+
+func (w *grpcStreamWriter) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+}
+
+// grpcCommand — общие поля ExecuteRequest и ExecuteStreamRequest
+type grpcCommand interface {
+	GetCommand() string
+	GetUserId() string
+	GetChatId() string
+	GetSentAt() *timestamppb.Timestamp
+}
+
+// commandRequest переводит запрос gRPC API в запрос HTTP API
+func commandRequest(req grpcCommand) CommandRequest {
+	cr := CommandRequest{Command: req.GetCommand(), UserID: req.GetUserId(), ChatID: req.GetChatId()}
+	if req.GetSentAt() != nil {
+		cr.SentAt = req.GetSentAt().AsTime()
+	}
+	return cr
+}
+
+// commandProto переводит описание команды в сообщение gRPC API
+func commandProto(info pkgcommand.Info) *commandbotv1.Command {
+	cmd := &commandbotv1.Command{
+		Name:        info.Name,
+		Aliases:     info.Aliases,
+		Description: info.Description,
+		Usage:       info.Usage,
+		Category:    info.Category,
+		Permissions: info.Permissions,
+	}
+	for _, example := range info.Examples {
+		cmd.Examples = append(cmd.Examples, &commandbotv1.Example{Input: example.Input, Description: example.Description})
+	}
+	for _, arg := range info.Arguments {
+		cmd.Arguments = append(cmd.Arguments, &commandbotv1.Argument{
+			Name:        arg.Name,
+			Type:        arg.Type,
+			Description: arg.Description,
+			Required:    arg.Required,
+			Variadic:    arg.Variadic,
+			Enum:        arg.Enum,
+		})
+	}
+	return cmd
+}
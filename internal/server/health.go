@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"command-bot/internal/health"
+	pkgcommand "command-bot/pkg/command"
+)
+
+// RegisterHealthCheck добавляет проверку компонента, о котором сервер сам не знает,
+// например транспорта. Проверка с тем же именем заменяется.
+func (s *Server) RegisterHealthCheck(name string, check health.Check) {
+	s.health.Register(name, check)
+}
+
+// SetDraining отмечает, что сервис останавливается: /readyz начинает отвечать 503,
+// и балансировщик перестает направлять на него новые запросы
+func (s *Server) SetDraining(draining bool) {
+	s.draining.Store(draining)
+}
+
+// Readiness проверяет готовность сервиса принимать запросы
+func (s *Server) Readiness(ctx context.Context) health.Report {
+	if s.draining.Load() {
+		return health.Report{Status: health.StatusFail, Reason: "draining"}
+	}
+
+	return health.Run(ctx, health.DefaultTimeout, s.readinessChecks(s.Runtime()))
+}
+
+// readinessChecks возвращает проверки компонентов rt и зарегистрированные проверки
+func (s *Server) readinessChecks(rt *Runtime) []health.NamedCheck {
+	handler := rt.Bot.Handler
+
+	checks := []health.NamedCheck{
+		{Name: "registry", Check: func(ctx context.Context) health.Result {
+			result := health.Result{
+				Critical: true,
+				Details:  map[string]any{"commands": len(rt.Bot.Names)},
+			}
+			if len(rt.Bot.Names) == 0 {
+				result.Err = fmt.Errorf("no commands registered")
+			}
+			return result
+		}},
+		{Name: "storage.quotes", Check: func(ctx context.Context) health.Result {
+			return health.Result{
+				Err:      rt.Quotes.Check(),
+				Critical: true,
+				Details:  map[string]any{"file": rt.Quotes.Path()},
+			}
+		}},
+	}
+
+	for _, cmd := range handler.ListCommands() {
+		checker, ok := cmd.(pkgcommand.HealthChecker)
+		if !ok {
+			continue
+		}
+		checks = append(checks, health.NamedCheck{
+			Name: "command." + strings.ToLower(cmd.Name()),
+			Check: func(ctx context.Context) health.Result {
+				report := checker.HealthCheck(ctx)
+				return health.Result(report)
+			},
+		})
+	}
+
+	return append(checks, s.health.Checks()...)
+}
+
+// handleLiveness отвечает, пока процесс способен обрабатывать запросы; зависимости
+// не проверяются, чтобы их сбой не приводил к перезапуску сервиса
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	health.WriteReport(w, health.Report{Status: health.StatusOK})
+}
+
+// handleReadiness отдает состояние компонентов: 200, если сервис готов, иначе 503
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	health.WriteReport(w, s.Readiness(r.Context()))
+}
@@ -0,0 +1,358 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"command-bot/internal/logging"
+	"command-bot/internal/tracing"
+	pkgcommand "command-bot/pkg/command"
+	"command-bot/pkg/transport"
+)
+
+// CommandRequest — тело запроса к /command
+type CommandRequest struct {
+	Command string `json:"command"`
+	UserID  string `json:"user_id,omitempty"`
+	ChatID  string `json:"chat_id,omitempty"`
+	// SentAt — время отправки запроса клиентом, позволяет измерить задержку доставки
+	SentAt time.Time `json:"sent_at,omitempty"`
+}
+
+// CommandResponse — ответ /command
+type CommandResponse struct {
+	Response string `json:"response"`
+	Error    *Error `json:"error,omitempty"`
+}
+
+// ReloadResponse — ответ /admin/reload
+type ReloadResponse struct {
+	Changes []string `json:"changes"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Handler возвращает HTTP-обработчик API сервиса
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/command", s.handleCommand)
+	mux.HandleFunc("/commands/batch", s.handleBatch)
+	mux.HandleFunc("/commands", s.handleCommands)
+	mux.HandleFunc("/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/ws", s.handleWebSocket)
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/healthz", s.handleLiveness)
+	mux.HandleFunc("/readyz", s.handleReadiness)
+	mux.HandleFunc("/admin/reload", s.handleReload)
+	if s.metrics != nil {
+		mux.Handle("/metrics", s.metrics.Registry.Handler())
+	}
+	return mux
+}
+
+// RequestIDHeader — заголовок с идентификатором запроса. Клиент может передать свой
+// идентификатор, иначе сервер генерирует новый; в ответе заголовок есть всегда.
+const RequestIDHeader = "X-Request-ID"
+
+func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
+	ctx, call, span, ok := s.startRequest(w, r, http.MethodPost, "POST /command", true)
+	defer span.End()
+	if !ok {
+		return
+	}
+
+	var req CommandRequest
+	limit := int64(call.rt.Config.Server.Batch.MaxCommandBytes)
+	if status, apiErr := decodeBody(w, r, limit, &req); apiErr != nil {
+		call.logger.Warn("Invalid command request", logging.KeyError, apiErr.Message)
+		writeJSON(w, status, CommandResponse{Error: apiErr})
+		return
+	}
+
+	// С ?stream=1 части ответа передаются событиями Server-Sent Events по мере готовности
+	if stream := r.URL.Query().Get("stream"); stream == "1" || stream == "true" {
+		s.streamCommand(ctx, w, call, req)
+		return
+	}
+
+	writeCommandResult(w, s.runCommand(ctx, call, req))
+}
+
+// writeCommandResult отправляет итог команды ответом /command
+func writeCommandResult(w http.ResponseWriter, result commandResult) {
+	if result.retryAfter > 0 {
+		w.Header().Set("Retry-After", retryAfterSeconds(result.retryAfter))
+	}
+	writeJSON(w, result.status, result.resp)
+}
+
+// decodeBody разбирает JSON-тело запроса не длиннее limit байт. При ошибке
+// возвращает статус ответа и ошибку API: 413 для слишком длинного тела, 400 для неверного JSON.
+func decodeBody(w http.ResponseWriter, r *http.Request, limit int64, v any) (int, *Error) {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit)).Decode(v)
+
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil:
+		return http.StatusOK, nil
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge, newError(CodeRequestTooLarge, "Request body exceeds %d bytes", limit).
+			withDetail("max_bytes", limit)
+	default:
+		return http.StatusBadRequest, newError(CodeInvalidRequest, "Invalid request: %v", err)
+	}
+}
+
+// startRequest начинает HTTP-запрос с методом method: назначает идентификатор,
+// начинает спан name, а затем проверяет, что сервис принимает команды (если
+// runsCommands) и что клиент аутентифицирован. Спан нужно завершить в любом
+// случае; при ok == false ответ клиенту уже отправлен.
+func (s *Server) startRequest(w http.ResponseWriter, r *http.Request, method, name string, runsCommands bool) (context.Context, commandCall, *tracing.Span, bool) {
+	ctx, call, span := s.newCall(r.Context(), "http", name, r.Header)
+	w.Header().Set(RequestIDHeader, call.requestID)
+
+	if r.Method != method {
+		writeJSON(w, http.StatusMethodNotAllowed, CommandResponse{Error: newError(CodeMethodNotAllowed, "Method not allowed")})
+		return ctx, call, span, false
+	}
+
+	if result := s.admitCall(ctx, &call, span, callerCredentials{header: r.Header, tls: r.TLS}, runsCommands); result != nil {
+		switch result.status {
+		case http.StatusServiceUnavailable:
+			// Клиент повторит запрос на другом экземпляре
+			w.Header().Set("Connection", "close")
+		case http.StatusUnauthorized:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="command-bot"`)
+		}
+		writeJSON(w, result.status, result.resp)
+		return ctx, call, span, false
+	}
+
+	return ctx, call, span, true
+}
+
+// runCommand определяет пользователя и чат команды, проверяет доступ к чату
+// и выполняет команду; итог записывается в журнал и текущий спан из ctx
+func (s *Server) runCommand(ctx context.Context, call commandCall, req CommandRequest) commandResult {
+	start := time.Now()
+	rt := call.rt
+
+	userID, chatID := req.UserID, req.ChatID
+	var claimedUser string
+	if rt.Auth.Enabled() {
+		// Указанный user_id должен совпадать с учетными данными
+		if userID != "" && userID != call.identity.UserID {
+			claimedUser = userID
+		}
+		userID = call.identity.UserID
+		if chatID == "" {
+			chatID = call.identity.DefaultChat(rt.Config.Server.DefaultChatID)
+		}
+	}
+	if userID == "" {
+		userID = rt.Config.Server.DefaultUserID
+	}
+	if chatID == "" {
+		chatID = rt.Config.Server.DefaultChatID
+	}
+
+	logger := call.logger.With(
+		logging.KeyUser, userID,
+		logging.KeyChat, chatID,
+		logging.KeyCommand, commandName(req.Command, rt.Bot.Handler.Prefix()),
+	)
+	logger.Debug("Received command", "input", req.Command)
+
+	var result commandResult
+	switch {
+	case claimedUser != "":
+		result = errorResult(http.StatusForbidden,
+			newError(CodeForbidden, "user_id does not match the credentials"),
+			fmt.Errorf("client claimed user %q", claimedUser))
+	case !call.identity.CanUseChat(chatID):
+		result = errorResult(http.StatusForbidden,
+			newError(CodeForbidden, "Chat %s is not allowed for these credentials", chatID),
+			fmt.Errorf("chat %q is not allowed", chatID))
+	default:
+		result = s.execute(ctx, call, req, userID, chatID)
+	}
+
+	s.record(ctx, logger, start, result)
+	return result
+}
+
+// execute проверяет лимит пользователя, разбирает и выполняет команду
+func (s *Server) execute(ctx context.Context, call commandCall, req CommandRequest, userID, chatID string) commandResult {
+	rt := call.rt
+	handler := rt.Bot.Handler
+
+	if ok, wait := rt.Limiter.Allow(userID); !ok {
+		if s.metrics != nil {
+			s.metrics.RateLimited.Inc(call.transport)
+		}
+		result := errorResult(http.StatusTooManyRequests, rateLimitError(wait), nil)
+		result.retryAfter = wait
+		return result
+	}
+
+	tracing.SpanFromContext(ctx).SetAttributes(tracing.String("user.id", userID), tracing.String("chat.id", chatID))
+
+	// Разбор только проверяет ввод, чтобы вернуть invalid_request для текста без
+	// префикса; саму команду разбирает обработчик сообщений
+	_, parseSpan := s.tracer.Start(ctx, "command.parse")
+	_, err := handler.ParseCommand(req.Command, userID, chatID)
+	parseSpan.RecordError(err)
+	parseSpan.End()
+	if err != nil {
+		return errorResult(http.StatusBadRequest, newError(CodeInvalidRequest, "Error parsing command: %v", err), err)
+	}
+
+	msg := transport.Message{
+		ID:     call.requestID,
+		ChatID: chatID,
+		UserID: userID,
+		Text:   req.Command,
+		Time:   call.receivedAt,
+		Metadata: map[string]any{
+			pkgcommand.MetadataReceivedAt:  call.receivedAt,
+			pkgcommand.MetadataPermissions: rt.Config.Permissions.For(userID),
+			pkgcommand.MetadataRequestID:   call.requestID,
+		},
+	}
+	if !req.SentAt.IsZero() {
+		msg.Metadata[pkgcommand.MetadataSentAt] = req.SentAt
+	}
+	if call.stream != nil {
+		msg.Metadata[pkgcommand.MetadataStream] = call.stream
+	}
+
+	name := commandName(req.Command, handler.Prefix())
+	execCtx, done := s.commands.start(ctx, InFlightCommand{
+		RequestID: call.requestID,
+		Command:   name,
+		UserID:    userID,
+		Started:   call.receivedAt,
+	})
+	response, err := s.messageHandler(rt).HandleMessage(execCtx, msg)
+	done()
+
+	switch {
+	case err != nil && s.commands.aborted():
+		apiErr := newError(CodeAborted, "Command aborted: service is shutting down")
+		apiErr.Command = name
+		return errorResult(http.StatusServiceUnavailable, apiErr, err)
+	case err != nil:
+		status, apiErr := classify(rt.Bot, name, err)
+		return errorResult(status, apiErr, err)
+	}
+
+	return commandResult{status: http.StatusOK, outcome: "ok", resp: CommandResponse{Response: response}}
+}
+
+// errorResult возвращает итог команды, завершившейся ошибкой API;
+// err — внутренняя причина для журнала и трассировки
+func errorResult(status int, apiErr *Error, err error) commandResult {
+	return commandResult{status: status, outcome: apiErr.Code, resp: CommandResponse{Error: apiErr}, err: err}
+}
+
+// record записывает итог команды в журнал и в текущий спан из ctx
+func (s *Server) record(ctx context.Context, logger *slog.Logger, start time.Time, result commandResult) {
+	attrs := []any{
+		logging.KeyOutcome, result.outcome,
+		logging.KeyDuration, time.Since(start),
+		"status", result.status,
+	}
+	level := slog.LevelInfo
+	if result.err != nil {
+		attrs = append(attrs, logging.KeyError, result.err)
+		level = slog.LevelWarn
+	}
+	logger.Log(ctx, level, "Command handled", attrs...)
+
+	span := tracing.SpanFromContext(ctx)
+	span.SetAttributes(tracing.String("command.outcome", result.outcome), tracing.Int("http.status_code", result.status))
+	span.RecordError(result.err)
+}
+
+// retryAfterSeconds возвращает значение заголовка Retry-After с округлением вверх
+func retryAfterSeconds(wait time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(wait.Seconds())))
+}
+
+// validRequestID проверяет идентификатор, переданный клиентом, чтобы он
+// не портил записи журнала
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// commandName возвращает имя команды без аргументов: они могут содержать личные данные
+func commandName(input, prefix string) string {
+	fields := strings.Fields(strings.TrimPrefix(input, prefix))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Command Bot service is running")
+}
+
+// handleReload перезагружает конфигурацию; доступен, только если задан server.admin_token
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	token := s.Runtime().Config.Server.AdminToken
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	s.logger.Info("Configuration reload requested via admin endpoint")
+
+	changes, err := s.Reload()
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, ReloadResponse{Error: err.Error()})
+		return
+	}
+
+	if changes == nil {
+		changes = []string{}
+	}
+	writeJSON(w, http.StatusOK, ReloadResponse{Changes: changes})
+}
+
+// writeJSON записывает ответ в формате JSON с заданным статусом
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
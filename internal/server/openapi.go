@@ -0,0 +1,375 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"command-bot/internal/auth"
+	"command-bot/internal/health"
+	"command-bot/pkg/command"
+)
+
+// APIVersion — версия HTTP API в документе OpenAPI
+const APIVersion = "1.0.0"
+
+// CommandsResponse — ответ GET /commands
+type CommandsResponse struct {
+	// Prefix — префикс, с которого начинаются команды в CommandRequest.Command
+	Prefix   string         `json:"prefix"`
+	Commands []command.Info `json:"commands"`
+}
+
+// handleCommands возвращает команды, доступные вызывающему. Учетные данные
+// проверяются так же, как на /command; без аутентификации список строится
+// для пользователя по умолчанию.
+func (s *Server) handleCommands(w http.ResponseWriter, r *http.Request) {
+	_, call, span, ok := s.startRequest(w, r, http.MethodGet, "GET /commands", false)
+	defer span.End()
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, catalog(call.rt, callerPermissions(call.rt, call.identity)))
+}
+
+// callerPermissions возвращает разрешения пользователя из учетных данных,
+// а без аутентификации — пользователя по умолчанию
+func callerPermissions(rt *Runtime, identity auth.Identity) []string {
+	userID := identity.UserID
+	if userID == "" {
+		userID = rt.Config.Server.DefaultUserID
+	}
+	return rt.Config.Permissions.For(userID)
+}
+
+// catalog описывает команды rt, разрешения на которые покрываются permissions, в порядке имен
+func catalog(rt *Runtime, permissions []string) CommandsResponse {
+	handler := rt.Bot.Handler
+	cmds := handler.ListCommands()
+	command.SortByName(cmds)
+
+	resp := CommandsResponse{Prefix: handler.Prefix(), Commands: make([]command.Info, 0, len(cmds))}
+	for _, cmd := range cmds {
+		if command.HasPermissions(permissions, cmd.RequiredPermissions()) {
+			resp.Commands = append(resp.Commands, command.Describe(cmd))
+		}
+	}
+	return resp
+}
+
+// openAPIDocument собирается один раз: он зависит только от типов API
+var openAPIDocument = sync.OnceValue(func() []byte {
+	data, err := json.MarshalIndent(OpenAPI(), "", "  ")
+	if err != nil {
+		panic("failed to encode OpenAPI document: " + err.Error())
+	}
+	return data
+})
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument())
+}
+
+// OpenAPI возвращает описание HTTP API в формате OpenAPI 3. Схемы тел запросов
+// и ответов строятся из типов Go, поэтому не расходятся с кодом.
+func OpenAPI() map[string]any {
+	schemas := newSchemaBuilder()
+
+	commandResponses := map[string]any{"200": response("Command executed", schemas.of(CommandResponse{}))}
+	for _, status := range []int{
+		http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound,
+		http.StatusMethodNotAllowed, http.StatusRequestEntityTooLarge, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+	} {
+		commandResponses[strconv.Itoa(status)] = response(http.StatusText(status)+"; see error.code", schemas.of(CommandResponse{}))
+	}
+	// С ?stream=1 ответ 200 — поток событий chunk и done
+	commandResponses["200"].(map[string]any)["content"].(map[string]any)["text/event-stream"] = map[string]any{
+		"schema": map[string]any{
+			"type":        "string",
+			"description": "Server-Sent Events: \"chunk\" events carry parts of the response, the final \"done\" event carries the status /command would return. Requests rejected before the command runs get the plain /command error response",
+		},
+		"x-events": map[string]any{
+			EventChunk: schemas.of(StreamChunk{}),
+			EventDone:  schemas.of(StreamDone{}),
+		},
+	}
+	commandResponses["429"].(map[string]any)["headers"] = map[string]any{
+		"Retry-After": map[string]any{
+			"description": "Seconds until the user's rate limit allows another command",
+			"schema":      map[string]any{"type": "integer"},
+		},
+	}
+
+	// Пустое требование означает, что аутентификация может быть отключена в конфигурации
+	clientSecurity := []any{map[string]any{}, map[string]any{"apiKey": []string{}}, map[string]any{"bearerJWT": []string{}}}
+	requestID := map[string]any{
+		"name": RequestIDHeader, "in": "header", "required": false,
+		"description": "Request ID to use in logs and traces; generated if absent",
+		"schema":      map[string]any{"type": "string", "maxLength": 64, "pattern": "^[A-Za-z0-9._-]+$"},
+	}
+
+	paths := map[string]any{
+		"/command": map[string]any{"post": map[string]any{
+			"operationId": "executeCommand",
+			"summary":     "Execute a command",
+			"parameters": []any{requestID, map[string]any{
+				"name": "stream", "in": "query", "required": false,
+				"description": "Stream the response as Server-Sent Events while the command runs",
+				"schema":      map[string]any{"type": "boolean"},
+			}},
+			"security":    clientSecurity,
+			"requestBody": requestBody(schemas.of(CommandRequest{})),
+			"responses":   commandResponses,
+		}},
+		"/commands/batch": map[string]any{"post": map[string]any{
+			"operationId": "executeBatch",
+			"summary":     "Execute several commands; each result carries the status /command would return",
+			"parameters":  []any{requestID},
+			"security":    clientSecurity,
+			"requestBody": requestBody(schemas.of(BatchRequest{})),
+			"responses": map[string]any{
+				"200": response("Per-command results in request order", schemas.of(BatchResponse{})),
+				"400": response("Invalid batch", schemas.of(BatchResponse{})),
+				"401": response("Authentication required", schemas.of(CommandResponse{})),
+				"413": response("Too many commands or request body too large", schemas.of(BatchResponse{})),
+				"503": response("Service is shutting down", schemas.of(CommandResponse{})),
+			},
+		}},
+		"/commands": map[string]any{"get": map[string]any{
+			"operationId": "listCommands",
+			"summary":     "List the commands the caller may run, with their arguments and permissions",
+			"security":    clientSecurity,
+			"responses": map[string]any{
+				"200": response("Command catalog", schemas.of(CommandsResponse{})),
+				"401": response("Authentication required", schemas.of(CommandResponse{})),
+			},
+		}},
+		"/health": map[string]any{"get": map[string]any{
+			"operationId": "health",
+			"summary":     "Report that the process is running",
+			"responses":   map[string]any{"200": textResponse("Service is running")},
+		}},
+		"/healthz": map[string]any{"get": map[string]any{
+			"operationId": "liveness",
+			"summary":     "Liveness check",
+			"responses": map[string]any{
+				"200": response("Alive", schemas.of(health.Report{})),
+				"503": response("Not alive", schemas.of(health.Report{})),
+			},
+		}},
+		"/readyz": map[string]any{"get": map[string]any{
+			"operationId": "readiness",
+			"summary":     "Readiness check of the registry, storage, commands and transports",
+			"responses": map[string]any{
+				"200": response("Ready, possibly degraded", schemas.of(health.Report{})),
+				"503": response("Not ready or shutting down", schemas.of(health.Report{})),
+			},
+		}},
+		"/admin/reload": map[string]any{"post": map[string]any{
+			"operationId": "reloadConfig",
+			"summary":     "Reload configuration; available only when server.admin_token is set",
+			"security":    []any{map[string]any{"adminToken": []string{}}},
+			"responses": map[string]any{
+				"200": response("Configuration reloaded", schemas.of(ReloadResponse{})),
+				"401": textResponse("Invalid admin token"),
+				"404": textResponse("Admin endpoint disabled"),
+				"422": response("Configuration rejected, the previous one stays active", schemas.of(ReloadResponse{})),
+			},
+		}},
+		"/ws": map[string]any{"get": map[string]any{
+			"operationId": "webSocket",
+			"summary":     "Open an interactive session: JSON commands, correlated responses and chat notifications",
+			"security":    clientSecurity,
+			"responses": map[string]any{
+				"101": map[string]any{"description": "Switched to WebSocket"},
+				"401": response("Invalid credentials", schemas.of(CommandResponse{})),
+				"503": response("Service is shutting down", schemas.of(CommandResponse{})),
+			},
+			// OpenAPI не описывает сообщения WebSocket, поэтому их схемы указаны в расширениях
+			"x-client-messages": schemas.of(WSRequest{}),
+			"x-server-messages": schemas.of(WSMessage{}),
+		}},
+		"/metrics": map[string]any{"get": map[string]any{
+			"operationId": "metrics",
+			"summary":     "Prometheus metrics; available when metrics are enabled",
+			"responses":   map[string]any{"200": textResponse("Prometheus text format")},
+		}},
+		"/openapi.json": map[string]any{"get": map[string]any{
+			"operationId": "openapi",
+			"summary":     "This document",
+			"responses":   map[string]any{"200": map[string]any{"description": "OpenAPI 3 document"}},
+		}},
+	}
+
+	components := schemas.components()
+	// Допустимые значения строковых полей, которые не видны по типам Go
+	setEnum(components, "Error", "code", errorCodes)
+	setEnum(components, "CommandArgument", "type",
+		[]string{command.ArgumentString, command.ArgumentNumber, command.ArgumentInteger})
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Command Bot API",
+			"version": APIVersion,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": components,
+			"securitySchemes": map[string]any{
+				"apiKey":     map[string]any{"type": "apiKey", "in": "header", "name": auth.APIKeyHeader},
+				"bearerJWT":  map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"adminToken": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// setEnum задает допустимые значения поля field схемы name
+func setEnum(components map[string]any, name, field string, values []string) {
+	properties := components[name].(map[string]any)["properties"].(map[string]any)
+	properties[field].(map[string]any)["enum"] = values
+}
+
+func response(description string, schema map[string]any) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+func textResponse(description string) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{"text/plain": map[string]any{"schema": map[string]any{"type": "string"}}},
+	}
+}
+
+func requestBody(schema map[string]any) map[string]any {
+	return map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+// schemaBuilder строит схемы JSON по типам Go с учетом тегов json.
+// Именованные структуры выносятся в components и подставляются ссылками.
+type schemaBuilder struct {
+	schemas map[string]map[string]any
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{schemas: make(map[string]map[string]any)}
+}
+
+// of возвращает схему типа значения v
+func (b *schemaBuilder) of(v any) map[string]any {
+	return b.schema(reflect.TypeOf(v))
+}
+
+// components возвращает собранные схемы структур
+func (b *schemaBuilder) components() map[string]any {
+	components := make(map[string]any, len(b.schemas))
+	for name, schema := range b.schemas {
+		components[name] = schema
+	}
+	return components
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, ok := b.schemas[name]; !ok {
+			// Заглушка на случай рекурсивных типов
+			b.schemas[name] = map[string]any{}
+			b.schemas[name] = b.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		// interface{} и прочие типы принимают любое значение
+		return map[string]any{}
+	}
+}
+
+// object описывает поля структуры; поля без omitempty считаются обязательными
+func (b *schemaBuilder) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	b.fields(t, properties, &required)
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (b *schemaBuilder) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Встроенные структуры без имени в теге раскрываются, как это делает encoding/json
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			b.fields(field.Type, properties, required)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// schemaName возвращает имя схемы; типам других пакетов добавляется имя пакета
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg == "server" || pkg == "" {
+		return t.Name()
+	}
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + t.Name()
+}
@@ -0,0 +1,304 @@
+// Пакет server реализует HTTP API сервиса и перезагрузку его конфигурации
+// без остановки обработки запросов.
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"command-bot/internal/auth"
+	"command-bot/internal/bot/quote"
+	"command-bot/internal/bot/ratelimit"
+	"command-bot/internal/bot/registry"
+	"command-bot/internal/config"
+	"command-bot/internal/health"
+	"command-bot/internal/logging"
+	"command-bot/internal/metrics"
+	"command-bot/internal/tracing"
+	pkgcommand "command-bot/pkg/command"
+	"command-bot/pkg/transport"
+)
+
+// restartKeys — ключи, изменение которых вступает в силу только после перезапуска
+var restartKeys = []string{
+	"server.listen",
+	"server.tls.cert_file", "server.tls.key_file", "server.tls.min_version",
+	"server.tls.client_auth", "server.tls.client_ca_file",
+	"log.file", "log.format", "log.max_size_mb", "log.rotate_every", "log.max_backups", "log.compress",
+	"tracing.exporter", "tracing.file",
+	"server.grpc.listen", "server.grpc.reflection",
+}
+
+// LoadFunc читает и проверяет актуальную конфигурацию
+type LoadFunc func() (config.Config, error)
+
+// Runtime — объекты, собранные из одной версии конфигурации.
+// Запрос до конца работает с тем Runtime, который был актуален при его начале.
+type Runtime struct {
+	Config  config.Config
+	Bot     *registry.Bot
+	Quotes  *quote.FileStore
+	Limiter *ratelimit.Limiter
+	// Auth равен nil, если аутентификация не настроена
+	Auth *auth.Authenticator
+}
+
+// Options задает зависимости сервера
+type Options struct {
+	// Load читает конфигурацию при перезагрузке
+	Load LoadFunc
+	// Senders — транспорты, через которые цитата дня рассылается кроме самого
+	// сервера, то есть сессий /ws
+	Senders []transport.Sender
+	// Logger — журнал сервера; при nil используется slog.Default()
+	Logger *slog.Logger
+	// Level — уровень журнала, который обновляется из log.level при перезагрузке
+	Level *slog.LevelVar
+	// Metrics — метрики, которые отдаются на /metrics; при nil эндпоинт отключен.
+	// Метрики переживают перезагрузку конфигурации.
+	Metrics *metrics.Metrics
+	// Tracer записывает спаны запросов, команд и исходящих запросов; при nil трассировка отключена
+	Tracer *tracing.Tracer
+}
+
+// Server хранит текущий Runtime и атомарно заменяет его при перезагрузке
+type Server struct {
+	load    LoadFunc
+	senders []transport.Sender
+	logger  *slog.Logger
+	level   *slog.LevelVar
+	metrics *metrics.Metrics
+	tracer  *tracing.Tracer
+
+	runtime atomic.Pointer[Runtime]
+
+	// health — проверки, зарегистрированные снаружи; draining — сервис останавливается
+	health   *health.Registry
+	draining atomic.Bool
+	commands *commandTracker
+	sessions *wsHub
+	// receiver — обработчик команд, заданный через Receive
+	receiver atomic.Pointer[transport.Handler]
+
+	// mu упорядочивает перезагрузки и управление рассылкой цитаты дня
+	mu            sync.Mutex
+	ctx           context.Context
+	stopScheduler context.CancelFunc
+}
+
+// New собирает первый Runtime из cfg
+func New(cfg config.Config, opts Options) (*Server, error) {
+	s := &Server{
+		load:     opts.Load,
+		senders:  opts.Senders,
+		logger:   opts.Logger,
+		level:    opts.Level,
+		metrics:  opts.Metrics,
+		tracer:   opts.Tracer,
+		health:   health.NewRegistry(),
+		commands: newCommandTracker(),
+		sessions: newWSHub(),
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+
+	rt, err := s.newRuntime(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.runtime.Store(rt)
+	s.applyLevel(cfg)
+
+	return s, nil
+}
+
+// Runtime возвращает текущий Runtime
+func (s *Server) Runtime() *Runtime {
+	return s.runtime.Load()
+}
+
+// Start запускает рассылку цитаты дня, которая перезапускается при каждой перезагрузке
+// и останавливается вместе с ctx
+func (s *Server) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctx = ctx
+	return s.startScheduler(s.Runtime())
+}
+
+// Reload перечитывает конфигурацию и заменяет Runtime.
+// При ошибке текущий Runtime остается в силе. Возвращает список изменившихся ключей.
+func (s *Server) Reload() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.load == nil {
+		return nil, fmt.Errorf("configuration reload is not supported")
+	}
+
+	cfg, err := s.load()
+	if err != nil {
+		s.logger.Error("Configuration reload rejected", logging.KeyError, err)
+		return nil, err
+	}
+
+	current := s.Runtime()
+	rt, err := s.newRuntime(cfg, current)
+	if err != nil {
+		s.logger.Error("Configuration reload rejected", logging.KeyError, err)
+		return nil, err
+	}
+
+	// Запросы, уже получившие старый Runtime, завершаются с ним
+	s.runtime.Store(rt)
+	s.applyLevel(cfg)
+
+	if s.ctx != nil {
+		if err := s.startScheduler(rt); err != nil {
+			s.logger.Error("Failed to restart daily quote scheduler", logging.KeyError, err)
+		}
+	}
+
+	changes := config.Diff(current.Config, cfg)
+	s.logger.Info("Configuration reloaded", "changes", len(changes))
+	for _, change := range changes {
+		s.logger.Info("Configuration changed", "change", change)
+	}
+	for _, key := range restartKeys {
+		for _, change := range changes {
+			if strings.HasPrefix(change, key+":") {
+				s.logger.Warn("Change takes effect after restart", "key", key)
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// applyLevel применяет уровень журнала из конфигурации
+func (s *Server) applyLevel(cfg config.Config) {
+	if s.level == nil {
+		return
+	}
+
+	// Уровень уже проверен в Validate
+	if level, err := logging.ParseLevel(cfg.Log.Level); err == nil {
+		s.level.Set(level)
+	}
+}
+
+// startScheduler перезапускает рассылку цитаты дня для rt; вызывается под s.mu
+func (s *Server) startScheduler(rt *Runtime) error {
+	if s.stopScheduler != nil {
+		s.stopScheduler()
+		s.stopScheduler = nil
+	}
+
+	quoteCfg := rt.Config.Commands.Quote
+	if rt.Bot.Quote == nil || quoteCfg.DailyTime == "" {
+		return nil
+	}
+
+	// Сервер — такой же транспорт, как остальные: его Send доставляет цитату сессиям /ws
+	post := quote.PostTo(append([]transport.Sender{s}, s.senders...)...)
+	scheduler, err := quote.NewScheduler(rt.Quotes, rt.Bot.Quote.DailyMessage, post,
+		quoteCfg.DailyTime, quoteCfg.Location(), s.logger)
+	if err != nil {
+		return fmt.Errorf("failed to create daily quote scheduler: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.stopScheduler = cancel
+	go scheduler.Run(ctx)
+
+	return nil
+}
+
+// newRuntime собирает Runtime из cfg. Хранилище цитат, ограничитель, недавно
+// показанные цитаты и сведения о запросах weather переиспользуются из prev,
+// если их настройки не изменились, чтобы не терять состояние.
+func (s *Server) newRuntime(cfg config.Config, prev *Runtime) (*Runtime, error) {
+	rt := &Runtime{Config: cfg}
+
+	sameQuotes := prev != nil && prev.Config.Commands.Quote.File == cfg.Commands.Quote.File
+	if sameQuotes {
+		rt.Quotes = prev.Quotes
+	} else {
+		store, err := quote.NewFileStore(cfg.Commands.Quote.File, quote.DefaultQuotes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to open quote store: %w", err)
+		}
+		rt.Quotes = store
+	}
+
+	if prev != nil && reflect.DeepEqual(prev.Config.RateLimit, cfg.RateLimit) {
+		rt.Limiter = prev.Limiter
+	} else {
+		rt.Limiter = ratelimit.NewLimiter(cfg.RateLimit.PerMinute, cfg.RateLimit.Burst)
+	}
+
+	authenticator, err := auth.NewAuthenticator(cfg.Auth.Options())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up authentication: %w", err)
+	}
+	rt.Auth = authenticator
+
+	opts := registry.OptionsFromConfig(cfg, rt.Quotes)
+	// Метрики оборачивают трассировку, чтобы учитывать и ее накладные расходы
+	transport := s.tracer.Transport(nil)
+	if s.metrics != nil {
+		opts.Middleware = append(opts.Middleware, s.metrics.Middleware())
+		transport = s.metrics.Transport("weather", transport)
+	}
+	opts.Middleware = append(opts.Middleware, s.tracer.Middleware(), requirePermissions, openStream)
+	opts.Weather.Transport = transport
+
+	bot, err := registry.Build(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build command registry: %w", err)
+	}
+	rt.Bot = bot
+
+	// Состояние Open-Meteo относится к API, а не к сборке команды, поэтому
+	// переносится, пока команда обращается к тем же адресам
+	if prev != nil && prev.Bot.Weather != nil && bot.Weather != nil {
+		old, cur := prev.Config.Commands.Weather, cfg.Commands.Weather
+		if old.GeocodingURL == cur.GeocodingURL && old.ForecastURL == cur.ForecastURL {
+			bot.Weather.InheritHealth(prev.Bot.Weather)
+		}
+	}
+
+	if sameQuotes {
+		if prev.Bot.Quote != nil && bot.Quote != nil {
+			bot.Quote.InheritHistory(prev.Bot.Quote)
+		}
+
+		// Файл мог быть изменен вручную, поэтому перечитываем его. Хранилище
+		// используется и текущим Runtime, поэтому оно меняется последним,
+		// когда остальное уже собрано без ошибок.
+		if err := prev.Quotes.Reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	return rt, nil
+}
+
+// requirePermissions отклоняет команды, разрешений на которые у вызывающего нет.
+// Все транспорты сервиса передают разрешения пользователя в метаданных,
+// поэтому проверка выполняется здесь, а не в обработчике команд.
+func requirePermissions(next pkgcommand.ExecuteFunc) pkgcommand.ExecuteFunc {
+	return func(ctx context.Context, cmd pkgcommand.Command, cmdCtx pkgcommand.CommandContext) (string, error) {
+		if cmd != nil && !cmdCtx.CanExecute(cmd) {
+			return "", fmt.Errorf("command %q requires permissions %v: %w", cmd.Name(), cmd.RequiredPermissions(), pkgcommand.ErrPermissionDenied)
+		}
+		return next(ctx, cmd, cmdCtx)
+	}
+}
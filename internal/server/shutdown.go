@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"google.golang.org/grpc"
+
+	"command-bot/internal/logging"
+)
+
+// abortWait — сколько ждать, пока прерванные команды вернут ответ клиентам
+const abortWait = time.Second
+
+// listenerGrace — на сколько дольше команд серверы HTTP и gRPC ждут своих
+// соединений, чтобы прерванные команды успели ответить клиентам
+const listenerGrace = 2 * time.Second
+
+// InFlightCommand — выполняющаяся команда
+type InFlightCommand struct {
+	RequestID string
+	Command   string
+	UserID    string
+	Started   time.Time
+}
+
+// ShutdownReport — итог остановки сервера
+type ShutdownReport struct {
+	// Drained — сколько команд завершилось за время ожидания
+	Drained int
+	// Aborted — команды, прерванные по истечении времени ожидания
+	Aborted []InFlightCommand
+}
+
+// commandTracker учитывает выполняющиеся команды и прерывает их при остановке
+type commandTracker struct {
+	ctx   context.Context
+	abort context.CancelFunc
+
+	mu       sync.Mutex
+	next     uint64
+	commands map[uint64]InFlightCommand
+	// idle закрывается, когда завершается последняя команда
+	idle chan struct{}
+}
+
+func newCommandTracker() *commandTracker {
+	ctx, abort := context.WithCancel(context.Background())
+	return &commandTracker{ctx: ctx, abort: abort, commands: make(map[uint64]InFlightCommand)}
+}
+
+// start регистрирует команду и возвращает контекст, который отменяется при ее
+// прерывании, и функцию, которую нужно вызвать по завершении команды
+func (t *commandTracker) start(ctx context.Context, cmd InFlightCommand) (context.Context, func()) {
+	t.mu.Lock()
+	id := t.next
+	t.next++
+	t.commands[id] = cmd
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	stopAbort := context.AfterFunc(t.ctx, cancel)
+
+	return ctx, func() {
+		stopAbort()
+		cancel()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		delete(t.commands, id)
+		if len(t.commands) == 0 && t.idle != nil {
+			close(t.idle)
+			t.idle = nil
+		}
+	}
+}
+
+// aborted сообщает, что команды прерваны остановкой сервера
+func (t *commandTracker) aborted() bool {
+	return t.ctx.Err() != nil
+}
+
+// snapshot возвращает выполняющиеся команды
+func (t *commandTracker) snapshot() []InFlightCommand {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	commands := make([]InFlightCommand, 0, len(t.commands))
+	for _, cmd := range t.commands {
+		commands = append(commands, cmd)
+	}
+	return commands
+}
+
+// wait ждет завершения всех команд или отмены ctx
+func (t *commandTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if len(t.commands) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown останавливает сервер: /readyz отвечает 503, новые команды отклоняются,
+// рассылка цитаты дня останавливается. Выполняющиеся команды получают время до
+// отмены ctx, после чего прерываются; затем закрываются сессии /ws.
+func (s *Server) Shutdown(ctx context.Context) ShutdownReport {
+	// Сессии не закрывает http.Server.Shutdown: их соединения переданы обработчику
+	defer s.sessions.closeAll(websocket.StatusGoingAway, "service is shutting down")
+
+	s.SetDraining(true)
+
+	s.mu.Lock()
+	if s.stopScheduler != nil {
+		s.stopScheduler()
+		s.stopScheduler = nil
+	}
+	s.mu.Unlock()
+
+	pending := len(s.commands.snapshot())
+	if pending > 0 {
+		s.logger.Info("Waiting for in-flight commands", "commands", pending)
+	}
+
+	if err := s.commands.wait(ctx); err == nil {
+		return ShutdownReport{Drained: pending}
+	}
+
+	aborted := s.commands.snapshot()
+	s.commands.abort()
+
+	waitCtx, cancel := context.WithTimeout(context.Background(), abortWait)
+	defer cancel()
+	s.commands.wait(waitCtx)
+
+	now := time.Now()
+	for _, cmd := range aborted {
+		s.logger.Warn("Command aborted by shutdown",
+			logging.KeyRequestID, cmd.RequestID,
+			logging.KeyUser, cmd.UserID,
+			logging.KeyCommand, cmd.Command,
+			"running", now.Sub(cmd.Started),
+		)
+	}
+
+	return ShutdownReport{Drained: max(pending-len(aborted), 0), Aborted: aborted}
+}
+
+// Stop перестает принимать запросы httpServer и grpcServer, ждет завершения
+// выполняющихся команд в пределах server.shutdown_timeout и прерывает
+// оставшиеся, см. Shutdown. grpcServer равен nil, если gRPC API отключен.
+func (s *Server) Stop(httpServer *http.Server, grpcServer *grpc.Server) ShutdownReport {
+	timeout := s.Runtime().Config.Server.ShutdownTimeout.Std()
+	s.logger.Info("Shutting down HTTP server...", "timeout", timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	listenerCtx, cancelListeners := context.WithTimeout(context.Background(), timeout+listenerGrace)
+	defer cancelListeners()
+
+	httpDone := make(chan error, 1)
+	go func() {
+		httpDone <- httpServer.Shutdown(listenerCtx)
+	}()
+
+	grpcDone := make(chan struct{})
+	if grpcServer != nil {
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcDone)
+		}()
+	}
+
+	report := s.Shutdown(ctx)
+
+	if err := <-httpDone; err != nil {
+		s.logger.Error("HTTP server shutdown error", logging.KeyError, err)
+		httpServer.Close()
+	}
+
+	if grpcServer != nil {
+		select {
+		case <-grpcDone:
+		case <-listenerCtx.Done():
+			s.logger.Error("gRPC server shutdown timed out")
+			grpcServer.Stop()
+		}
+	}
+
+	s.logger.Info("Shutdown complete", "drained", report.Drained, "aborted", len(report.Aborted))
+	return report
+}
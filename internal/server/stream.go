@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	pkgcommand "command-bot/pkg/command"
+)
+
+// События потокового ответа /command?stream=1
+const (
+	// EventChunk — очередная часть ответа команды
+	EventChunk = "chunk"
+	// EventDone — последнее событие с итогом команды
+	EventDone = "done"
+)
+
+// StreamChunk — данные события chunk
+type StreamChunk struct {
+	Text string `json:"text"`
+}
+
+// StreamDone — данные события done: код, который вернул бы /command, и ошибка
+type StreamDone struct {
+	Status int    `json:"status"`
+	Error  *Error `json:"error,omitempty"`
+}
+
+// errStreamClosed возвращается командам, которые пишут части после завершения запроса
+var errStreamClosed = errors.New("response stream is closed")
+
+// sseWriter передает части ответа команды событиями Server-Sent Events.
+// Поток начинается, когда команда найдена и разрешена, см. openStream:
+// до этого ответ еще можно отправить с кодом и заголовками /command.
+type sseWriter struct {
+	mu     sync.Mutex
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	opened bool
+	closed bool
+}
+
+func newSSEWriter(w http.ResponseWriter) *sseWriter {
+	return &sseWriter{w: w, rc: http.NewResponseController(w)}
+}
+
+// open отправляет заголовки потокового ответа, если они еще не отправлены
+func (s *sseWriter) open() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.openLocked()
+}
+
+func (s *sseWriter) openLocked() {
+	if s.opened {
+		return
+	}
+	s.opened = true
+
+	s.w.Header().Set("Content-Type", "text/event-stream")
+	s.w.Header().Set("Cache-Control", "no-cache")
+	// Обратные прокси, например nginx, не должны накапливать события
+	s.w.Header().Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+	s.rc.Flush()
+}
+
+// isOpen сообщает, начат ли поток
+func (s *sseWriter) isOpen() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.opened
+}
+
+// WriteChunk отправляет часть ответа событием chunk
+func (s *sseWriter) WriteChunk(chunk string) error {
+	return s.send(EventChunk, StreamChunk{Text: chunk})
+}
+
+// finish отправляет событие done; после него части ответа не принимаются
+func (s *sseWriter) finish(done StreamDone) error {
+	err := s.send(EventDone, done)
+
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	return err
+}
+
+func (s *sseWriter) send(event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errStreamClosed
+	}
+	s.openLocked()
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// streamCommand выполняет команду и передает ее ответ по частям. Поток начинается
+// с кода 200 перед выполнением команды; код, который вернул бы /command, приходит
+// в событии done. Если запрос отклонен раньше — лимитом, доступом к чату, разбором,
+// поиском команды или проверкой разрешений, — ответ такой же, как у /command.
+func (s *Server) streamCommand(ctx context.Context, w http.ResponseWriter, call commandCall, req CommandRequest) {
+	sse := newSSEWriter(w)
+	call.stream = sse
+
+	result := s.runCommand(ctx, call, req)
+	if !sse.isOpen() && result.resp.Error != nil {
+		writeCommandResult(w, result)
+		return
+	}
+	// Итоговый ответ команды продолжает уже отправленные части
+	if result.resp.Response != "" {
+		sse.WriteChunk(result.resp.Response)
+	}
+	sse.finish(StreamDone{Status: result.status, Error: result.resp.Error})
+}
+
+// openStream начинает потоковый ответ перед выполнением найденной и разрешенной
+// команды. Добавляется последним промежуточным обработчиком, после requirePermissions.
+func openStream(next pkgcommand.ExecuteFunc) pkgcommand.ExecuteFunc {
+	return func(ctx context.Context, cmd pkgcommand.Command, cmdCtx pkgcommand.CommandContext) (string, error) {
+		if sse, ok := cmdCtx.Stream().(*sseWriter); ok && cmd != nil {
+			sse.open()
+		}
+		return next(ctx, cmd, cmdCtx)
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"command-bot/internal/logging"
+	"command-bot/pkg/transport"
+)
+
+// Server — транспорт HTTP API: команды приходят через /command, /commands/batch,
+// /ws и gRPC, а сообщения для чатов получают сессии /ws, подписанные на чат.
+var _ transport.Transport = (*Server)(nil)
+
+// Name возвращает "http"
+func (s *Server) Name() string {
+	return "http"
+}
+
+// ErrNoSubscribers возвращается Send, если сообщение не получила ни одна сессия /ws
+var ErrNoSubscribers = errors.New("no /ws session is subscribed to the chat")
+
+// Capabilities сообщает возможности HTTP API. Сервер не хранит отправленные
+// сообщения, поэтому не может их изменить или удалить.
+func (s *Server) Capabilities() transport.Capabilities {
+	return transport.Capabilities{Push: true, Stream: true}
+}
+
+// Receive передает команды всех API обработчику handler, пока не отменен ctx.
+// Без него команды выполняет обработчик команд текущей конфигурации.
+func (s *Server) Receive(ctx context.Context, handler transport.Handler) error {
+	s.receiver.Store(&handler)
+	<-ctx.Done()
+	s.receiver.CompareAndSwap(&handler, nil)
+
+	return ctx.Err()
+}
+
+// messageHandler возвращает обработчик команд запроса, выполняемого с rt
+func (s *Server) messageHandler(rt *Runtime) transport.Handler {
+	if handler := s.receiver.Load(); handler != nil {
+		return *handler
+	}
+	return rt.Bot.Handler
+}
+
+// Send отправляет сообщение сессиям /ws, подписанным на чат. HTTP API не хранит
+// сообщения для клиентов, поэтому без таких сессий возвращается ErrNoSubscribers.
+func (s *Server) Send(ctx context.Context, chatID, text string) (string, error) {
+	id := logging.NewRequestID()
+	if s.Notify(Notification{ChatID: chatID, Kind: NotificationMessage, MessageID: id, Text: text}) == 0 {
+		return "", fmt.Errorf("http: send to chat %s: %w", chatID, ErrNoSubscribers)
+	}
+	return id, nil
+}
+
+// Edit не поддерживается: сервер не хранит отправленные сообщения
+func (s *Server) Edit(ctx context.Context, chatID, messageID, text string) error {
+	return fmt.Errorf("http: edit: %w", transport.ErrUnsupported)
+}
+
+// Delete не поддерживается: сервер не хранит отправленные сообщения
+func (s *Server) Delete(ctx context.Context, chatID, messageID string) error {
+	return fmt.Errorf("http: delete: %w", transport.ErrUnsupported)
+}
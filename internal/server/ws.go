@@ -0,0 +1,470 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/coder/websocket"
+
+	"command-bot/internal/auth"
+	"command-bot/internal/config"
+	"command-bot/internal/logging"
+)
+
+// Типы сообщений /ws: первые четыре отправляет клиент, остальные — сервер
+const (
+	WSTypeAuth         = "auth"
+	WSTypeCommand      = "command"
+	WSTypeSubscribe    = "subscribe"
+	WSTypeUnsubscribe  = "unsubscribe"
+	WSTypeReady        = "ready"
+	WSTypeResponse     = "response"
+	WSTypeSubscribed   = "subscribed"
+	WSTypeNotification = "notification"
+	WSTypeError        = "error"
+)
+
+// Виды уведомлений
+const (
+	// NotificationMessage — сообщение, отправленное через Server.Send
+	NotificationMessage = "message"
+)
+
+// WSRequest — сообщение клиента /ws
+type WSRequest struct {
+	Type string `json:"type"`
+	// ID выбирает клиент; ответ на сообщение приходит с тем же ID
+	ID string `json:"id,omitempty"`
+	// Поля команды — как в теле запроса к /command
+	CommandRequest
+	// Chats — чаты сообщений subscribe и unsubscribe
+	Chats []string `json:"chats,omitempty"`
+	// APIKey или Token — учетные данные сообщения auth
+	APIKey string `json:"api_key,omitempty"`
+	Token  string `json:"token,omitempty"`
+}
+
+// WSMessage — сообщение сервера /ws
+type WSMessage struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+	// Status — код HTTP, который получила бы команда, отправленная на /command
+	Status   int    `json:"status,omitempty"`
+	Response string `json:"response,omitempty"`
+	Error    *Error `json:"error,omitempty"`
+	// UserID и Chats — пользователь сессии и чаты, уведомления которых она получает
+	UserID       string        `json:"user_id,omitempty"`
+	Chats        []string      `json:"chats,omitempty"`
+	Notification *Notification `json:"notification,omitempty"`
+}
+
+// Notification — асинхронное сообщение для чата: напоминание, оповещение,
+// завершение задачи или цитата дня
+type Notification struct {
+	ChatID string `json:"chat_id"`
+	Kind   string `json:"kind"`
+	// MessageID связывает уведомления об одном сообщении
+	MessageID string    `json:"message_id,omitempty"`
+	Text      string    `json:"text"`
+	Time      time.Time `json:"time"`
+}
+
+// Notify отправляет уведомление сессиям /ws, подписанным на его чат,
+// и возвращает число таких сессий
+func (s *Server) Notify(n Notification) int {
+	if n.Time.IsZero() {
+		n.Time = time.Now()
+	}
+	data, err := json.Marshal(WSMessage{Type: WSTypeNotification, Notification: &n})
+	if err != nil {
+		return 0
+	}
+
+	delivered := 0
+	for _, session := range s.sessions.list() {
+		if session.subscribed(n.ChatID) && session.enqueue(data) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// wsHub хранит открытые сессии /ws
+type wsHub struct {
+	mu       sync.Mutex
+	sessions map[*wsSession]struct{}
+}
+
+func newWSHub() *wsHub {
+	return &wsHub{sessions: make(map[*wsSession]struct{})}
+}
+
+func (h *wsHub) add(session *wsSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sessions[session] = struct{}{}
+}
+
+func (h *wsHub) remove(session *wsSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sessions, session)
+}
+
+func (h *wsHub) list() []*wsSession {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sessions := make([]*wsSession, 0, len(h.sessions))
+	for session := range h.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// closeAll закрывает все сессии с кодом code. Сессии закрываются одновременно,
+// чтобы медленный клиент не задерживал закрытие остальных.
+func (h *wsHub) closeAll(code websocket.StatusCode, reason string) {
+	var wg sync.WaitGroup
+	for _, session := range h.list() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session.close(code, reason)
+		}()
+	}
+	wg.Wait()
+}
+
+// wsSession — подключение клиента к /ws
+type wsSession struct {
+	id       string
+	conn     *websocket.Conn
+	identity auth.Identity
+	logger   *slog.Logger
+	limits   config.WebSocketConfig
+
+	// ctx отменяется при закрытии сессии и прерывает ее команды
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// send — очередь сообщений клиенту; inFlight ограничивает число выполняющихся команд
+	send     chan []byte
+	inFlight chan struct{}
+	commands sync.WaitGroup
+
+	mu        sync.Mutex
+	chats     map[string]bool
+	closeOnce sync.Once
+}
+
+// subscribed сообщает, получает ли сессия уведомления чата chatID
+func (ss *wsSession) subscribed(chatID string) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.chats[chatID]
+}
+
+// subscriptions возвращает чаты сессии по алфавиту
+func (ss *wsSession) subscriptions() []string {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	chats := make([]string, 0, len(ss.chats))
+	for chat := range ss.chats {
+		chats = append(chats, chat)
+	}
+	sort.Strings(chats)
+	return chats
+}
+
+// enqueue ставит сообщение в очередь отправки. Клиент, который не успевает
+// читать сообщения, отключается, чтобы очередь не росла без ограничений.
+func (ss *wsSession) enqueue(data []byte) bool {
+	if ss.ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case ss.send <- data:
+		return true
+	default:
+		ss.logger.Warn("WebSocket client is too slow, closing session", "send_buffer", cap(ss.send))
+		// Закрытие ждет ответа клиента, а отправитель, например Notify, ждать не должен
+		go ss.close(websocket.StatusTryAgainLater, "send buffer is full")
+		return false
+	}
+}
+
+// reply отправляет сообщение клиенту
+func (ss *wsSession) reply(msg WSMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		ss.logger.Error("Failed to encode WebSocket message", logging.KeyError, err)
+		return
+	}
+	ss.enqueue(data)
+}
+
+// close сообщает клиенту код закрытия, закрывает соединение и отменяет
+// контекст сессии. Отмена контекста чтения обрывает соединение без кода,
+// поэтому контекст отменяется последним.
+func (ss *wsSession) close(code websocket.StatusCode, reason string) {
+	ss.closeOnce.Do(func() {
+		ss.conn.Close(code, reason)
+		ss.cancel()
+	})
+}
+
+// write отправляет текстовое сообщение, ожидая не дольше pong_timeout
+func (ss *wsSession) write(data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(ss.limits.PongTimeout))
+	defer cancel()
+	return ss.conn.Write(ctx, websocket.MessageText, data)
+}
+
+// writeLoop отправляет сообщения из очереди и проверяет связь ping-сообщениями
+func (ss *wsSession) writeLoop() {
+	ticker := time.NewTicker(time.Duration(ss.limits.PingInterval))
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ss.ctx.Done():
+			return
+		case data := <-ss.send:
+			err = ss.write(data)
+		case <-ticker.C:
+			// Ping ждет ответа клиента, который читает цикл сессии
+			ctx, cancel := context.WithTimeout(ss.ctx, time.Duration(ss.limits.PongTimeout))
+			err = ss.conn.Ping(ctx)
+			cancel()
+		}
+		if err != nil {
+			ss.logger.Debug("WebSocket write failed", logging.KeyError, err)
+			ss.close(websocket.StatusGoingAway, "")
+			return
+		}
+	}
+}
+
+// handleWebSocket открывает сессию /ws. Клиент аутентифицируется один раз:
+// заголовками или сертификатом при подключении либо первым сообщением auth.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		w.Header().Set("Connection", "close")
+		writeJSON(w, http.StatusServiceUnavailable, CommandResponse{Error: newError(CodeShuttingDown, "Service is shutting down")})
+		return
+	}
+
+	rt := s.Runtime()
+	identity, err := rt.Auth.Authenticate(r)
+	// Браузеры не передают заголовки при подключении, поэтому без учетных
+	// данных сессия открывается и ждет сообщения auth
+	pendingAuth := rt.Auth.Enabled() && errors.Is(err, auth.ErrNoCredentials)
+	if rt.Auth.Enabled() && err != nil && !pendingAuth {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="command-bot"`)
+		writeJSON(w, http.StatusUnauthorized, CommandResponse{Error: newError(CodeUnauthenticated, "Invalid credentials")})
+		return
+	}
+
+	// Accept сам отвечает клиенту, если запрос не является подключением WebSocket
+	// или пришел со страницы чужого сайта: без allowed_origins Origin должен
+	// совпадать с узлом запроса
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: rt.Config.Server.WebSocket.AllowedOrigins,
+	})
+	if err != nil {
+		s.logger.Warn("WebSocket upgrade failed", logging.KeyError, err)
+		return
+	}
+
+	limits := rt.Config.Server.WebSocket
+	conn.SetReadLimit(int64(limits.MaxMessageBytes))
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	session := &wsSession{
+		id:       logging.NewRequestID(),
+		conn:     conn,
+		limits:   limits,
+		ctx:      ctx,
+		cancel:   cancel,
+		send:     make(chan []byte, limits.SendBuffer),
+		inFlight: make(chan struct{}, limits.MaxInFlight),
+		chats:    make(map[string]bool),
+	}
+	session.logger = s.logger.With("session", session.id, "remote", r.RemoteAddr)
+	defer session.close(websocket.StatusNormalClosure, "")
+
+	if pendingAuth {
+		identity, err = s.wsAuthenticate(session, r, rt)
+		if err != nil {
+			session.logger.Warn("WebSocket authentication failed", logging.KeyError, err)
+			data, _ := json.Marshal(WSMessage{Type: WSTypeError, Error: newError(CodeUnauthenticated, "Authentication required")})
+			session.write(data)
+			session.close(websocket.StatusPolicyViolation, "authentication required")
+			return
+		}
+	}
+
+	session.identity = identity
+	userID := identity.UserID
+	if userID == "" {
+		userID = rt.Config.Server.DefaultUserID
+	}
+	// По умолчанию сессия получает уведомления всех разрешенных чатов
+	// или чата по умолчанию, если ограничений нет
+	chats := identity.Chats
+	if len(chats) == 0 {
+		chats = []string{rt.Config.Server.DefaultChatID}
+	}
+	for _, chat := range chats {
+		session.chats[chat] = true
+	}
+
+	s.sessions.add(session)
+	if s.metrics != nil {
+		s.metrics.WebSocketSessions.Inc()
+	}
+	defer func() {
+		s.sessions.remove(session)
+		if s.metrics != nil {
+			s.metrics.WebSocketSessions.Dec()
+		}
+	}()
+
+	session.logger.Info("WebSocket session opened", logging.KeyUser, userID, "auth", identity.Method)
+	go session.writeLoop()
+	session.reply(WSMessage{Type: WSTypeReady, UserID: userID, Chats: session.subscriptions()})
+
+	// Связь проверяют ping-сообщения writeLoop, поэтому чтение ждет без ограничения времени
+	for {
+		op, data, err := conn.Read(ctx)
+		if err != nil {
+			session.logger.Info("WebSocket session closed", "reason", err)
+			break
+		}
+
+		// Библиотека не проверяет кодировку текстовых сообщений, RFC 6455 требует UTF-8
+		if op == websocket.MessageText && !utf8.Valid(data) {
+			session.logger.Info("WebSocket session closed", "reason", "invalid UTF-8 in a text message")
+			session.close(websocket.StatusInvalidFramePayloadData, "text message is not valid UTF-8")
+			break
+		}
+
+		var req WSRequest
+		if op != websocket.MessageText || json.Unmarshal(data, &req) != nil {
+			session.reply(WSMessage{Type: WSTypeError, Error: newError(CodeInvalidRequest, "Expected a JSON text message")})
+			continue
+		}
+
+		switch req.Type {
+		case WSTypeCommand:
+			s.wsCommand(session, req)
+		case WSTypeSubscribe, WSTypeUnsubscribe:
+			s.wsSubscribe(session, req)
+		case WSTypeAuth:
+			session.reply(WSMessage{Type: WSTypeError, ID: req.ID, Error: newError(CodeInvalidRequest, "Session is already authenticated")})
+		default:
+			session.reply(WSMessage{Type: WSTypeError, ID: req.ID, Error: newError(CodeInvalidRequest, "Unknown message type %q", req.Type)})
+		}
+	}
+
+	// Команды закрытой сессии прерываются через ее контекст
+	session.cancel()
+	session.commands.Wait()
+}
+
+// wsAuthenticate ждет сообщения auth не дольше pong_timeout и проверяет его
+// учетные данные так же, как заголовки запроса
+func (s *Server) wsAuthenticate(session *wsSession, r *http.Request, rt *Runtime) (auth.Identity, error) {
+	ctx, cancel := context.WithTimeout(session.ctx, time.Duration(session.limits.PongTimeout))
+	defer cancel()
+
+	_, data, err := session.conn.Read(ctx)
+	if err != nil {
+		return auth.Identity{}, err
+	}
+
+	var req WSRequest
+	if err := json.Unmarshal(data, &req); err != nil || req.Type != WSTypeAuth {
+		return auth.Identity{}, auth.ErrNoCredentials
+	}
+
+	authReq := r.Clone(r.Context())
+	authReq.Header = http.Header{}
+	if req.APIKey != "" {
+		authReq.Header.Set(auth.APIKeyHeader, req.APIKey)
+	}
+	if req.Token != "" {
+		authReq.Header.Set("Authorization", "Bearer "+req.Token)
+	}
+	return rt.Auth.Authenticate(authReq)
+}
+
+// wsCommand выполняет команду сессии в отдельной горутине
+func (s *Server) wsCommand(session *wsSession, req WSRequest) {
+	select {
+	case session.inFlight <- struct{}{}:
+	default:
+		session.reply(WSMessage{Type: WSTypeResponse, ID: req.ID, Status: http.StatusTooManyRequests,
+			Error: newError(CodeTooManyInFlight, "At most %d commands may run at once in a session", cap(session.inFlight))})
+		return
+	}
+
+	session.commands.Add(1)
+	go func() {
+		defer session.commands.Done()
+		defer func() { <-session.inFlight }()
+
+		// Сессия аутентифицирована при открытии, поэтому учетные данные не проверяются заново
+		ctx, call, span := s.newCall(session.ctx, "ws", "ws.command", nil)
+		defer span.End()
+		call.logger = call.logger.With("session", session.id)
+
+		var result commandResult
+		if rejected := s.admitCall(ctx, &call, span, callerCredentials{identity: &session.identity}, true); rejected != nil {
+			result = *rejected
+		} else {
+			result = s.runCommand(ctx, call, req.CommandRequest)
+		}
+		session.reply(WSMessage{
+			Type:     WSTypeResponse,
+			ID:       req.ID,
+			Status:   result.status,
+			Response: result.resp.Response,
+			Error:    result.resp.Error,
+		})
+	}()
+}
+
+// wsSubscribe меняет чаты, уведомления которых получает сессия
+func (s *Server) wsSubscribe(session *wsSession, req WSRequest) {
+	for _, chat := range req.Chats {
+		if !session.identity.CanUseChat(chat) {
+			session.reply(WSMessage{Type: WSTypeError, ID: req.ID,
+				Error: newError(CodeForbidden, "Chat %s is not allowed for these credentials", chat)})
+			return
+		}
+	}
+
+	session.mu.Lock()
+	for _, chat := range req.Chats {
+		if req.Type == WSTypeSubscribe {
+			session.chats[chat] = true
+		} else {
+			delete(session.chats, chat)
+		}
+	}
+	session.mu.Unlock()
+
+	session.reply(WSMessage{Type: WSTypeSubscribed, ID: req.ID, Chats: session.subscriptions()})
+}
@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// SpanData — завершенный спан, передаваемый экспортеру
+type SpanData struct {
+	Name         string
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]any
+	// Error — текст ошибки, если операция завершилась неудачно
+	Error string
+}
+
+// Duration возвращает длительность спана
+func (d SpanData) Duration() time.Duration {
+	return d.End.Sub(d.Start)
+}
+
+// Exporter получает завершенные спаны
+type Exporter interface {
+	ExportSpan(span SpanData)
+}
+
+// WriterExporter записывает спаны в io.Writer по одному JSON-объекту в строке.
+// Подходит для stdout и файла, которые потом можно разобрать без коллектора.
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter создает экспортер, пишущий в w
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// spanJSON — формат записи спана
+type spanJSON struct {
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Name         string         `json:"name"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	DurationMS   float64        `json:"duration_ms"`
+	Status       string         `json:"status"`
+	Error        string         `json:"error,omitempty"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+}
+
+// ExportSpan записывает спан
+func (e *WriterExporter) ExportSpan(span SpanData) {
+	record := spanJSON{
+		TraceID:    span.TraceID.String(),
+		SpanID:     span.SpanID.String(),
+		Name:       span.Name,
+		Start:      span.Start,
+		End:        span.End,
+		DurationMS: float64(span.Duration().Microseconds()) / 1000,
+		Status:     "ok",
+		Error:      span.Error,
+		Attributes: span.Attributes,
+	}
+	if span.ParentSpanID.IsValid() {
+		record.ParentSpanID = span.ParentSpanID.String()
+	}
+	if span.Error != "" {
+		record.Status = "error"
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		data, _ = json.Marshal(spanJSON{TraceID: record.TraceID, SpanID: record.SpanID, Name: span.Name,
+			Status: "error", Error: fmt.Sprintf("failed to encode span: %v", err)})
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.w.Write(append(data, '\n'))
+}
+
+// RecordingExporter хранит спаны в памяти, например для тестов
+type RecordingExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// ExportSpan сохраняет спан
+func (e *RecordingExporter) ExportSpan(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, span)
+}
+
+// Spans возвращает сохраненные спаны в порядке завершения
+func (e *RecordingExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]SpanData(nil), e.spans...)
+}
@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"command-bot/pkg/command"
+)
+
+// Middleware возвращает промежуточный обработчик команд со спанами
+// command.execute и вложенным command.authorize
+func (t *Tracer) Middleware() command.Middleware {
+	return func(next command.ExecuteFunc) command.ExecuteFunc {
+		if t == nil {
+			return next
+		}
+
+		return func(ctx context.Context, cmd command.Command, cmdCtx command.CommandContext) (string, error) {
+			name := "unknown"
+			if cmd != nil {
+				name = strings.ToLower(cmd.Name())
+			}
+
+			ctx, span := t.Start(ctx, "command.execute",
+				String("command.name", name),
+				Int("command.args", len(cmdCtx.Arguments)),
+			)
+			defer span.End()
+
+			// Сама проверка выполняется транспортом, здесь фиксируется ее результат
+			if cmd != nil {
+				_, authSpan := t.Start(ctx, "command.authorize")
+				allowed := cmdCtx.CanExecute(cmd)
+				authSpan.SetAttributes(Bool("command.allowed", allowed))
+				authSpan.End()
+			}
+
+			response, err := next(ctx, cmd, cmdCtx)
+			span.RecordError(err)
+
+			return response, err
+		}
+	}
+}
+
+// Transport оборачивает http.RoundTripper: каждый исходящий запрос получает
+// спан "HTTP <method> <host><path>", а сервер — заголовок traceparent.
+// При next == nil используется http.DefaultTransport.
+func (t *Tracer) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if t == nil {
+		return next
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// Строка запроса не записывается: она может содержать данные пользователя
+		ctx, span := t.Start(req.Context(), "HTTP "+req.Method+" "+req.URL.Host+req.URL.Path,
+			String("http.method", req.Method),
+			String("http.host", req.URL.Host),
+			String("http.path", req.URL.Path),
+		)
+		defer span.End()
+
+		req = req.Clone(ctx)
+		Inject(ctx, req.Header)
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+
+		span.SetAttributes(Int("http.status_code", resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.RecordError(errStatus(resp.Status))
+		}
+
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// errStatus — ошибка с текстом статуса HTTP-ответа
+type errStatus string
+
+func (e errStatus) Error() string {
+	return "HTTP " + string(e)
+}
@@ -0,0 +1,229 @@
+// Пакет tracing записывает спаны выполнения запросов в стиле OpenTelemetry
+// и передает контекст трассировки в заголовке W3C traceparent.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID — идентификатор трассы
+type TraceID [16]byte
+
+// String возвращает идентификатор в шестнадцатеричном виде
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid сообщает, что идентификатор не нулевой
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID — идентификатор спана
+type SpanID [8]byte
+
+// String возвращает идентификатор в шестнадцатеричном виде
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid сообщает, что идентификатор не нулевой
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext — данные спана, передаваемые между сервисами
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid сообщает, что оба идентификатора заданы
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Attribute — атрибут спана
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String создает атрибут-строку
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int создает целочисленный атрибут
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool создает логический атрибут
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer создает спаны и передает завершенные экспортеру.
+// Нулевой указатель на Tracer создает спаны, которые ничего не записывают.
+type Tracer struct {
+	exporter Exporter
+	now      func() time.Time
+}
+
+// NewTracer создает трассировщик, отправляющий спаны в exporter
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter, now: time.Now}
+}
+
+// Start начинает спан name, дочерний для спана из ctx (локального или
+// полученного из traceparent), и возвращает контекст с новым спаном
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	parent := SpanContextFromContext(ctx)
+
+	sc := SpanContext{SpanID: newSpanID(), Sampled: true}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+	}
+
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:       name,
+			TraceID:    sc.TraceID,
+			SpanID:     sc.SpanID,
+			Start:      t.now(),
+			Attributes: make(map[string]any, len(attrs)),
+		},
+		sc: sc,
+	}
+	if parent.IsValid() {
+		span.data.ParentSpanID = parent.SpanID
+	}
+	span.SetAttributes(attrs...)
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Span — выполняемая операция. Методы нулевого указателя ничего не делают,
+// поэтому код может работать без трассировки.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext возвращает данные спана для передачи дальше
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttributes добавляет атрибуты спана
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, attr := range attrs {
+		s.data.Attributes[attr.Key] = attr.Value
+	}
+}
+
+// RecordError отмечает спан как завершившийся ошибкой
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Error = err.Error()
+}
+
+// End завершает спан и передает его экспортеру; повторные вызовы игнорируются
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = s.tracer.now()
+	data := s.data
+	s.mu.Unlock()
+
+	if s.sc.Sampled && s.tracer.exporter != nil {
+		s.tracer.exporter.ExportSpan(data)
+	}
+}
+
+// spanKey — ключ текущего спана в контексте
+type spanKey struct{}
+
+// remoteKey — ключ родительского спана из другого сервиса в контексте
+type remoteKey struct{}
+
+// SpanFromContext возвращает текущий спан из контекста
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext возвращает контекст, в котором sc станет
+// родителем следующего спана, например после разбора входящего traceparent
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext возвращает данные текущего спана: локального,
+// а если его нет — полученного от другого сервиса
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,800 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: commandbot/v1/commandbot.proto
+
+// Пакет commandbot.v1 — gRPC API сервиса. Команды выполняются тем же
+// обработчиком, что и в HTTP API, с теми же проверками доступа и лимитами.
+
+package commandbotv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ExecuteRequest — команда с префиксом и аргументами, как в поле command HTTP API
+type ExecuteRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Command string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	// user_id и chat_id необязательны; при включенной аутентификации
+	// пользователь определяется учетными данными
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ChatId string `protobuf:"bytes,3,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	// sent_at — время отправки запроса клиентом, позволяет измерить задержку доставки
+	SentAt        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteRequest) Reset() {
+	*x = ExecuteRequest{}
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteRequest) ProtoMessage() {}
+
+func (x *ExecuteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteRequest) Descriptor() ([]byte, []int) {
+	return file_commandbot_v1_commandbot_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ExecuteRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *ExecuteRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ExecuteRequest) GetChatId() string {
+	if x != nil {
+		return x.ChatId
+	}
+	return ""
+}
+
+func (x *ExecuteRequest) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+type ExecuteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Response      string                 `protobuf:"bytes,1,opt,name=response,proto3" json:"response,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteResponse) Reset() {
+	*x = ExecuteResponse{}
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteResponse) ProtoMessage() {}
+
+func (x *ExecuteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteResponse.ProtoReflect.Descriptor instead.
+func (*ExecuteResponse) Descriptor() ([]byte, []int) {
+	return file_commandbot_v1_commandbot_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ExecuteResponse) GetResponse() string {
+	if x != nil {
+		return x.Response
+	}
+	return ""
+}
+
+// ExecuteStreamRequest — команда, как в ExecuteRequest
+type ExecuteStreamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ChatId        string                 `protobuf:"bytes,3,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	SentAt        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteStreamRequest) Reset() {
+	*x = ExecuteStreamRequest{}
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteStreamRequest) ProtoMessage() {}
+
+func (x *ExecuteStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteStreamRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteStreamRequest) Descriptor() ([]byte, []int) {
+	return file_commandbot_v1_commandbot_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExecuteStreamRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *ExecuteStreamRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ExecuteStreamRequest) GetChatId() string {
+	if x != nil {
+		return x.ChatId
+	}
+	return ""
+}
+
+func (x *ExecuteStreamRequest) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+// ExecuteStreamResponse — очередная часть ответа команды
+type ExecuteStreamResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Chunk         string                 `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteStreamResponse) Reset() {
+	*x = ExecuteStreamResponse{}
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteStreamResponse) ProtoMessage() {}
+
+func (x *ExecuteStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteStreamResponse.ProtoReflect.Descriptor instead.
+func (*ExecuteStreamResponse) Descriptor() ([]byte, []int) {
+	return file_commandbot_v1_commandbot_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ExecuteStreamResponse) GetChunk() string {
+	if x != nil {
+		return x.Chunk
+	}
+	return ""
+}
+
+type ListCommandsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommandsRequest) Reset() {
+	*x = ListCommandsRequest{}
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommandsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommandsRequest) ProtoMessage() {}
+
+func (x *ListCommandsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommandsRequest.ProtoReflect.Descriptor instead.
+func (*ListCommandsRequest) Descriptor() ([]byte, []int) {
+	return file_commandbot_v1_commandbot_proto_rawDescGZIP(), []int{4}
+}
+
+type ListCommandsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// prefix — префикс, с которого начинаются команды в ExecuteRequest.command
+	Prefix        string     `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Commands      []*Command `protobuf:"bytes,2,rep,name=commands,proto3" json:"commands,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommandsResponse) Reset() {
+	*x = ListCommandsResponse{}
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommandsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommandsResponse) ProtoMessage() {}
+
+func (x *ListCommandsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommandsResponse.ProtoReflect.Descriptor instead.
+func (*ListCommandsResponse) Descriptor() ([]byte, []int) {
+	return file_commandbot_v1_commandbot_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListCommandsResponse) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ListCommandsResponse) GetCommands() []*Command {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+type GetCommandRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name — имя или псевдоним команды без префикса
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCommandRequest) Reset() {
+	*x = GetCommandRequest{}
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCommandRequest) ProtoMessage() {}
+
+func (x *GetCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCommandRequest.ProtoReflect.Descriptor instead.
+func (*GetCommandRequest) Descriptor() ([]byte, []int) {
+	return file_commandbot_v1_commandbot_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetCommandRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetCommandResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Command       *Command               `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCommandResponse) Reset() {
+	*x = GetCommandResponse{}
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCommandResponse) ProtoMessage() {}
+
+func (x *GetCommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCommandResponse.ProtoReflect.Descriptor instead.
+func (*GetCommandResponse) Descriptor() ([]byte, []int) {
+	return file_commandbot_v1_commandbot_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetCommandResponse) GetCommand() *Command {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+// Command — описание команды, как в GET /commands
+type Command struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Aliases       []string               `protobuf:"bytes,2,rep,name=aliases,proto3" json:"aliases,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Usage         string                 `protobuf:"bytes,4,opt,name=usage,proto3" json:"usage,omitempty"`
+	Category      string                 `protobuf:"bytes,5,opt,name=category,proto3" json:"category,omitempty"`
+	Examples      []*Example             `protobuf:"bytes,6,rep,name=examples,proto3" json:"examples,omitempty"`
+	Permissions   []string               `protobuf:"bytes,7,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	Arguments     []*Argument            `protobuf:"bytes,8,rep,name=arguments,proto3" json:"arguments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Command) Reset() {
+	*x = Command{}
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Command) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Command) ProtoMessage() {}
+
+func (x *Command) ProtoReflect() protoreflect.Message {
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Command.ProtoReflect.Descriptor instead.
+func (*Command) Descriptor() ([]byte, []int) {
+	return file_commandbot_v1_commandbot_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Command) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Command) GetAliases() []string {
+	if x != nil {
+		return x.Aliases
+	}
+	return nil
+}
+
+func (x *Command) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Command) GetUsage() string {
+	if x != nil {
+		return x.Usage
+	}
+	return ""
+}
+
+func (x *Command) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Command) GetExamples() []*Example {
+	if x != nil {
+		return x.Examples
+	}
+	return nil
+}
+
+func (x *Command) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *Command) GetArguments() []*Argument {
+	if x != nil {
+		return x.Arguments
+	}
+	return nil
+}
+
+type Example struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Input         string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Example) Reset() {
+	*x = Example{}
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Example) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Example) ProtoMessage() {}
+
+func (x *Example) ProtoReflect() protoreflect.Message {
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Example.ProtoReflect.Descriptor instead.
+func (*Example) Descriptor() ([]byte, []int) {
+	return file_commandbot_v1_commandbot_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Example) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *Example) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type Argument struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// type — string, number или integer
+	Type        string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Description string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Required    bool   `protobuf:"varint,4,opt,name=required,proto3" json:"required,omitempty"`
+	// variadic — аргумент забирает все оставшиеся слова ввода
+	Variadic bool `protobuf:"varint,5,opt,name=variadic,proto3" json:"variadic,omitempty"`
+	// enum — допустимые значения, если их набор ограничен
+	Enum          []string `protobuf:"bytes,6,rep,name=enum,proto3" json:"enum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Argument) Reset() {
+	*x = Argument{}
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Argument) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Argument) ProtoMessage() {}
+
+func (x *Argument) ProtoReflect() protoreflect.Message {
+	mi := &file_commandbot_v1_commandbot_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Argument.ProtoReflect.Descriptor instead.
+func (*Argument) Descriptor() ([]byte, []int) {
+	return file_commandbot_v1_commandbot_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Argument) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Argument) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Argument) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Argument) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+func (x *Argument) GetVariadic() bool {
+	if x != nil {
+		return x.Variadic
+	}
+	return false
+}
+
+func (x *Argument) GetEnum() []string {
+	if x != nil {
+		return x.Enum
+	}
+	return nil
+}
+
+var File_commandbot_v1_commandbot_proto protoreflect.FileDescriptor
+
+const file_commandbot_v1_commandbot_proto_rawDesc = "" +
+	"\n" +
+	"\x1ecommandbot/v1/commandbot.proto\x12\rcommandbot.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x91\x01\n" +
+	"\x0eExecuteRequest\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x17\n" +
+	"\achat_id\x18\x03 \x01(\tR\x06chatId\x123\n" +
+	"\asent_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06sentAt\"-\n" +
+	"\x0fExecuteResponse\x12\x1a\n" +
+	"\bresponse\x18\x01 \x01(\tR\bresponse\"\x97\x01\n" +
+	"\x14ExecuteStreamRequest\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x17\n" +
+	"\achat_id\x18\x03 \x01(\tR\x06chatId\x123\n" +
+	"\asent_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06sentAt\"-\n" +
+	"\x15ExecuteStreamResponse\x12\x14\n" +
+	"\x05chunk\x18\x01 \x01(\tR\x05chunk\"\x15\n" +
+	"\x13ListCommandsRequest\"b\n" +
+	"\x14ListCommandsResponse\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x122\n" +
+	"\bcommands\x18\x02 \x03(\v2\x16.commandbot.v1.CommandR\bcommands\"'\n" +
+	"\x11GetCommandRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"F\n" +
+	"\x12GetCommandResponse\x120\n" +
+	"\acommand\x18\x01 \x01(\v2\x16.commandbot.v1.CommandR\acommand\"\x98\x02\n" +
+	"\aCommand\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aaliases\x18\x02 \x03(\tR\aaliases\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05usage\x18\x04 \x01(\tR\x05usage\x12\x1a\n" +
+	"\bcategory\x18\x05 \x01(\tR\bcategory\x122\n" +
+	"\bexamples\x18\x06 \x03(\v2\x16.commandbot.v1.ExampleR\bexamples\x12 \n" +
+	"\vpermissions\x18\a \x03(\tR\vpermissions\x125\n" +
+	"\targuments\x18\b \x03(\v2\x17.commandbot.v1.ArgumentR\targuments\"A\n" +
+	"\aExample\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"\xa0\x01\n" +
+	"\bArgument\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1a\n" +
+	"\brequired\x18\x04 \x01(\bR\brequired\x12\x1a\n" +
+	"\bvariadic\x18\x05 \x01(\bR\bvariadic\x12\x12\n" +
+	"\x04enum\x18\x06 \x03(\tR\x04enum2\xe4\x02\n" +
+	"\x0eCommandService\x12H\n" +
+	"\aExecute\x12\x1d.commandbot.v1.ExecuteRequest\x1a\x1e.commandbot.v1.ExecuteResponse\x12\\\n" +
+	"\rExecuteStream\x12#.commandbot.v1.ExecuteStreamRequest\x1a$.commandbot.v1.ExecuteStreamResponse0\x01\x12W\n" +
+	"\fListCommands\x12\".commandbot.v1.ListCommandsRequest\x1a#.commandbot.v1.ListCommandsResponse\x12Q\n" +
+	"\n" +
+	"GetCommand\x12 .commandbot.v1.GetCommandRequest\x1a!.commandbot.v1.GetCommandResponseB0Z.command-bot/pkg/api/commandbot/v1;commandbotv1b\x06proto3"
+
+var (
+	file_commandbot_v1_commandbot_proto_rawDescOnce sync.Once
+	file_commandbot_v1_commandbot_proto_rawDescData []byte
+)
+
+func file_commandbot_v1_commandbot_proto_rawDescGZIP() []byte {
+	file_commandbot_v1_commandbot_proto_rawDescOnce.Do(func() {
+		file_commandbot_v1_commandbot_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_commandbot_v1_commandbot_proto_rawDesc), len(file_commandbot_v1_commandbot_proto_rawDesc)))
+	})
+	return file_commandbot_v1_commandbot_proto_rawDescData
+}
+
+var file_commandbot_v1_commandbot_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_commandbot_v1_commandbot_proto_goTypes = []any{
+	(*ExecuteRequest)(nil),        // 0: commandbot.v1.ExecuteRequest
+	(*ExecuteResponse)(nil),       // 1: commandbot.v1.ExecuteResponse
+	(*ExecuteStreamRequest)(nil),  // 2: commandbot.v1.ExecuteStreamRequest
+	(*ExecuteStreamResponse)(nil), // 3: commandbot.v1.ExecuteStreamResponse
+	(*ListCommandsRequest)(nil),   // 4: commandbot.v1.ListCommandsRequest
+	(*ListCommandsResponse)(nil),  // 5: commandbot.v1.ListCommandsResponse
+	(*GetCommandRequest)(nil),     // 6: commandbot.v1.GetCommandRequest
+	(*GetCommandResponse)(nil),    // 7: commandbot.v1.GetCommandResponse
+	(*Command)(nil),               // 8: commandbot.v1.Command
+	(*Example)(nil),               // 9: commandbot.v1.Example
+	(*Argument)(nil),              // 10: commandbot.v1.Argument
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_commandbot_v1_commandbot_proto_depIdxs = []int32{
+	11, // 0: commandbot.v1.ExecuteRequest.sent_at:type_name -> google.protobuf.Timestamp
+	11, // 1: commandbot.v1.ExecuteStreamRequest.sent_at:type_name -> google.protobuf.Timestamp
+	8,  // 2: commandbot.v1.ListCommandsResponse.commands:type_name -> commandbot.v1.Command
+	8,  // 3: commandbot.v1.GetCommandResponse.command:type_name -> commandbot.v1.Command
+	9,  // 4: commandbot.v1.Command.examples:type_name -> commandbot.v1.Example
+	10, // 5: commandbot.v1.Command.arguments:type_name -> commandbot.v1.Argument
+	0,  // 6: commandbot.v1.CommandService.Execute:input_type -> commandbot.v1.ExecuteRequest
+	2,  // 7: commandbot.v1.CommandService.ExecuteStream:input_type -> commandbot.v1.ExecuteStreamRequest
+	4,  // 8: commandbot.v1.CommandService.ListCommands:input_type -> commandbot.v1.ListCommandsRequest
+	6,  // 9: commandbot.v1.CommandService.GetCommand:input_type -> commandbot.v1.GetCommandRequest
+	1,  // 10: commandbot.v1.CommandService.Execute:output_type -> commandbot.v1.ExecuteResponse
+	3,  // 11: commandbot.v1.CommandService.ExecuteStream:output_type -> commandbot.v1.ExecuteStreamResponse
+	5,  // 12: commandbot.v1.CommandService.ListCommands:output_type -> commandbot.v1.ListCommandsResponse
+	7,  // 13: commandbot.v1.CommandService.GetCommand:output_type -> commandbot.v1.GetCommandResponse
+	10, // [10:14] is the sub-list for method output_type
+	6,  // [6:10] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_commandbot_v1_commandbot_proto_init() }
+func file_commandbot_v1_commandbot_proto_init() {
+	if File_commandbot_v1_commandbot_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_commandbot_v1_commandbot_proto_rawDesc), len(file_commandbot_v1_commandbot_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_commandbot_v1_commandbot_proto_goTypes,
+		DependencyIndexes: file_commandbot_v1_commandbot_proto_depIdxs,
+		MessageInfos:      file_commandbot_v1_commandbot_proto_msgTypes,
+	}.Build()
+	File_commandbot_v1_commandbot_proto = out.File
+	file_commandbot_v1_commandbot_proto_goTypes = nil
+	file_commandbot_v1_commandbot_proto_depIdxs = nil
+}
@@ -0,0 +1,108 @@
+package command
+
+import (
+	"sort"
+	"strings"
+)
+
+// OtherCategory — категория для команд, не сообщивших свою категорию
+const OtherCategory = "Other"
+
+// Info — машиночитаемое описание команды для справки, API и генераторов документации
+type Info struct {
+	Name        string    `json:"name"`
+	Aliases     []string  `json:"aliases"`
+	Description string    `json:"description"`
+	Usage       string    `json:"usage"`
+	Category    string    `json:"category"`
+	Examples    []Example `json:"examples"`
+	Permissions []string  `json:"permissions"`
+	// Arguments пуст, если команда не описывает аргументы
+	Arguments []Argument `json:"arguments"`
+}
+
+// Describe собирает описание команды, включая данные необязательных интерфейсов
+func Describe(cmd Command) Info {
+	info := Info{
+		Name:        cmd.Name(),
+		Aliases:     nonNil(cmd.Aliases()),
+		Description: cmd.Description(),
+		Usage:       cmd.Usage(),
+		Category:    CategoryOf(cmd),
+		Examples:    []Example{},
+		Permissions: nonNil(cmd.RequiredPermissions()),
+		Arguments:   []Argument{},
+	}
+
+	if provider, ok := cmd.(ExampleProvider); ok && provider.Examples() != nil {
+		info.Examples = provider.Examples()
+	}
+	if provider, ok := cmd.(ArgumentProvider); ok && provider.Arguments() != nil {
+		info.Arguments = provider.Arguments()
+	}
+
+	return info
+}
+
+// CategoryOf возвращает категорию команды или OtherCategory, если команда ее не сообщает
+func CategoryOf(cmd Command) string {
+	if provider, ok := cmd.(CategoryProvider); ok {
+		if category := strings.TrimSpace(provider.Category()); category != "" {
+			return category
+		}
+	}
+
+	return OtherCategory
+}
+
+// SortedCategories возвращает имена категорий в алфавитном порядке, OtherCategory — последней
+func SortedCategories[T any](categories map[string]T) []string {
+	names := make([]string, 0, len(categories))
+	for name := range categories {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == OtherCategory) != (names[j] == OtherCategory) {
+			return names[j] == OtherCategory
+		}
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+// SortByName сортирует команды по имени
+func SortByName(cmds []Command) {
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].Name() < cmds[j].Name()
+	})
+}
+
+// Matches сообщает, встречается ли term в имени, псевдонимах или описании команды без учета регистра
+func Matches(cmd Command, term string) bool {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		return true
+	}
+
+	if strings.Contains(strings.ToLower(cmd.Name()), term) ||
+		strings.Contains(strings.ToLower(cmd.Description()), term) {
+		return true
+	}
+
+	for _, alias := range cmd.Aliases() {
+		if strings.Contains(strings.ToLower(alias), term) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -2,15 +2,6 @@ package command
 
 import (
 	"context"
-	"errors"
-)
-
-// Общие ошибки
-var (
-	ErrCommandNotFound        = errors.New("command not found")
-	ErrInvalidArguments       = errors.New("invalid command arguments")
-	ErrCommandExecutionFailed = errors.New("command execution failed")
-	ErrPermissionDenied       = errors.New("permission denied to execute command")
 )
 
 type CommandContext struct {
@@ -31,6 +22,48 @@ type Command interface {
 	RequiredPermissions() []string
 }
 
+// Example описывает пример вызова команды.
+// Input указывается без префикса команды, как и строка Usage.
+type Example struct {
+	Input       string
+	Description string
+}
+
+// ExampleProvider реализуется командами, которые предоставляют примеры использования для справки
+type ExampleProvider interface {
+	Examples() []Example
+}
+
+// Типы аргументов команды
+const (
+	ArgumentString  = "string"
+	ArgumentNumber  = "number"
+	ArgumentInteger = "integer"
+)
+
+// Argument описывает позиционный аргумент команды для клиентов API,
+// которые строят формы ввода и проверяют запросы до отправки
+type Argument struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	// Variadic — аргумент забирает все оставшиеся слова ввода
+	Variadic bool `json:"variadic,omitempty"`
+	// Enum — допустимые значения, если их набор ограничен
+	Enum []string `json:"enum,omitempty"`
+}
+
+// ArgumentProvider реализуется командами, которые описывают свои аргументы
+type ArgumentProvider interface {
+	Arguments() []Argument
+}
+
+// CategoryProvider реализуется командами, которые относят себя к категории в справке
+type CategoryProvider interface {
+	Category() string
+}
+
 type CommandHandler interface {
 	RegisterCommand(cmd Command) error
 	UnregisterCommand(name string) error
@@ -39,3 +72,27 @@ type CommandHandler interface {
 	ExecuteCommand(ctx context.Context, cmdCtx CommandContext) (string, error)
 	ParseCommand(input string, userID, chatID string) (CommandContext, error)
 }
+
+// ExecuteFunc выполняет найденную команду. cmd равен nil, если команда не найдена:
+// так промежуточные обработчики видят и неудачные вызовы.
+type ExecuteFunc func(ctx context.Context, cmd Command, cmdCtx CommandContext) (string, error)
+
+// Middleware оборачивает выполнение команд, например для метрик или трассировки.
+// Обработчик применяет его к каждому вызову независимо от транспорта.
+type Middleware func(next ExecuteFunc) ExecuteFunc
+
+// HealthReport — состояние зависимости команды, например внешнего API
+type HealthReport struct {
+	// Err — причина неисправности; nil означает, что команда работоспособна
+	Err error
+	// Critical — неисправность делает сервис неготовым принимать запросы
+	Critical bool
+	// Details — дополнительные сведения, например время последнего успешного запроса
+	Details map[string]any
+}
+
+// HealthChecker реализуется командами, которые сообщают о своем состоянии
+// в проверках готовности сервиса
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) HealthReport
+}
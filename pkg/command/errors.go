@@ -0,0 +1,75 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Общие ошибки
+var (
+	ErrCommandNotFound        = errors.New("command not found")
+	ErrInvalidArguments       = errors.New("invalid command arguments")
+	ErrCommandExecutionFailed = errors.New("command execution failed")
+	ErrPermissionDenied       = errors.New("permission denied to execute command")
+	// ErrUnavailable — внешний сервис, от которого зависит команда, не ответил или ответил ошибкой
+	ErrUnavailable = errors.New("external service unavailable")
+)
+
+// NotFoundError — команда не найдена; errors.Is(err, ErrCommandNotFound) для нее истинно
+type NotFoundError struct {
+	Name string
+	// Suggestions — похожие имена зарегистрированных команд
+	Suggestions []string
+}
+
+func (e *NotFoundError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("command not found: %s", e.Name)
+	}
+	return fmt.Sprintf("command not found: %s (did you mean %s?)", e.Name, strings.Join(e.Suggestions, ", "))
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return ErrCommandNotFound
+}
+
+// UsageError — неверные аргументы с подсказкой по использованию команды;
+// errors.Is(err, ErrInvalidArguments) для нее истинно
+type UsageError struct {
+	// Reason — что не так с аргументами
+	Reason string
+	// Usage — строка использования команды или подкоманды
+	Usage string
+}
+
+// NewUsageError создает ошибку аргументов с подсказкой usage
+func NewUsageError(usage, format string, args ...any) *UsageError {
+	return &UsageError{Reason: fmt.Sprintf(format, args...), Usage: usage}
+}
+
+func (e *UsageError) Error() string {
+	if e.Usage == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s, usage: %s", e.Reason, e.Usage)
+}
+
+func (e *UsageError) Unwrap() error {
+	return ErrInvalidArguments
+}
+
+// UnavailableError — сбой внешнего сервиса Service;
+// errors.Is(err, ErrUnavailable) для нее истинно
+type UnavailableError struct {
+	Service string
+	Err     error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s is unavailable: %v", e.Service, e.Err)
+}
+
+func (e *UnavailableError) Unwrap() []error {
+	return []error{ErrUnavailable, e.Err}
+}
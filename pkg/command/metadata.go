@@ -0,0 +1,74 @@
+package command
+
+import "time"
+
+// Ключи метаданных, которые транспорт помещает в CommandContext.Metadata
+const (
+	// MetadataReceivedAt — момент получения сообщения транспортом (time.Time)
+	MetadataReceivedAt = "received_at"
+	// MetadataSentAt — момент отправки сообщения клиентом, если клиент его сообщил (time.Time)
+	MetadataSentAt = "sent_at"
+	// MetadataPermissions — разрешения вызывающего пользователя ([]string)
+	MetadataPermissions = "permissions"
+	// MetadataRequestID — идентификатор запроса для сопоставления записей журнала (string)
+	MetadataRequestID = "request_id"
+	// MetadataStream — получатель частей ответа (StreamWriter), см. CommandContext.Emit
+	MetadataStream = "stream"
+)
+
+// AllPermissions — разрешение, включающее все остальные
+const AllPermissions = "*"
+
+// MetadataTime возвращает значение метаданных типа time.Time по ключу
+func (c CommandContext) MetadataTime(key string) (time.Time, bool) {
+	if c.Metadata == nil {
+		return time.Time{}, false
+	}
+
+	t, ok := c.Metadata[key].(time.Time)
+	if !ok || t.IsZero() {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// RequestID возвращает идентификатор запроса, если транспорт его передал
+func (c CommandContext) RequestID() string {
+	id, _ := c.Metadata[MetadataRequestID].(string)
+	return id
+}
+
+// Permissions возвращает разрешения вызывающего пользователя из метаданных
+func (c CommandContext) Permissions() []string {
+	if c.Metadata == nil {
+		return nil
+	}
+
+	permissions, _ := c.Metadata[MetadataPermissions].([]string)
+	return permissions
+}
+
+// HasPermissions сообщает, покрывают ли выданные разрешения все требуемые
+func HasPermissions(granted, required []string) bool {
+	set := make(map[string]bool, len(granted))
+	for _, p := range granted {
+		if p == AllPermissions {
+			return true
+		}
+		set[p] = true
+	}
+
+	for _, p := range required {
+		if !set[p] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CanExecute сообщает, достаточно ли у вызывающего разрешений для выполнения команды
+func (c CommandContext) CanExecute(cmd Command) bool {
+	return HasPermissions(c.Permissions(), cmd.RequiredPermissions())
+}
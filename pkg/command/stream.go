@@ -0,0 +1,70 @@
+package command
+
+import (
+	"strings"
+	"sync"
+)
+
+// StreamWriter получает части ответа команды по мере их готовности.
+// Транспорт, который умеет передавать ответ по частям, помещает его
+// в метаданные по ключу MetadataStream.
+type StreamWriter interface {
+	WriteChunk(chunk string) error
+}
+
+// StreamFunc позволяет использовать функцию как StreamWriter
+type StreamFunc func(chunk string) error
+
+// WriteChunk вызывает f(chunk)
+func (f StreamFunc) WriteChunk(chunk string) error {
+	return f(chunk)
+}
+
+// ChunkBuffer собирает части ответа в одну строку. Его использует обработчик
+// команд, если транспорт не передает ответ по частям.
+type ChunkBuffer struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+// WriteChunk добавляет часть к собранному ответу
+func (b *ChunkBuffer) WriteChunk(chunk string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sb.WriteString(chunk)
+	return nil
+}
+
+// String возвращает собранные части
+func (b *ChunkBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.sb.String()
+}
+
+// Stream возвращает получателя частей ответа или nil, если его не задали
+func (c CommandContext) Stream() StreamWriter {
+	w, _ := c.Metadata[MetadataStream].(StreamWriter)
+	return w
+}
+
+// SetStream задает получателя частей ответа
+func (c *CommandContext) SetStream(w StreamWriter) {
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]interface{})
+	}
+	c.Metadata[MetadataStream] = w
+}
+
+// Emit отправляет часть ответа до завершения команды. Итоговый ответ команды
+// продолжает отправленные части: без потокового транспорта обработчик
+// возвращает их вместе одной строкой. Без получателя часть отбрасывается.
+func (c CommandContext) Emit(chunk string) error {
+	w := c.Stream()
+	if w == nil || chunk == "" {
+		return nil
+	}
+	return w.WriteChunk(chunk)
+}
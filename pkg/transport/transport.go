@@ -0,0 +1,77 @@
+// Пакет transport описывает платформы, через которые бот получает и отправляет
+// сообщения: терминал, HTTP API, мессенджеры. Фоновые функции — напоминания,
+// оповещения, рассылки — отправляют сообщения в чат через Sender, не зная платформы.
+package transport
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrUnsupported возвращается операциями, которые транспорт не поддерживает,
+// см. Capabilities
+var ErrUnsupported = errors.New("operation is not supported by the transport")
+
+// Message — входящее сообщение
+type Message struct {
+	// ID — идентификатор сообщения на платформе; пустой, если платформа его не назначает
+	ID     string
+	ChatID string
+	UserID string
+	Text   string
+	// Time — момент получения сообщения транспортом
+	Time time.Time
+	// Metadata попадает в метаданные контекста команды; ключи — command.Metadata*
+	Metadata map[string]any
+}
+
+// Handler обрабатывает входящее сообщение и возвращает ответ на него.
+// Как доставить ответ, решает транспорт: терминал печатает его,
+// HTTP API возвращает в теле ответа.
+type Handler interface {
+	HandleMessage(ctx context.Context, msg Message) (string, error)
+}
+
+// HandlerFunc позволяет использовать функцию как Handler
+type HandlerFunc func(ctx context.Context, msg Message) (string, error)
+
+// HandleMessage вызывает f(ctx, msg)
+func (f HandlerFunc) HandleMessage(ctx context.Context, msg Message) (string, error) {
+	return f(ctx, msg)
+}
+
+// Capabilities — возможности транспорта
+type Capabilities struct {
+	// Push — транспорт доставляет сообщения, которые не являются ответом на входящее
+	Push bool
+	// Edit и Delete — транспорт изменяет и удаляет отправленные сообщения
+	Edit   bool
+	Delete bool
+	// Stream — транспорт показывает части ответа по мере готовности, см. command.CommandContext.Emit
+	Stream bool
+	// MaxMessageLength — наибольшая длина сообщения в символах; 0 — без ограничения
+	MaxMessageLength int
+}
+
+// Sender отправляет, изменяет и удаляет сообщения в чатах
+type Sender interface {
+	// Send отправляет сообщение в чат и возвращает его идентификатор для Edit и Delete
+	Send(ctx context.Context, chatID, text string) (string, error)
+	// Edit заменяет текст отправленного сообщения
+	Edit(ctx context.Context, chatID, messageID, text string) error
+	// Delete удаляет отправленное сообщение
+	Delete(ctx context.Context, chatID, messageID string) error
+}
+
+// Transport — платформа обмена сообщениями
+type Transport interface {
+	Sender
+
+	// Name — имя транспорта для журнала и метрик
+	Name() string
+	Capabilities() Capabilities
+	// Receive передает входящие сообщения handler и возвращает управление,
+	// когда сообщения закончились или отменен ctx
+	Receive(ctx context.Context, handler Handler) error
+}
@@ -0,0 +1,160 @@
+package auth_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"command-bot/internal/auth"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestNewAuthenticatorDisabledWithoutCredentials(t *testing.T) {
+	a, err := auth.NewAuthenticator(auth.Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.Enabled() {
+		t.Error("Expected authentication to be disabled")
+	}
+
+	if _, err := auth.NewAuthenticator(auth.Options{APIKeys: []auth.APIKey{{SHA256: "abc", UserID: "alice"}}}); err == nil {
+		t.Error("Expected malformed key hash to be rejected")
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	a, err := auth.NewAuthenticator(auth.Options{APIKeys: []auth.APIKey{
+		{SHA256: auth.HashAPIKey("alice-key"), UserID: "alice", Chats: []string{"team"}},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/command", nil)
+	if _, err := a.Authenticate(req); !errors.Is(err, auth.ErrNoCredentials) {
+		t.Errorf("Expected ErrNoCredentials, got %v", err)
+	}
+
+	req.Header.Set(auth.APIKeyHeader, "alice-key")
+	identity, err := a.Authenticate(req)
+	if err != nil {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+	if identity.UserID != "alice" || identity.Method != auth.MethodAPIKey {
+		t.Errorf("Unexpected identity %+v", identity)
+	}
+	if !identity.CanUseChat("team") || identity.CanUseChat("other") || identity.DefaultChat("api-chat") != "team" {
+		t.Errorf("Expected access to chat team only, got %+v", identity)
+	}
+
+	req.Header.Set(auth.APIKeyHeader, "wrong")
+	if _, err := a.Authenticate(req); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestVerifyJWT(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	opts := auth.JWTOptions{Secret: secret, Issuer: "issuer", Audience: "command-bot", Leeway: time.Minute}
+	valid := auth.Claims{
+		Subject:   "bob",
+		Issuer:    "issuer",
+		Audience:  auth.Audience{"command-bot"},
+		ExpiresAt: now.Add(time.Hour).Unix(),
+		Chats:     []string{"ops"},
+	}
+
+	sign := func(claims auth.Claims, key []byte) string {
+		token, err := auth.SignJWT(claims, key)
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		return token
+	}
+	with := func(change func(c *auth.Claims)) auth.Claims {
+		c := valid
+		change(&c)
+		return c
+	}
+
+	claims, err := auth.VerifyJWT(sign(valid, secret), opts, now)
+	if err != nil {
+		t.Fatalf("Failed to verify valid token: %v", err)
+	}
+	if claims.Subject != "bob" || len(claims.Chats) != 1 {
+		t.Errorf("Unexpected claims %+v", claims)
+	}
+
+	// Токен с "alg": "none" и пустой подписью
+	unsigned := "eyJhbGciOiJub25lIn0." + strings.Split(sign(valid, secret), ".")[1] + "."
+
+	tests := map[string]string{
+		"wrong secret":   sign(valid, []byte("another-secret-another-secret-000")),
+		"alg none":       unsigned,
+		"expired":        sign(with(func(c *auth.Claims) { c.ExpiresAt = now.Add(-2 * time.Minute).Unix() }), secret),
+		"no expiration":  sign(with(func(c *auth.Claims) { c.ExpiresAt = 0 }), secret),
+		"not yet valid":  sign(with(func(c *auth.Claims) { c.NotBefore = now.Add(time.Hour).Unix() }), secret),
+		"wrong issuer":   sign(with(func(c *auth.Claims) { c.Issuer = "someone" }), secret),
+		"wrong audience": sign(with(func(c *auth.Claims) { c.Audience = auth.Audience{"other"} }), secret),
+		"no subject":     sign(with(func(c *auth.Claims) { c.Subject = "" }), secret),
+		"malformed":      "not-a-token",
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := auth.VerifyJWT(token, opts, now); err == nil {
+				t.Error("Expected token to be rejected")
+			}
+		})
+	}
+
+	// Расхождение часов в пределах leeway допускается
+	recent := sign(with(func(c *auth.Claims) { c.ExpiresAt = now.Add(-30 * time.Second).Unix() }), secret)
+	if _, err := auth.VerifyJWT(recent, opts, now); err != nil {
+		t.Errorf("Expected token within leeway to be accepted, got %v", err)
+	}
+}
+
+func TestAuthenticateClientCertificate(t *testing.T) {
+	a, err := auth.NewAuthenticator(auth.Options{ClientCerts: []auth.ClientCert{
+		{Subject: "deploy-bot", UserID: "deployer", Chats: []string{"releases"}},
+		{Subject: "spiffe://example.org/monitor", UserID: "monitor"},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+
+	request := func(cert *x509.Certificate) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/command", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		return req
+	}
+
+	identity, err := a.Authenticate(request(&x509.Certificate{Subject: pkix.Name{CommonName: "deploy-bot"}}))
+	if err != nil || identity.UserID != "deployer" || identity.Method != auth.MethodClientCert {
+		t.Errorf("Expected identity from common name, got %+v, %v", identity, err)
+	}
+
+	monitor, _ := url.Parse("spiffe://example.org/monitor")
+	identity, err = a.Authenticate(request(&x509.Certificate{Subject: pkix.Name{CommonName: "host"}, URIs: []*url.URL{monitor}}))
+	if err != nil || identity.UserID != "monitor" {
+		t.Errorf("Expected identity from URI SAN, got %+v, %v", identity, err)
+	}
+
+	if _, err := a.Authenticate(request(&x509.Certificate{Subject: pkix.Name{CommonName: "stranger"}})); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("Expected unmapped certificate to be rejected, got %v", err)
+	}
+
+	// Без проверенной цепочки сертификат не учитывается
+	if _, err := a.Authenticate(httptest.NewRequest(http.MethodPost, "/command", nil)); !errors.Is(err, auth.ErrNoCredentials) {
+		t.Errorf("Expected ErrNoCredentials without TLS, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"command-bot/internal/bot/command/commands"
+	pkgcommand "command-bot/pkg/command"
+)
+
+func runEcho(t *testing.T, raw string) (string, error) {
+	t.Helper()
+
+	return commands.NewEchoCommand().Execute(context.Background(), pkgcommand.CommandContext{
+		UserID:    "alice",
+		ChatID:    "team",
+		Arguments: strings.Fields(raw)[1:],
+		RawInput:  raw,
+	})
+}
+
+func TestEchoTemplates(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"echo Hello World", "Hello World"},
+		{"echo Hello {user} from {chat}", "Hello alice from team"},
+		{"echo {user|upper}!", "ALICE!"},
+		{"echo {arg2|title} is {argc} words", "Is is 4 words"},
+		{"echo {user|repeat:3}", "alicealicealice"},
+		{"echo {unknown} stays", "{unknown} stays"},
+		{"echo {{user}}", "{user}"},
+		{"echo --code {user}", "```\nalice\n```"},
+		{"echo --raw a   b    {user}", "a   b    alice"},
+		// С --literal текст повторяется как есть
+		{"echo --literal {user} {{x}}", "{user} {{x}}"},
+		{"echo --code a ``` b", "````\na ``` b\n````"},
+	}
+
+	for _, tt := range tests {
+		response, err := runEcho(t, tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+
+		if response != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, response)
+		}
+	}
+}
+
+func TestEchoTemplateLimits(t *testing.T) {
+	for _, input := range []string{
+		"echo {user|repeat:1000}",
+		"echo {user|repeat:abc}",
+		"echo {user|shell}",
+	} {
+		if _, err := runEcho(t, input); !errors.Is(err, pkgcommand.ErrInvalidArguments) {
+			t.Errorf("%q: expected ErrInvalidArguments, got %v", input, err)
+		}
+	}
+}
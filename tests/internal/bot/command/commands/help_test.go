@@ -0,0 +1,206 @@
+package commands_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"command-bot/internal/bot/command"
+	"command-bot/internal/bot/command/commands"
+	pkgcommand "command-bot/pkg/command"
+)
+
+// customCommand — команда без категории и примеров
+type customCommand struct{}
+
+func (c *customCommand) Name() string                  { return "custom" }
+func (c *customCommand) Aliases() []string             { return nil }
+func (c *customCommand) Description() string           { return "Custom command" }
+func (c *customCommand) Usage() string                 { return "custom" }
+func (c *customCommand) RequiredPermissions() []string { return nil }
+
+func (c *customCommand) Execute(ctx context.Context, cmdCtx pkgcommand.CommandContext) (string, error) {
+	return "", nil
+}
+
+// describedCommand сообщает категорию и примеры через необязательные интерфейсы
+type describedCommand struct {
+	customCommand
+}
+
+func (c *describedCommand) Name() string     { return "described" }
+func (c *describedCommand) Category() string { return "Admin" }
+
+func (c *describedCommand) Examples() []pkgcommand.Example {
+	return []pkgcommand.Example{{Input: "described now", Description: "Runs it now"}}
+}
+
+func newHelpHandler(t *testing.T, cmds ...pkgcommand.Command) *command.Handler {
+	t.Helper()
+
+	handler := command.NewHandler("!")
+	for _, cmd := range cmds {
+		if err := handler.RegisterCommand(cmd); err != nil {
+			t.Fatalf("Failed to register %s: %v", cmd.Name(), err)
+		}
+	}
+
+	if err := handler.RegisterCommand(commands.NewHelpCommand(handler)); err != nil {
+		t.Fatalf("Failed to register help: %v", err)
+	}
+
+	return handler
+}
+
+func runHelp(t *testing.T, handler *command.Handler, input string) string {
+	t.Helper()
+
+	cmdCtx, err := handler.ParseCommand(input, "user", "chat")
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", input, err)
+	}
+
+	response, err := handler.ExecuteCommand(context.Background(), cmdCtx)
+	if err != nil {
+		t.Fatalf("Failed to execute %q: %v", input, err)
+	}
+
+	return response
+}
+
+func TestHelpUsesCommandMetadata(t *testing.T) {
+	handler := newHelpHandler(t, &customCommand{}, &describedCommand{}, commands.NewCalcCommand())
+
+	response := runHelp(t, handler, "!help described")
+	if !strings.Contains(response, "Category: Admin") {
+		t.Errorf("Expected category in command help, got %q", response)
+	}
+
+	if !strings.Contains(response, "!described now - Runs it now") {
+		t.Errorf("Expected example with handler prefix, got %q", response)
+	}
+
+	response = runHelp(t, handler, "!help custom")
+	if !strings.Contains(response, "No examples available") || !strings.Contains(response, "Category: Other") {
+		t.Errorf("Expected fallback examples and category, got %q", response)
+	}
+}
+
+func TestHelpCategoriesAreSorted(t *testing.T) {
+	handler := newHelpHandler(t, &customCommand{}, &describedCommand{}, commands.NewCalcCommand())
+
+	// Порядок не должен зависеть от порядка обхода карты
+	first := runHelp(t, handler, "!help")
+	for i := 0; i < 10; i++ {
+		if again := runHelp(t, handler, "!help"); again != first {
+			t.Fatalf("Expected stable help output, got %q and %q", first, again)
+		}
+	}
+
+	admin := strings.Index(first, "== Admin ==")
+	fun := strings.Index(first, "== Fun ==")
+	utility := strings.Index(first, "== Utility ==")
+	other := strings.Index(first, "== Other ==")
+
+	if admin < 0 || fun < 0 || utility < 0 || other < 0 {
+		t.Fatalf("Expected all categories in help output, got %q", first)
+	}
+
+	if !(admin < fun && fun < utility && utility < other) {
+		t.Errorf("Expected categories sorted with Other last, got %q", first)
+	}
+}
+
+// restrictedCommand требует разрешения admin
+type restrictedCommand struct {
+	customCommand
+}
+
+func (c *restrictedCommand) Name() string                  { return "restricted" }
+func (c *restrictedCommand) Description() string           { return "Admin only" }
+func (c *restrictedCommand) RequiredPermissions() []string { return []string{"admin"} }
+
+func TestHelpSearchAndPermissions(t *testing.T) {
+	handler := newHelpHandler(t, &customCommand{}, &restrictedCommand{}, commands.NewTimeCommand())
+
+	// Поиск находит команду по псевдониму
+	response := runHelp(t, handler, "!help search now")
+	if !strings.Contains(response, "time") || strings.Contains(response, "custom") {
+		t.Errorf("Expected only time command in search results, got %q", response)
+	}
+
+	// Без разрешения admin команда не видна ни в списке, ни в подробной справке
+	if response = runHelp(t, handler, "!help"); strings.Contains(response, "restricted") {
+		t.Errorf("Expected restricted command to be hidden, got %q", response)
+	}
+
+	cmdCtx, _ := handler.ParseCommand("!help restricted", "user", "chat")
+	if _, err := handler.ExecuteCommand(context.Background(), cmdCtx); err == nil {
+		t.Error("Expected error for help on restricted command, got nil")
+	}
+
+	cmdCtx, _ = handler.ParseCommand("!help", "admin", "chat")
+	cmdCtx.Metadata[pkgcommand.MetadataPermissions] = []string{"admin"}
+	response, err := handler.ExecuteCommand(context.Background(), cmdCtx)
+	if err != nil {
+		t.Fatalf("Failed to execute help: %v", err)
+	}
+
+	if !strings.Contains(response, "restricted") {
+		t.Errorf("Expected restricted command for admin, got %q", response)
+	}
+}
+
+func TestHelpJSONAndPagination(t *testing.T) {
+	var cmds []pkgcommand.Command
+	for i := 0; i < 12; i++ {
+		cmds = append(cmds, &numberedCommand{n: i})
+	}
+	handler := newHelpHandler(t, cmds...)
+
+	var page struct {
+		Page     int `json:"page"`
+		Pages    int `json:"pages"`
+		Total    int `json:"total"`
+		Commands []struct {
+			Name     string `json:"name"`
+			Category string `json:"category"`
+		} `json:"commands"`
+	}
+
+	response := runHelp(t, handler, "!help --page 2 --format json")
+	if err := json.Unmarshal([]byte(response), &page); err != nil {
+		t.Fatalf("Failed to decode JSON help %q: %v", response, err)
+	}
+
+	// 12 команд и сама help: первая страница заполнена, на второй оставшиеся три
+	if page.Page != 2 || page.Pages != 2 || page.Total != 13 || len(page.Commands) != 3 {
+		t.Errorf("Unexpected page: %+v", page)
+	}
+
+	response = runHelp(t, handler, "!help --format markdown")
+	if !strings.Contains(response, "| `!cmd00` |") || !strings.Contains(response, "_Page 1 of 2_") {
+		t.Errorf("Unexpected markdown help: %q", response)
+	}
+
+	response = runHelp(t, handler, "!help search cmd")
+	if !strings.Contains(response, "Type 'help search cmd --page 2' for more.") {
+		t.Errorf("Expected paging hint to keep the search term, got %q", response)
+	}
+
+	cmdCtx, _ := handler.ParseCommand("!help --page 3", "user", "chat")
+	if _, err := handler.ExecuteCommand(context.Background(), cmdCtx); !errors.Is(err, pkgcommand.ErrInvalidArguments) {
+		t.Errorf("Expected ErrInvalidArguments for page out of range, got %v", err)
+	}
+}
+
+// numberedCommand — одна из множества однотипных команд для проверки постраничного вывода
+type numberedCommand struct {
+	customCommand
+	n int
+}
+
+func (c *numberedCommand) Name() string { return fmt.Sprintf("cmd%02d", c.n) }
@@ -0,0 +1,93 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"command-bot/internal/bot/command/commands"
+	pkgcommand "command-bot/pkg/command"
+)
+
+func TestPingReportsProcessingTime(t *testing.T) {
+	cmd := commands.NewPingCommand()
+
+	receivedAt := time.Now().Add(-50 * time.Millisecond)
+	response, err := cmd.Execute(context.Background(), pkgcommand.CommandContext{
+		Metadata: map[string]interface{}{
+			pkgcommand.MetadataReceivedAt: receivedAt,
+			pkgcommand.MetadataSentAt:     receivedAt.Add(-20 * time.Millisecond),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to execute ping: %v", err)
+	}
+
+	if !strings.Contains(response, "Processing time:") {
+		t.Errorf("Expected processing time in response, got %q", response)
+	}
+
+	if !strings.Contains(response, "Transport delay: 20ms") {
+		t.Errorf("Expected transport delay in response, got %q", response)
+	}
+
+	// Без времени получения команда не выдумывает задержку
+	response, err = cmd.Execute(context.Background(), pkgcommand.CommandContext{})
+	if err != nil {
+		t.Fatalf("Failed to execute ping: %v", err)
+	}
+
+	if strings.Contains(response, "Processing time:") {
+		t.Errorf("Expected no processing time without receipt timestamp, got %q", response)
+	}
+}
+
+func TestPingTargetAllowlist(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cmd := commands.NewPingCommandWithTargets([]string{listener.Addr().String(), "127.0.0.1"})
+
+	ping := func(target string) (string, error) {
+		return cmd.Execute(context.Background(), pkgcommand.CommandContext{Arguments: []string{target}})
+	}
+
+	response, err := ping(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to ping TCP target: %v", err)
+	}
+
+	if !strings.Contains(response, "is reachable") {
+		t.Errorf("Expected reachable TCP target, got %q", response)
+	}
+
+	response, err = ping(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to ping HTTP target: %v", err)
+	}
+
+	if !strings.Contains(response, "204 No Content") {
+		t.Errorf("Expected HTTP status in response, got %q", response)
+	}
+
+	if _, err = ping("example.com:443"); !errors.Is(err, pkgcommand.ErrPermissionDenied) {
+		t.Errorf("Expected ErrPermissionDenied for target outside allowlist, got %v", err)
+	}
+
+	if _, err = ping("not-a-target"); !errors.Is(err, pkgcommand.ErrInvalidArguments) {
+		t.Errorf("Expected ErrInvalidArguments for malformed target, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package commands_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"command-bot/internal/bot/command/commands"
+	"command-bot/pkg/command"
+)
+
+func TestWeatherCommandUsesConfiguredEndpoints(t *testing.T) {
+	var timezone string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/geo", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("name"); got != "New York" {
+			t.Errorf("Expected location New York, got %q", got)
+		}
+		w.Write([]byte(`{"results":[{"name":"New York","country":"United States","latitude":40.71,"longitude":-74.01}]}`))
+	})
+	mux.HandleFunc("/forecast", func(w http.ResponseWriter, r *http.Request) {
+		timezone = r.URL.Query().Get("timezone")
+		w.Write([]byte(`{"current_weather":{"temperature":21.5,"windspeed":3.2,"winddirection":180,"weathercode":1,"time":"2024-05-01T12:00"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cmd := commands.NewWeatherCommandWithOptions(commands.WeatherOptions{
+		GeocodingURL: server.URL + "/geo",
+		ForecastURL:  server.URL + "/forecast",
+		Timezone:     "auto",
+	})
+
+	// Название места приходит отдельной частью до погоды
+	cmdCtx := command.CommandContext{Arguments: []string{"New", "York"}}
+	var chunks []string
+	cmdCtx.SetStream(command.StreamFunc(func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	}))
+	response, err := cmd.Execute(context.Background(), cmdCtx)
+	if err != nil {
+		t.Fatalf("Failed to execute weather command: %v", err)
+	}
+
+	if len(chunks) != 1 || !strings.Contains(chunks[0], "New York, United States") || !strings.Contains(response, "21.5°C") {
+		t.Errorf("Unexpected response: %q %s", chunks, response)
+	}
+	if timezone != "auto" {
+		t.Errorf("Expected configured timezone, got %q", timezone)
+	}
+}
+
+func TestWeatherCommandTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	cmd := commands.NewWeatherCommandWithOptions(commands.WeatherOptions{
+		GeocodingURL: server.URL,
+		Timeout:      50 * time.Millisecond,
+	})
+
+	if _, err := cmd.Execute(context.Background(), command.CommandContext{Arguments: []string{"Paris"}}); err == nil {
+		t.Error("Expected timeout error, got nil")
+	}
+}
+
+func TestWeatherCommandHealthCheck(t *testing.T) {
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"results":[]}`))
+	}))
+	defer server.Close()
+
+	cmd := commands.NewWeatherCommandWithOptions(commands.WeatherOptions{GeocodingURL: server.URL})
+	args := command.CommandContext{Arguments: []string{"Nowhere"}}
+
+	if report := cmd.HealthCheck(context.Background()); report.Err != nil || report.Critical {
+		t.Errorf("Expected healthy non-critical report before any request, got %+v", report)
+	}
+
+	cmd.Execute(context.Background(), args)
+	report := cmd.HealthCheck(context.Background())
+	if report.Err == nil || report.Details["last_error"] == nil {
+		t.Errorf("Expected failed request to be reported, got %+v", report)
+	}
+
+	// Неизвестная локация — ответ API, а не его недоступность
+	status = http.StatusOK
+	cmd.Execute(context.Background(), args)
+	report = cmd.HealthCheck(context.Background())
+	if report.Err != nil || report.Details["last_success"] == nil {
+		t.Errorf("Expected successful request to clear the failure, got %+v", report)
+	}
+}
@@ -2,10 +2,14 @@ package command_test
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"testing"
+	"time"
 
 	"command-bot/internal/bot/command"
 	pkgcommand "command-bot/pkg/command"
+	"command-bot/pkg/transport"
 )
 
 // MockCommand - это простая реализация интерфейса Command для тестирования
@@ -168,4 +172,162 @@ func TestExecuteCommand(t *testing.T) {
 	if response != expectedResponse {
 		t.Errorf("Expected response '%s', got '%s'", expectedResponse, response)
 	}
-}
\ No newline at end of file
+}
+
+func TestExecuteCommandLeavesPermissionsToTransports(t *testing.T) {
+	handler := command.NewHandler("/")
+
+	mockCmd := &MockCommand{
+		name:        "admin",
+		permissions: []string{"admin"},
+	}
+
+	if err := handler.RegisterCommand(mockCmd); err != nil {
+		t.Fatalf("Failed to register command: %v", err)
+	}
+
+	cmdCtx, err := handler.ParseCommand("/admin", "user123", "chat456")
+	if err != nil {
+		t.Fatalf("Failed to parse command: %v", err)
+	}
+
+	// Транспорты без разрешений, например терминал, выполняют любые команды
+	if _, err := handler.ExecuteCommand(context.Background(), cmdCtx); err != nil {
+		t.Errorf("Expected command to execute without permissions metadata, got %v", err)
+	}
+}
+
+func TestExecuteUnknownCommandSuggestsSimilar(t *testing.T) {
+	handler := command.NewHandler("/")
+	for _, cmd := range []*MockCommand{
+		{name: "weather", aliases: []string{"w"}},
+		{name: "echo"},
+		{name: "help", aliases: []string{"h", "commands"}},
+	} {
+		if err := handler.RegisterCommand(cmd); err != nil {
+			t.Fatalf("Failed to register %s: %v", cmd.name, err)
+		}
+	}
+
+	tests := map[string][]string{
+		"wether":  {"weather"},
+		"ech":     {"echo"},
+		"comand":  {"help"},
+		"zzzzzzz": nil,
+	}
+	for input, want := range tests {
+		cmdCtx, err := handler.ParseCommand("/"+input, "user", "chat")
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", input, err)
+		}
+
+		_, err = handler.ExecuteCommand(context.Background(), cmdCtx)
+		var notFound *pkgcommand.NotFoundError
+		if !errors.As(err, &notFound) || !errors.Is(err, pkgcommand.ErrCommandNotFound) {
+			t.Fatalf("%s: expected NotFoundError, got %v", input, err)
+		}
+		if !slices.Equal(notFound.Suggestions, want) {
+			t.Errorf("%s: expected suggestions %v, got %v", input, want, notFound.Suggestions)
+		}
+	}
+
+	// Недоступные вызывающему команды не предлагаются
+	if err := handler.RegisterCommand(&MockCommand{name: "shutdown", aliases: []string{"stop"}, permissions: []string{"admin"}}); err != nil {
+		t.Fatalf("Failed to register shutdown: %v", err)
+	}
+	for _, input := range []string{"shutdow", "sto"} {
+		if got := handler.Suggest(pkgcommand.CommandContext{}, input); len(got) != 0 {
+			t.Errorf("%s: expected restricted command to be hidden, got %v", input, got)
+		}
+	}
+	admin := pkgcommand.CommandContext{Metadata: map[string]any{pkgcommand.MetadataPermissions: []string{"admin"}}}
+	if got := handler.Suggest(admin, "sto"); !slices.Equal(got, []string{"shutdown"}) {
+		t.Errorf("Expected shutdown to be suggested to an admin, got %v", got)
+	}
+}
+
+func TestExecuteCommandStreamsChunks(t *testing.T) {
+	handler := command.NewHandler("/")
+	err := handler.RegisterCommand(&MockCommand{
+		name: "progress",
+		executeFunc: func(ctx context.Context, cmdCtx pkgcommand.CommandContext) (string, error) {
+			cmdCtx.Emit("step 1\n")
+			cmdCtx.Emit("step 2\n")
+			return "done", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register command: %v", err)
+	}
+
+	cmdCtx, err := handler.ParseCommand("/progress", "user", "chat")
+	if err != nil {
+		t.Fatalf("Failed to parse command: %v", err)
+	}
+
+	// Без получателя части собираются перед итоговым ответом
+	response, err := handler.ExecuteCommand(context.Background(), cmdCtx)
+	if err != nil {
+		t.Fatalf("Failed to execute command: %v", err)
+	}
+	if response != "step 1\nstep 2\ndone" {
+		t.Errorf("Expected concatenated chunks, got %q", response)
+	}
+	if cmdCtx.Stream() != nil {
+		t.Error("Expected caller's context to stay without a stream writer")
+	}
+
+	// С получателем части приходят ему, а ответ содержит только итог
+	var chunks []string
+	cmdCtx.SetStream(pkgcommand.StreamFunc(func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	}))
+	response, err = handler.ExecuteCommand(context.Background(), cmdCtx)
+	if err != nil {
+		t.Fatalf("Failed to execute command: %v", err)
+	}
+	if response != "done" {
+		t.Errorf("Expected final response only, got %q", response)
+	}
+	if !slices.Equal(chunks, []string{"step 1\n", "step 2\n"}) {
+		t.Errorf("Unexpected chunks: %v", chunks)
+	}
+}
+
+func TestHandleMessage(t *testing.T) {
+	handler := command.NewHandler("/")
+	var got pkgcommand.CommandContext
+	err := handler.RegisterCommand(&MockCommand{
+		name: "whoami",
+		executeFunc: func(ctx context.Context, cmdCtx pkgcommand.CommandContext) (string, error) {
+			got = cmdCtx
+			return cmdCtx.UserID + "@" + cmdCtx.ChatID, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register command: %v", err)
+	}
+
+	receivedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	response, err := handler.HandleMessage(context.Background(), transport.Message{
+		UserID:   "alice",
+		ChatID:   "team",
+		Text:     "/whoami now",
+		Time:     receivedAt,
+		Metadata: map[string]any{pkgcommand.MetadataRequestID: "req-1"},
+	})
+	if err != nil || response != "alice@team" {
+		t.Fatalf("Expected alice@team, got %q %v", response, err)
+	}
+	if got.RequestID() != "req-1" || !slices.Equal(got.Arguments, []string{"now"}) {
+		t.Errorf("Expected metadata and arguments from the message, got %+v", got)
+	}
+	if at, ok := got.MetadataTime(pkgcommand.MetadataReceivedAt); !ok || !at.Equal(receivedAt) {
+		t.Errorf("Expected received_at from the message time, got %v", at)
+	}
+
+	if _, err := handler.HandleMessage(context.Background(), transport.Message{Text: "whoami"}); err == nil {
+		t.Error("Expected error for text without prefix")
+	}
+}
@@ -0,0 +1,71 @@
+package docs_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"command-bot/internal/bot/command"
+	"command-bot/internal/bot/command/commands"
+	"command-bot/internal/bot/docs"
+)
+
+func newReference(t *testing.T) docs.Reference {
+	t.Helper()
+
+	handler := command.NewHandler("/")
+	if err := handler.RegisterCommand(commands.NewCalcCommand()); err != nil {
+		t.Fatalf("Failed to register calc: %v", err)
+	}
+	if err := handler.RegisterCommand(commands.NewTimeCommand()); err != nil {
+		t.Fatalf("Failed to register time: %v", err)
+	}
+	if err := handler.RegisterCommand(commands.NewHelpCommand(handler)); err != nil {
+		t.Fatalf("Failed to register help: %v", err)
+	}
+
+	return docs.Build(handler, "/")
+}
+
+func TestBuildOrdersCategories(t *testing.T) {
+	ref := newReference(t)
+
+	var names []string
+	for _, category := range ref.Categories {
+		names = append(names, category.Name)
+	}
+
+	if strings.Join(names, ",") != "Fun,Information,Utility" {
+		t.Errorf("Unexpected category order: %v", names)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	ref := newReference(t)
+
+	tests := []struct {
+		format   string
+		expected []string
+	}{
+		{"markdown", []string{"## Fun", "### calc", "**Usage:** `/calc <number> <operation> <number>`", "| `/calc 5 + 3` | Calculates 5 + 3 = 8 |"}},
+		{"html", []string{`<section id="calc">`, "<code>/calc &lt;number&gt; &lt;operation&gt; &lt;number&gt;</code>"}},
+		{"man", []string{".TH COMMAND-BOT 7", ".SH FUN", `\fB/calc 10 \- 4\fR`}},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := docs.Write(&buf, ref, tt.format); err != nil {
+			t.Fatalf("%s: failed to write reference: %v", tt.format, err)
+		}
+
+		for _, want := range tt.expected {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%s: expected output to contain %q, got:\n%s", tt.format, want, buf.String())
+			}
+		}
+	}
+
+	if err := docs.Write(&bytes.Buffer{}, ref, "pdf"); err == nil {
+		t.Error("Expected error for unknown format, got nil")
+	}
+}
@@ -0,0 +1,218 @@
+package quote_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"command-bot/internal/bot/command/commands"
+	"command-bot/internal/bot/quote"
+	"command-bot/internal/logging"
+	"command-bot/internal/repl"
+	pkgcommand "command-bot/pkg/command"
+	"command-bot/pkg/transport"
+)
+
+func TestPickDailyIsDeterministic(t *testing.T) {
+	quotes := quote.NewMemoryStore(quote.DefaultQuotes())
+	list, _ := quotes.List("chat")
+
+	morning := time.Date(2025, 3, 14, 8, 0, 0, 0, time.UTC)
+	evening := time.Date(2025, 3, 14, 23, 30, 0, 0, time.UTC)
+
+	first, _ := quote.PickDaily(list, "chat", morning)
+	second, _ := quote.PickDaily(list, "chat", evening)
+	if first.ID != second.ID {
+		t.Errorf("Expected the same quote for the whole day, got #%d and #%d", first.ID, second.ID)
+	}
+}
+
+func TestPickDailyAvoidsRepeats(t *testing.T) {
+	quotes := quote.NewMemoryStore(quote.DefaultQuotes())
+	list, _ := quotes.List("chat")
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	n := len(list)
+
+	var ids []int
+	for day := 0; day < 3*n; day++ {
+		q, ok := quote.PickDaily(list, "chat", start.AddDate(0, 0, day))
+		if !ok {
+			t.Fatal("Expected a quote to be picked")
+		}
+
+		if day > 0 && q.ID == ids[day-1] {
+			t.Errorf("Quote #%d repeated on consecutive days (day %d)", q.ID, day)
+		}
+		ids = append(ids, q.ID)
+	}
+
+	// Где-то в этом интервале начинается полный цикл, в котором показаны все цитаты без повторов
+	distinct := func(window []int) bool {
+		seen := make(map[int]bool)
+		for _, id := range window {
+			if seen[id] {
+				return false
+			}
+			seen[id] = true
+		}
+		return true
+	}
+
+	for offset := 0; offset < n; offset++ {
+		if distinct(ids[offset:offset+n]) && distinct(ids[offset+n:offset+2*n]) {
+			return
+		}
+	}
+
+	t.Errorf("Expected full cycles without repeats, got %v", ids)
+}
+
+func TestPickDailyIgnoresQuotesAddedToday(t *testing.T) {
+	store := quote.NewMemoryStore(quote.DefaultQuotes())
+	now := time.Now()
+
+	before, _ := store.List("chat")
+	first, _ := quote.PickDaily(before, "chat", now)
+
+	if _, err := store.Add(quote.Quote{Text: "Fresh", Author: "Alice", ChatID: "chat", AddedAt: now}); err != nil {
+		t.Fatalf("Failed to add quote: %v", err)
+	}
+
+	after, _ := store.List("chat")
+	if second, _ := quote.PickDaily(after, "chat", now); second.ID != first.ID {
+		t.Errorf("Expected quote of the day to stay #%d after adding a quote, got #%d", first.ID, second.ID)
+	}
+}
+
+func TestPickDailyTwoQuotesAlternate(t *testing.T) {
+	quotes := []quote.Quote{{ID: 1, Text: "One"}, {ID: 2, Text: "Two"}}
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, chatID := range []string{"chat", "team", "ops", ""} {
+		prev, _ := quote.PickDaily(quotes, chatID, start)
+		for day := 1; day < 20; day++ {
+			q, _ := quote.PickDaily(quotes, chatID, start.AddDate(0, 0, day))
+			if q.ID == prev.ID {
+				t.Fatalf("Quote #%d repeated on day %d in chat %q", q.ID, day, chatID)
+			}
+			prev = q
+		}
+	}
+}
+
+func TestSchedulerNext(t *testing.T) {
+	store := quote.NewMemoryStore(nil)
+	scheduler, err := quote.NewScheduler(store, nil, nil, "09:30", time.UTC, nil)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+
+	before := time.Date(2025, 5, 1, 8, 0, 0, 0, time.UTC)
+	if next := scheduler.Next(before); !next.Equal(time.Date(2025, 5, 1, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected next run before posting time: %v", next)
+	}
+
+	after := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	if next := scheduler.Next(after); !next.Equal(time.Date(2025, 5, 2, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected next run after posting time: %v", next)
+	}
+
+	if _, err := quote.NewScheduler(store, nil, nil, "25:00", time.UTC, nil); err == nil {
+		t.Error("Expected error for invalid time of day, got nil")
+	}
+}
+
+func TestSchedulerPostsToSubscribers(t *testing.T) {
+	store := quote.NewMemoryStore(quote.DefaultQuotes())
+	cmd := commands.NewQuoteCommandWithStore(store)
+
+	_, err := cmd.Execute(context.Background(), pkgcommand.CommandContext{
+		ChatID:    "team",
+		Arguments: []string{"subscribe"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	posted := make(map[string]string)
+	scheduler, err := quote.NewScheduler(store, cmd.DailyMessage, func(ctx context.Context, chatID, text string) error {
+		posted[chatID] = text
+		return nil
+	}, "09:00", time.UTC, nil)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+
+	day := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+	scheduler.PostAll(context.Background(), day)
+
+	expected, _ := cmd.DailyMessage("team", day)
+	if len(posted) != 1 || posted["team"] != expected {
+		t.Errorf("Expected daily quote posted to team chat, got %v", posted)
+	}
+
+	if !strings.HasPrefix(expected, "Quote of the day (2025-05-01)") {
+		t.Errorf("Unexpected daily quote message: %q", expected)
+	}
+}
+
+func TestSchedulerLogsFailedPosts(t *testing.T) {
+	store := quote.NewMemoryStore(quote.DefaultQuotes())
+	cmd := commands.NewQuoteCommandWithStore(store)
+	if _, err := cmd.Execute(context.Background(), pkgcommand.CommandContext{ChatID: "team", Arguments: []string{"subscribe"}}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	scheduler, err := quote.NewScheduler(store, cmd.DailyMessage, quote.PostTo(failingSender{}), "09:00", time.UTC, logger)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	scheduler.PostAll(context.Background(), time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC))
+
+	var record map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON log record, got %q: %v", logs.String(), err)
+	}
+	if record[slog.LevelKey] != "ERROR" || record[logging.KeyChat] != "team" ||
+		!strings.Contains(fmt.Sprint(record[logging.KeyError]), "platform is down") {
+		t.Errorf("Expected structured error record for chat team, got %v", record)
+	}
+}
+
+// failingSender — транспорт, который не может отправить сообщение
+type failingSender struct{}
+
+func (failingSender) Send(ctx context.Context, chatID, text string) (string, error) {
+	return "", errors.New("platform is down")
+}
+
+func (failingSender) Edit(ctx context.Context, chatID, messageID, text string) error {
+	return transport.ErrUnsupported
+}
+
+func (failingSender) Delete(ctx context.Context, chatID, messageID string) error {
+	return transport.ErrUnsupported
+}
+
+func TestPostToSendsThroughEveryTransport(t *testing.T) {
+	var out bytes.Buffer
+	terminal := repl.New(strings.NewReader(""), &out, repl.Options{})
+
+	// Ошибка одного транспорта не мешает доставке через остальные
+	err := quote.PostTo(failingSender{}, terminal)(context.Background(), "team", "Quote of the day")
+	if err == nil || !strings.Contains(err.Error(), "platform is down") {
+		t.Errorf("Expected error of the failing transport, got %v", err)
+	}
+	if !strings.Contains(out.String(), "[team] Quote of the day") {
+		t.Errorf("Expected quote printed by the terminal, got %q", out.String())
+	}
+}
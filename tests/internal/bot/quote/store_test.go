@@ -0,0 +1,190 @@
+package quote_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"command-bot/internal/bot/command/commands"
+	"command-bot/internal/bot/quote"
+	pkgcommand "command-bot/pkg/command"
+)
+
+func TestMemoryStoreCollections(t *testing.T) {
+	store := quote.NewMemoryStore([]quote.Quote{
+		{Text: "Shared", Author: "Everyone"},
+	})
+
+	added, err := store.Add(quote.Quote{Text: "Team quote", Author: "Alice", ChatID: "team"})
+	if err != nil {
+		t.Fatalf("Failed to add quote: %v", err)
+	}
+
+	if added.ID != 2 {
+		t.Errorf("Expected new quote to get ID 2, got %d", added.ID)
+	}
+
+	// Цитата чата видна только в этом чате
+	teamQuotes, _ := store.List("team")
+	otherQuotes, _ := store.List("other")
+	if len(teamQuotes) != 2 || len(otherQuotes) != 1 {
+		t.Errorf("Expected 2 quotes in team chat and 1 elsewhere, got %d and %d", len(teamQuotes), len(otherQuotes))
+	}
+
+	if _, err := store.Get("other", added.ID); !errors.Is(err, quote.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another chat's quote, got %v", err)
+	}
+
+	// Общую цитату нельзя удалить из чата
+	if err := store.Remove("team", 1); !errors.Is(err, quote.ErrForbidden) {
+		t.Errorf("Expected ErrForbidden when removing shared quote, got %v", err)
+	}
+
+	if err := store.Remove("team", added.ID); err != nil {
+		t.Fatalf("Failed to remove quote: %v", err)
+	}
+}
+
+func TestFileStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.json")
+
+	store, err := quote.NewFileStore(path, quote.DefaultQuotes())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	added, err := store.Add(quote.Quote{Text: "Persist me", Author: "Bob", ChatID: "chat"})
+	if err != nil {
+		t.Fatalf("Failed to add quote: %v", err)
+	}
+
+	reopened, err := quote.NewFileStore(path, nil)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+
+	q, err := reopened.Get("chat", added.ID)
+	if err != nil {
+		t.Fatalf("Failed to get persisted quote: %v", err)
+	}
+
+	if q.Text != "Persist me" || q.Author != "Bob" {
+		t.Errorf("Unexpected persisted quote: %+v", q)
+	}
+
+	// Новые идентификаторы не должны повторять существующие
+	next, err := reopened.Add(quote.Quote{Text: "Another", ChatID: "chat"})
+	if err != nil {
+		t.Fatalf("Failed to add quote: %v", err)
+	}
+
+	if next.ID <= added.ID {
+		t.Errorf("Expected ID greater than %d, got %d", added.ID, next.ID)
+	}
+}
+
+func TestFileStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.json")
+
+	store, err := quote.NewFileStore(path, nil)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	// Второй экземпляр имитирует изменение файла в обход первого
+	other, err := quote.NewFileStore(path, nil)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	if _, err := other.Add(quote.Quote{Text: "Edited elsewhere"}); err != nil {
+		t.Fatalf("Failed to add quote: %v", err)
+	}
+	if err := other.Subscribe("chat1"); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := store.Reload(); err != nil {
+		t.Fatalf("Failed to reload store: %v", err)
+	}
+
+	if quotes, _ := store.List(""); len(quotes) != 1 || quotes[0].Text != "Edited elsewhere" {
+		t.Errorf("Expected reloaded quotes, got %+v", quotes)
+	}
+	if subs, _ := store.Subscribers(); len(subs) != 1 {
+		t.Errorf("Expected reloaded subscribers, got %v", subs)
+	}
+}
+
+func TestQuoteCommandSubcommands(t *testing.T) {
+	cmd := commands.NewQuoteCommandWithStore(quote.NewMemoryStore(nil))
+	ctx := context.Background()
+
+	run := func(args string) (string, error) {
+		return cmd.Execute(ctx, pkgcommand.CommandContext{
+			UserID:    "user",
+			ChatID:    "chat",
+			Arguments: strings.Fields(args),
+			RawInput:  "quote " + args,
+		})
+	}
+
+	response, err := run(`add "Stay hungry, stay foolish" -- Steve Jobs`)
+	if err != nil {
+		t.Fatalf("Failed to add quote: %v", err)
+	}
+
+	if !strings.Contains(response, "#1") {
+		t.Errorf("Expected response to mention quote #1, got %q", response)
+	}
+
+	response, err = run("1")
+	if err != nil {
+		t.Fatalf("Failed to get quote: %v", err)
+	}
+
+	if response != "\"Stay hungry, stay foolish\"\n— Steve Jobs" {
+		t.Errorf("Unexpected quote output: %q", response)
+	}
+
+	if response, _ = run("by jobs"); !strings.Contains(response, "Found 1 quote(s)") {
+		t.Errorf("Expected one quote by author, got %q", response)
+	}
+
+	if response, _ = run("search nothing-like-this"); !strings.HasPrefix(response, "No quotes found") {
+		t.Errorf("Expected no search results, got %q", response)
+	}
+
+	if _, err = run("remove abc"); !errors.Is(err, pkgcommand.ErrInvalidArguments) {
+		t.Errorf("Expected ErrInvalidArguments for bad id, got %v", err)
+	}
+
+	if _, err = run("remove 1"); err != nil {
+		t.Fatalf("Failed to remove quote: %v", err)
+	}
+
+	if _, err = run("1"); !errors.Is(err, quote.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after removal, got %v", err)
+	}
+}
+
+func TestQuoteCommandSharedCollectionIsReadOnly(t *testing.T) {
+	store := quote.NewMemoryStore([]quote.Quote{{Text: "Shared", Author: "Everyone"}})
+	cmd := commands.NewQuoteCommandWithStore(store)
+
+	for _, args := range []string{`add "New" -- Someone`, "remove 1"} {
+		_, err := cmd.Execute(context.Background(), pkgcommand.CommandContext{
+			UserID:    "user",
+			Arguments: strings.Fields(args),
+			RawInput:  "quote " + args,
+		})
+		if !errors.Is(err, pkgcommand.ErrPermissionDenied) {
+			t.Errorf("Expected ErrPermissionDenied for %q without a chat, got %v", args, err)
+		}
+	}
+
+	if quotes, _ := store.List(""); len(quotes) != 1 || quotes[0].Text != "Shared" {
+		t.Errorf("Expected shared collection to be unchanged, got %v", quotes)
+	}
+}
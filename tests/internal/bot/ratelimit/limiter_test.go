@@ -0,0 +1,48 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"command-bot/internal/bot/ratelimit"
+)
+
+func TestLimiterBurstAndRefill(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := ratelimit.NewLimiterWithClock(60, 2, func() time.Time { return now })
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("alice"); !ok {
+			t.Fatalf("Expected request %d to be allowed within burst", i+1)
+		}
+	}
+
+	ok, wait := limiter.Allow("alice")
+	if ok {
+		t.Fatal("Expected request over burst to be rejected")
+	}
+	if wait != time.Second {
+		t.Errorf("Expected to wait 1s for the next token, got %s", wait)
+	}
+
+	// Другие пользователи ограничиваются независимо
+	if ok, _ := limiter.Allow("bob"); !ok {
+		t.Error("Expected another user to be allowed")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := limiter.Allow("alice"); !ok {
+		t.Error("Expected request to be allowed after refill")
+	}
+}
+
+func TestNilLimiterAllowsEverything(t *testing.T) {
+	limiter := ratelimit.NewLimiter(0, 10)
+	if limiter != nil {
+		t.Fatal("Expected disabled limiter to be nil")
+	}
+
+	if ok, _ := limiter.Allow("alice"); !ok {
+		t.Error("Expected nil limiter to allow requests")
+	}
+}
@@ -0,0 +1,50 @@
+package registry_test
+
+import (
+	"strings"
+	"testing"
+
+	"command-bot/internal/bot/registry"
+)
+
+func TestBuildRegistersAllCommands(t *testing.T) {
+	bot, err := registry.Build(registry.Options{})
+	if err != nil {
+		t.Fatalf("Failed to build registry: %v", err)
+	}
+
+	if got := strings.Join(bot.Names, ","); got != strings.Join(registry.Names(), ",") {
+		t.Errorf("Expected all commands to be registered, got %s", got)
+	}
+
+	if len(bot.Handler.ListCommands()) != len(registry.Names()) {
+		t.Errorf("Expected %d commands in handler, got %d", len(registry.Names()), len(bot.Handler.ListCommands()))
+	}
+
+	if bot.Quote == nil {
+		t.Error("Expected quote command to be available")
+	}
+}
+
+func TestBuildDisablesCommands(t *testing.T) {
+	bot, err := registry.Build(registry.Options{Disabled: []string{"Weather", "quote"}})
+	if err != nil {
+		t.Fatalf("Failed to build registry: %v", err)
+	}
+
+	if _, err := bot.Handler.GetCommand("weather"); err == nil {
+		t.Error("Expected weather command to be disabled")
+	}
+
+	if _, err := bot.Handler.GetCommand("help"); err != nil {
+		t.Errorf("Expected help command to stay enabled, got %v", err)
+	}
+
+	if bot.Quote != nil {
+		t.Error("Expected no quote command when it is disabled")
+	}
+
+	if _, err := registry.Build(registry.Options{Disabled: []string{"wether"}}); err == nil {
+		t.Error("Expected error for unknown command name, got nil")
+	}
+}
@@ -0,0 +1,208 @@
+package certs_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"command-bot/internal/certs"
+)
+
+// issuer — сертификат с ключом, которым можно подписывать другие
+type issuer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newCert выпускает сертификат; при parent == nil — самоподписанный центр
+func newCert(t *testing.T, parent *issuer, name string, serial int64, usage x509.ExtKeyUsage) (issuer, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.ExtKeyUsage = nil
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+
+	return issuer{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})},
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set time of %s: %v", path, err)
+	}
+}
+
+// serve принимает TLS-соединения и завершает рукопожатие
+func serve(t *testing.T, config *tls.Config) string {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestManagerReloadsChangedCertificates(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	ca, _ := newCert(t, nil, "ca", 1, x509.ExtKeyUsageServerAuth)
+	first, firstKey := newCert(t, &ca, "bot.local", 10, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, first.pem, time.Now().Add(-time.Minute))
+	writeFile(t, keyFile, firstKey, time.Now().Add(-time.Minute))
+
+	manager, err := certs.NewManager(certs.Options{
+		CertFile:      certFile,
+		KeyFile:       keyFile,
+		MinVersion:    tls.VersionTLS12,
+		CheckInterval: time.Nanosecond,
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	addr := serve(t, manager.TLSConfig())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	serial := func() int64 {
+		t.Helper()
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, ServerName: "bot.local"})
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	if got := serial(); got != 10 {
+		t.Fatalf("Expected certificate 10, got %d", got)
+	}
+
+	second, secondKey := newCert(t, &ca, "bot.local", 20, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, second.pem, time.Now())
+	writeFile(t, keyFile, secondKey, time.Now())
+	if got := serial(); got != 20 {
+		t.Errorf("Expected renewed certificate 20, got %d", got)
+	}
+
+	// Поврежденный файл не заменяет действующий сертификат
+	writeFile(t, certFile, []byte("garbage"), time.Now().Add(time.Minute))
+	if got := serial(); got != 20 {
+		t.Errorf("Expected previous certificate to stay active, got %d", got)
+	}
+}
+
+func TestManagerRequiresClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+
+	ca, _ := newCert(t, nil, "ca", 1, x509.ExtKeyUsageServerAuth)
+	server, serverKey := newCert(t, &ca, "bot.local", 2, x509.ExtKeyUsageServerAuth)
+	client, clientKey := newCert(t, &ca, "deploy-bot", 3, x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, server.pem, time.Now())
+	writeFile(t, keyFile, serverKey, time.Now())
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	manager, err := certs.NewManager(certs.Options{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	addr := serve(t, manager.TLSConfig())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	handshake := func(certificates []tls.Certificate) error {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, ServerName: "bot.local", Certificates: certificates})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		// В TLS 1.3 сервер сообщает об отказе после рукопожатия клиента
+		_, err = conn.Read(make([]byte, 1))
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+
+	if err := handshake(nil); err == nil {
+		t.Error("Expected connection without a client certificate to be rejected")
+	}
+
+	pair, err := tls.X509KeyPair(client.pem, clientKey)
+	if err != nil {
+		t.Fatalf("Failed to load client certificate: %v", err)
+	}
+	if err := handshake([]tls.Certificate{pair}); err != nil {
+		t.Errorf("Expected client certificate to be accepted, got %v", err)
+	}
+}
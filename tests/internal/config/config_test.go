@@ -0,0 +1,231 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"command-bot/internal/config"
+)
+
+// env возвращает функцию поиска переменных окружения по карте
+func env(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, flags, err := config.Load("test", nil, env(nil))
+	if err != nil {
+		t.Fatalf("Failed to load defaults: %v", err)
+	}
+
+	if cfg.Server.Listen != ":8080" || cfg.Bot.Prefix != "/" {
+		t.Errorf("Unexpected defaults: %+v", cfg)
+	}
+	if flags.ConfigFile != "" || flags.PrintConfig {
+		t.Errorf("Unexpected flags: %+v", flags)
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	files := map[string]string{
+		"bot.yaml": "server:\n  listen: \":9090\"\ncommands:\n  ping:\n    timeout: 2s\n",
+		"bot.toml": "[server]\nlisten = \":9090\"\n[commands.ping]\ntimeout = \"2s\"\n",
+		"bot.json": `{"server": {"listen": ":9090"}, "commands": {"ping": {"timeout": "2s"}}}`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := config.LoadFile(writeFile(t, name, content))
+			if err != nil {
+				t.Fatalf("Failed to load %s: %v", name, err)
+			}
+
+			if cfg.Server.Listen != ":9090" {
+				t.Errorf("Expected listen :9090, got %s", cfg.Server.Listen)
+			}
+			if cfg.Commands.Ping.Timeout.Std() != 2*time.Second {
+				t.Errorf("Expected ping timeout 2s, got %s", cfg.Commands.Ping.Timeout)
+			}
+			// Ключи, отсутствующие в файле, сохраняют значения по умолчанию
+			if cfg.Server.DefaultUserID != "api-user" {
+				t.Errorf("Expected default user ID to be kept, got %s", cfg.Server.DefaultUserID)
+			}
+		})
+	}
+}
+
+func TestLoadFileRejectsUnknownKeys(t *testing.T) {
+	files := map[string]string{
+		"bot.yaml": "server:\n  lisen: \":9090\"\n",
+		"bot.toml": "[server]\nlisen = \":9090\"\n",
+		"bot.json": `{"server": {"lisen": ":9090"}}`,
+	}
+
+	for name, content := range files {
+		if _, err := config.LoadFile(writeFile(t, name, content)); err == nil || !strings.Contains(err.Error(), "lisen") {
+			t.Errorf("Expected unknown key error for %s, got %v", name, err)
+		}
+	}
+
+	if _, err := config.LoadFile(writeFile(t, "bot.ini", "")); err == nil {
+		t.Error("Expected error for unsupported extension, got nil")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "bot.yaml", "server:\n  listen: \":9090\"\n  default_user_id: file-user\nbot:\n  prefix: \"!\"\n")
+
+	cfg, flags, err := config.Load("test",
+		[]string{"-server.listen", ":7070", "-bot.disabled_commands", "weather, calc"},
+		env(map[string]string{
+			"COMMAND_BOT_CONFIG":                 path,
+			"COMMAND_BOT_SERVER_LISTEN":          ":6060",
+			"COMMAND_BOT_SERVER_DEFAULT_USER_ID": "env-user",
+			"COMMAND_BOT_COMMANDS_PING_TIMEOUT":  "1s",
+		}))
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if flags.ConfigFile != path {
+		t.Errorf("Expected config file from environment, got %q", flags.ConfigFile)
+	}
+	if cfg.Server.Listen != ":7070" {
+		t.Errorf("Expected flag to override environment, got %s", cfg.Server.Listen)
+	}
+	if cfg.Server.DefaultUserID != "env-user" {
+		t.Errorf("Expected environment to override file, got %s", cfg.Server.DefaultUserID)
+	}
+	if cfg.Bot.Prefix != "!" {
+		t.Errorf("Expected prefix from file, got %s", cfg.Bot.Prefix)
+	}
+	if got := strings.Join(cfg.Bot.DisabledCommands, ","); got != "weather,calc" {
+		t.Errorf("Expected disabled commands from flag, got %s", got)
+	}
+	if cfg.Commands.Ping.Timeout.Std() != time.Second {
+		t.Errorf("Expected ping timeout from environment, got %s", cfg.Commands.Ping.Timeout)
+	}
+}
+
+func TestLoadInvalidOverride(t *testing.T) {
+	_, _, err := config.Load("test", nil, env(map[string]string{"COMMAND_BOT_COMMANDS_PING_TIMEOUT": "soon"}))
+	if err == nil || !strings.Contains(err.Error(), "COMMAND_BOT_COMMANDS_PING_TIMEOUT") {
+		t.Errorf("Expected error naming the variable, got %v", err)
+	}
+}
+
+func TestValidateReportsAllFields(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Listen = "8080"
+	cfg.Bot.Prefix = ""
+	cfg.Commands.Weather.ForecastURL = "ftp://example.com"
+	cfg.Commands.Quote.DailyTime = "25:00"
+	cfg.Commands.Quote.Timezone = "Mars/Olympus"
+	cfg.Auth.APIKeys = map[string]config.APIKeyConfig{"ci": {SHA256: "plain-text-key"}}
+	cfg.Auth.JWT.Secret = "short"
+	cfg.Server.TLS.ClientAuth = "required"
+	cfg.Server.GRPC.Listen = "localhost"
+	cfg.Server.WebSocket.AllowedOrigins = []string{"[example.com"}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+
+	for _, path := range []string{
+		"server.listen", "bot.prefix", "commands.weather.forecast_url",
+		"commands.quote.daily_time", "commands.quote.timezone",
+		"auth.api_keys.ci.sha256", "auth.api_keys.ci.user_id", "auth.jwt.secret",
+		"server.tls.client_auth", "server.tls.client_ca_file", "server.grpc.listen",
+		"server.websocket.allowed_origins[0]",
+	} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("Expected error to mention %s, got %v", path, err)
+		}
+	}
+}
+
+func TestDumpRoundTrip(t *testing.T) {
+	cfg := config.Default()
+	cfg.Commands.Ping.Targets = []string{"example.com"}
+
+	var buf bytes.Buffer
+	if err := config.Dump(&buf, cfg); err != nil {
+		t.Fatalf("Failed to dump configuration: %v", err)
+	}
+
+	loaded, err := config.LoadFile(writeFile(t, "dump.yaml", buf.String()))
+	if err != nil {
+		t.Fatalf("Failed to load dumped configuration: %v", err)
+	}
+
+	if loaded.Commands.Ping.Targets[0] != "example.com" || loaded.Commands.Weather.Timeout != cfg.Commands.Weather.Timeout {
+		t.Errorf("Dumped configuration does not round-trip: %+v", loaded)
+	}
+}
+
+func TestDumpRedactsSecrets(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.AdminToken = "admin-token-value"
+	cfg.Auth.JWT.Secret = "jwt-secret-value-of-at-least-32-bytes"
+	cfg.Auth.APIKeys = map[string]config.APIKeyConfig{
+		"alice": {SHA256: "api-key-hash-value", UserID: "alice"},
+	}
+
+	var buf bytes.Buffer
+	if err := config.Dump(&buf, cfg); err != nil {
+		t.Fatalf("Failed to dump configuration: %v", err)
+	}
+
+	for _, secret := range []string{"admin-token-value", "jwt-secret-value", "api-key-hash-value"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("Expected %q to be redacted, got:\n%s", secret, buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), "admin_token: <redacted>") || !strings.Contains(buf.String(), "user_id: alice") {
+		t.Errorf("Expected only secret values to be redacted, got:\n%s", buf.String())
+	}
+	if cfg.Server.AdminToken != "admin-token-value" || cfg.Auth.APIKeys["alice"].SHA256 != "api-key-hash-value" {
+		t.Errorf("Expected Dump to leave the configuration unchanged, got %+v", cfg)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := config.Default()
+	updated := config.Default()
+	updated.Server.Listen = ":9090"
+	updated.Server.AdminToken = "secret"
+	updated.Permissions.Users = map[string][]string{"alice": {"admin"}}
+
+	changes := config.Diff(old, updated)
+	joined := strings.Join(changes, "\n")
+
+	if len(changes) != 3 {
+		t.Errorf("Expected 3 changes, got %v", changes)
+	}
+	if !strings.Contains(joined, `server.listen: ":8080" -> ":9090"`) {
+		t.Errorf("Expected listen change, got %v", changes)
+	}
+	if strings.Contains(joined, "secret") {
+		t.Errorf("Expected secret value to be hidden, got %v", changes)
+	}
+	if len(config.Diff(old, config.Default())) != 0 {
+		t.Error("Expected no changes for equal configurations")
+	}
+}
@@ -0,0 +1,85 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"command-bot/internal/health"
+)
+
+func TestRunStatus(t *testing.T) {
+	ok := func(ctx context.Context) health.Result { return health.Result{Critical: true} }
+	optionalFailure := func(ctx context.Context) health.Result { return health.Result{Err: errors.New("down")} }
+	criticalFailure := func(ctx context.Context) health.Result {
+		return health.Result{Err: errors.New("down"), Critical: true}
+	}
+
+	tests := []struct {
+		name   string
+		checks []health.NamedCheck
+		want   health.Status
+	}{
+		{"all ok", []health.NamedCheck{{Name: "a", Check: ok}}, health.StatusOK},
+		{"optional failure", []health.NamedCheck{{Name: "a", Check: ok}, {Name: "b", Check: optionalFailure}}, health.StatusDegraded},
+		{"critical failure", []health.NamedCheck{{Name: "b", Check: optionalFailure}, {Name: "c", Check: criticalFailure}}, health.StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := health.Run(context.Background(), time.Second, tt.checks)
+			if report.Status != tt.want {
+				t.Errorf("Expected status %s, got %s", tt.want, report.Status)
+			}
+			if len(report.Components) != len(tt.checks) {
+				t.Errorf("Expected %d components, got %+v", len(tt.checks), report.Components)
+			}
+		})
+	}
+}
+
+func TestRunTimesOutHangingCheck(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	hanging := func(ctx context.Context) health.Result {
+		<-release
+		return health.Result{}
+	}
+
+	report := health.Run(context.Background(), 10*time.Millisecond, []health.NamedCheck{{Name: "slow", Check: hanging}})
+	if report.Status != health.StatusFail || report.Components["slow"].Error == "" {
+		t.Errorf("Expected hanging check to fail, got %+v", report)
+	}
+}
+
+func TestFlagAndWriteReport(t *testing.T) {
+	flag := health.NewFlag(errors.New("not listening yet"), true)
+	checks := []health.NamedCheck{{Name: "transport", Check: flag.Check}}
+
+	rec := httptest.NewRecorder()
+	health.WriteReport(rec, health.Run(context.Background(), time.Second, checks))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 before the flag is set, got %d", rec.Code)
+	}
+
+	flag.Set(nil)
+
+	rec = httptest.NewRecorder()
+	health.WriteReport(rec, health.Run(context.Background(), time.Second, checks))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 after the flag is set, got %d", rec.Code)
+	}
+
+	var report health.Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if report.Components["transport"].Status != health.StatusOK {
+		t.Errorf("Expected transport to be ok, got %+v", report.Components)
+	}
+}
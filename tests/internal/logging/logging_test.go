@@ -0,0 +1,58 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"command-bot/internal/logging"
+)
+
+func TestNewRespectsFormatAndLevel(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+
+	logger, err := logging.New(&buf, logging.FormatJSON, level)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, `"msg":"shown"`) {
+		t.Errorf("Unexpected log output: %s", out)
+	}
+
+	if _, err := logging.New(&buf, "xml", level); err == nil {
+		t.Error("Expected error for unknown format, got nil")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if level, err := logging.ParseLevel("debug"); err != nil || level != slog.LevelDebug {
+		t.Errorf("Expected debug level, got %v, %v", level, err)
+	}
+	if _, err := logging.ParseLevel("verbose"); err == nil {
+		t.Error("Expected error for unknown level, got nil")
+	}
+}
+
+func TestFromContextAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	id := logging.NewRequestID()
+	if len(id) != 16 || id == logging.NewRequestID() {
+		t.Fatalf("Expected unique 16-character request IDs, got %q", id)
+	}
+
+	ctx := logging.WithRequestID(context.Background(), id)
+	logging.FromContext(ctx, logger).Info("handled")
+
+	if !strings.Contains(buf.String(), "request_id="+id) {
+		t.Errorf("Expected request ID in log line, got %s", buf.String())
+	}
+}
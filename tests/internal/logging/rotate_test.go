@@ -0,0 +1,227 @@
+package logging_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"command-bot/internal/logging"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestRotatingFileRotatesBySizeAndKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "bot.log")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	f, err := logging.OpenRotatingFileWithClock(path, logging.RotateOptions{MaxSize: 10, MaxBackups: 2}, nil,
+		func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+		now = now.Add(time.Second)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	if got := readFile(t, path); got != "fourth\n" {
+		t.Errorf("Expected current file to hold the last line, got %q", got)
+	}
+
+	backups, err := f.Backups()
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups to be kept, got %v", backups)
+	}
+	if got := readFile(t, backups[0]); got != "second\n" {
+		t.Errorf("Expected oldest kept backup to hold the second line, got %q", got)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat log file: %v", err)
+	}
+	if info.Mode().Perm() != logging.FileMode {
+		t.Errorf("Expected mode %o, got %o", logging.FileMode, info.Mode().Perm())
+	}
+}
+
+func TestRotatingFileRotatesByTimeAndCompresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.log")
+	now := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+
+	f, err := logging.OpenRotatingFileWithClock(path, logging.RotateOptions{Interval: 24 * time.Hour, Compress: true}, nil,
+		func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+
+	f.Write([]byte("day one\n"))
+	now = now.Add(2 * time.Minute)
+	f.Write([]byte("day two\n"))
+	f.Close()
+
+	backups, _ := f.Backups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], ".gz") {
+		t.Fatalf("Expected one compressed backup, got %v", backups)
+	}
+
+	gz, err := os.Open(backups[0])
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer gz.Close()
+	zr, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatalf("Failed to read gzip: %v", err)
+	}
+	data, _ := io.ReadAll(zr)
+	if string(data) != "day one\n" {
+		t.Errorf("Unexpected backup content %q", data)
+	}
+
+	if got := readFile(t, path); got != "day two\n" {
+		t.Errorf("Unexpected current content %q", got)
+	}
+}
+
+func TestRotatingFileFallsBackAndReopens(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	// Каталог журнала нельзя создать, потому что на его месте файл
+	path := filepath.Join(blocker, "bot.log")
+	var fallback bytes.Buffer
+	f, err := logging.OpenRotatingFile(path, logging.RotateOptions{}, &fallback)
+	if err == nil {
+		t.Fatal("Expected open error, got nil")
+	}
+
+	f.Write([]byte("to fallback\n"))
+	if !strings.Contains(fallback.String(), "to fallback") {
+		t.Errorf("Expected write to go to fallback, got %q", fallback.String())
+	}
+
+	// Причина устранена, Reopen возвращает запись в файл
+	os.Remove(blocker)
+	if err := f.Reopen(); err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	f.Write([]byte("to file\n"))
+	f.Close()
+
+	if got := readFile(t, path); got != "to file\n" {
+		t.Errorf("Expected write to go to file after reopen, got %q", got)
+	}
+}
+
+func TestRotatingFileReopenAfterExternalRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.log")
+	f, err := logging.OpenRotatingFile(path, logging.RotateOptions{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("old\n"))
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("Failed to rename: %v", err)
+	}
+	if err := f.Reopen(); err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	f.Write([]byte("new\n"))
+
+	if got := readFile(t, path); got != "new\n" {
+		t.Errorf("Expected new file after reopen, got %q", got)
+	}
+	if got := readFile(t, path+".1"); got != "old\n" {
+		t.Errorf("Expected renamed file to keep old lines, got %q", got)
+	}
+}
+
+func TestRotatingFileOrdersAndPrunesOnlyOwnBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bot.log")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	stamp := path + ".20240501-120000"
+
+	// Копии за одну секунду различаются номером, часть из них сжата
+	own := []string{path + ".20240430-235959.gz", stamp}
+	for i := 1; i <= 11; i++ {
+		name := fmt.Sprintf("%s.%d", stamp, i)
+		if i%2 == 1 {
+			name += ".gz"
+		}
+		own = append(own, name)
+	}
+	// Файлы внешнего logrotate и незавершенное сжатие копиями не считаются
+	foreign := []string{path + ".1", path + ".2.gz", path + ".old", stamp + ".gz.tmp", stamp + ".01"}
+	for _, name := range append(slices.Clone(own), foreign...) {
+		if err := os.WriteFile(name, []byte(filepath.Base(name)), 0o644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	f, err := logging.OpenRotatingFileWithClock(path, logging.RotateOptions{MaxSize: 10}, nil,
+		func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	backups, err := f.Backups()
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if !slices.Equal(backups, own) {
+		t.Errorf("Expected backups in rotation order\n%v\ngot\n%v", own, backups)
+	}
+
+	f.Close()
+	f, err = logging.OpenRotatingFileWithClock(path, logging.RotateOptions{MaxSize: 10, MaxBackups: 3}, nil,
+		func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	f.Write([]byte("first line\n"))
+	f.Write([]byte("second line\n"))
+	f.Close()
+
+	backups, _ = f.Backups()
+	want := []string{stamp + ".10", stamp + ".11.gz", stamp + ".12"}
+	if !slices.Equal(backups, want) {
+		t.Errorf("Expected the newest backups to be kept\n%v\ngot\n%v", want, backups)
+	}
+	if got := readFile(t, stamp+".12"); got != "first line\n" {
+		t.Errorf("Expected the new backup to hold the rotated line, got %q", got)
+	}
+	for _, name := range foreign {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("Expected %s to be left alone: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"command-bot/internal/bot/command"
+	"command-bot/internal/metrics"
+	pkgcommand "command-bot/pkg/command"
+)
+
+// stubCommand возвращает заданную ошибку
+type stubCommand struct {
+	name string
+	err  error
+}
+
+func (c *stubCommand) Name() string                  { return c.name }
+func (c *stubCommand) Aliases() []string             { return nil }
+func (c *stubCommand) Description() string           { return "stub" }
+func (c *stubCommand) Usage() string                 { return c.name }
+func (c *stubCommand) RequiredPermissions() []string { return nil }
+func (c *stubCommand) Execute(ctx context.Context, cmdCtx pkgcommand.CommandContext) (string, error) {
+	return "done", c.err
+}
+
+func TestRegistryTextFormat(t *testing.T) {
+	reg := metrics.NewRegistry()
+	counter := reg.NewCounter("requests_total", "Requests.", "path")
+	gauge := reg.NewGauge("temperature", "Current temperature.")
+	hist := reg.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1}, "op")
+
+	counter.Inc("/b")
+	counter.Inc("/a\"quoted\"")
+	counter.Inc("/b")
+	gauge.Set(21.5)
+	hist.Observe(0.05, "read")
+	hist.Observe(0.5, "read")
+	hist.Observe(3, "read")
+
+	var buf strings.Builder
+	if err := reg.WriteText(&buf); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+
+	want := `# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{path="/a\"quoted\""} 1
+requests_total{path="/b"} 2
+# HELP temperature Current temperature.
+# TYPE temperature gauge
+temperature 21.5
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="read",le="0.1"} 1
+latency_seconds_bucket{op="read",le="1"} 2
+latency_seconds_bucket{op="read",le="+Inf"} 3
+latency_seconds_sum{op="read"} 3.55
+latency_seconds_count{op="read"} 3
+`
+	if buf.String() != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	tests := map[error]string{
+		nil:                           metrics.OutcomeOK,
+		pkgcommand.ErrCommandNotFound: metrics.OutcomeNotFound,
+		fmt.Errorf("bad: %w", pkgcommand.ErrInvalidArguments):                metrics.OutcomeInvalidArgs,
+		pkgcommand.ErrPermissionDenied:                                       metrics.OutcomePermissionDenied,
+		fmt.Errorf("slow: %w", context.DeadlineExceeded):                     metrics.OutcomeTimeout,
+		fmt.Errorf("gone: %w", context.Canceled):                             metrics.OutcomeCanceled,
+		&pkgcommand.UnavailableError{Service: "api", Err: errors.New("502")}: metrics.OutcomeUnavailable,
+		errors.New("boom"): metrics.OutcomeError,
+	}
+
+	for err, want := range tests {
+		if got := metrics.Outcome(err); got != want {
+			t.Errorf("Outcome(%v) = %s, want %s", err, got, want)
+		}
+	}
+}
+
+func TestMiddlewareCountsExecutions(t *testing.T) {
+	m := metrics.New()
+	handler := command.NewHandler("/")
+	handler.Use(m.Middleware())
+	handler.RegisterCommand(&stubCommand{name: "ok"})
+	handler.RegisterCommand(&stubCommand{name: "bad", err: fmt.Errorf("%w: missing", pkgcommand.ErrInvalidArguments)})
+
+	for _, input := range []string{"/ok", "/ok", "/bad", "/nope"} {
+		cmdCtx, err := handler.ParseCommand(input, "user", "chat")
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", input, err)
+		}
+		handler.ExecuteCommand(context.Background(), cmdCtx)
+	}
+
+	checks := []struct {
+		command, outcome string
+		want             float64
+	}{
+		{"ok", metrics.OutcomeOK, 2},
+		{"bad", metrics.OutcomeInvalidArgs, 1},
+		{metrics.UnknownCommand, metrics.OutcomeNotFound, 1},
+	}
+	for _, c := range checks {
+		if got := m.Executions.Value(c.command, c.outcome); got != c.want {
+			t.Errorf("Expected %v executions of %s/%s, got %v", c.want, c.command, c.outcome, got)
+		}
+	}
+
+	if got := m.Duration.Count("ok"); got != 2 {
+		t.Errorf("Expected 2 latency observations, got %d", got)
+	}
+	if got := m.InFlight.Value("ok"); got != 0 {
+		t.Errorf("Expected no commands in flight, got %v", got)
+	}
+}
+
+func TestTransportRecordsExternalCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	m := metrics.New()
+	client := &http.Client{Transport: m.Transport("weather", nil), Timeout: time.Second}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := m.ExternalRequests.Value("weather", "418"); got != 1 {
+		t.Errorf("Expected one request with status 418, got %v", got)
+	}
+	if got := m.ExternalDuration.Count("weather"); got != 1 {
+		t.Errorf("Expected one latency observation, got %d", got)
+	}
+}
@@ -0,0 +1,67 @@
+package repl_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"command-bot/internal/repl"
+	"command-bot/pkg/command"
+	"command-bot/pkg/transport"
+)
+
+func TestReceive(t *testing.T) {
+	var out bytes.Buffer
+	session := repl.New(strings.NewReader("/progress\n/fail\nexit\n/never\n"), &out, repl.Options{UserID: "alice", ChatID: "team"})
+
+	var messages []transport.Message
+	handler := transport.HandlerFunc(func(ctx context.Context, msg transport.Message) (string, error) {
+		messages = append(messages, msg)
+		if msg.Text == "/fail" {
+			return "", errors.New("boom")
+		}
+
+		// Части ответа печатаются до итогового ответа
+		cmdCtx := command.CommandContext{Metadata: msg.Metadata}
+		cmdCtx.Emit("step 1\n")
+		return "done", nil
+	})
+
+	if err := session.Receive(context.Background(), handler); err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+
+	want := "> step 1\ndone\n> Error: boom\n> Goodbye!\n"
+	if out.String() != want {
+		t.Errorf("Expected output %q, got %q", want, out.String())
+	}
+	if len(messages) != 2 || messages[0].UserID != "alice" || messages[0].ChatID != "team" || messages[0].Time.IsZero() {
+		t.Errorf("Unexpected messages: %+v", messages)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var out bytes.Buffer
+	session := repl.New(strings.NewReader(""), &out, repl.Options{})
+	ctx := context.Background()
+
+	id, err := session.Send(ctx, "team", "daily quote")
+	if err != nil || id == "" {
+		t.Fatalf("Failed to send: %q %v", id, err)
+	}
+	if out.String() != "\n[team] daily quote\n> " {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+
+	if err := session.Edit(ctx, "team", id, "changed"); !errors.Is(err, transport.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported for edit, got %v", err)
+	}
+	if err := session.Delete(ctx, "team", id); !errors.Is(err, transport.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported for delete, got %v", err)
+	}
+	if caps := session.Capabilities(); caps.Edit || caps.Delete || !caps.Push {
+		t.Errorf("Unexpected capabilities: %+v", caps)
+	}
+}
@@ -0,0 +1,470 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"command-bot/internal/auth"
+	"command-bot/internal/certs"
+	"command-bot/internal/config"
+	"command-bot/internal/server"
+	commandbotv1 "command-bot/pkg/api/commandbot/v1"
+)
+
+// dialGRPC запускает gRPC-сервер srv на bufconn и возвращает подключение к нему
+func dialGRPC(t *testing.T, srv *server.Server) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	gs := srv.GRPCServer()
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial gRPC server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// errorInfo возвращает деталь ErrorInfo ошибки gRPC
+func errorInfo(t *testing.T, err error) *errdetails.ErrorInfo {
+	t.Helper()
+
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	t.Fatalf("Expected ErrorInfo in %v", err)
+	return nil
+}
+
+func TestGRPCExecute(t *testing.T) {
+	srv, _, _ := newServer(t)
+	client := commandbotv1.NewCommandServiceClient(dialGRPC(t, srv))
+	ctx := context.Background()
+
+	var header metadata.MD
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", "grpc-req-1")
+	resp, err := client.Execute(ctx, &commandbotv1.ExecuteRequest{Command: "/echo hi"}, grpc.Header(&header))
+	if err != nil || resp.GetResponse() != "hi" {
+		t.Fatalf("Expected echo response, got %v %v", resp, err)
+	}
+	if ids := header.Get("x-request-id"); !slices.Equal(ids, []string{"grpc-req-1"}) {
+		t.Errorf("Expected request ID in response header, got %v", ids)
+	}
+
+	_, err = client.Execute(ctx, &commandbotv1.ExecuteRequest{Command: "/ech hi"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+	info := errorInfo(t, err)
+	if info.GetReason() != server.CodeCommandNotFound || info.GetDomain() != server.GRPCErrorDomain ||
+		info.GetMetadata()["command"] != "ech" || info.GetMetadata()["suggestions"] != "echo" {
+		t.Errorf("Unexpected error info: %v", info)
+	}
+
+	_, err = client.Execute(ctx, &commandbotv1.ExecuteRequest{Command: "/calc 1 ^ 2"})
+	if status.Code(err) != codes.InvalidArgument || errorInfo(t, err).GetReason() != server.CodeInvalidArguments {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestGRPCExecuteStream(t *testing.T) {
+	weather := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/geo" {
+			w.Write([]byte(`{"results":[{"name":"Paris","country":"France","latitude":48.85,"longitude":2.35}]}`))
+			return
+		}
+		w.Write([]byte(`{"current_weather":{"temperature":18,"windspeed":2,"winddirection":90,"weathercode":0,"time":"2024-05-01T12:00"}}`))
+	}))
+	defer weather.Close()
+
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.Commands.Weather.GeocodingURL = weather.URL + "/geo"
+		cfg.Commands.Weather.ForecastURL = weather.URL + "/forecast"
+	})
+	client := commandbotv1.NewCommandServiceClient(dialGRPC(t, srv))
+
+	stream, err := client.ExecuteStream(context.Background(), &commandbotv1.ExecuteStreamRequest{Command: "/weather Paris"})
+	if err != nil {
+		t.Fatalf("Failed to start stream: %v", err)
+	}
+
+	var chunks []string
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Stream failed: %v", err)
+		}
+		chunks = append(chunks, msg.GetChunk())
+	}
+
+	if len(chunks) != 2 || chunks[0] != "Weather for Paris, France:\n" || !strings.Contains(chunks[1], "18.0°C") {
+		t.Errorf("Expected header and forecast chunks, got %q", chunks)
+	}
+
+	// Ошибка команды завершает поток статусом
+	stream, err = client.ExecuteStream(context.Background(), &commandbotv1.ExecuteStreamRequest{Command: "/weather"})
+	if err != nil {
+		t.Fatalf("Failed to start stream: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestGRPCCommands(t *testing.T) {
+	srv, _, _ := newServer(t)
+	client := commandbotv1.NewCommandServiceClient(dialGRPC(t, srv))
+	ctx := context.Background()
+
+	list, err := client.ListCommands(ctx, &commandbotv1.ListCommandsRequest{})
+	if err != nil {
+		t.Fatalf("Failed to list commands: %v", err)
+	}
+	names := make([]string, 0, len(list.GetCommands()))
+	for _, cmd := range list.GetCommands() {
+		names = append(names, cmd.GetName())
+	}
+	if list.GetPrefix() != "/" || !slices.IsSorted(names) || !slices.Contains(names, "weather") {
+		t.Errorf("Unexpected command list: %s %v", list.GetPrefix(), names)
+	}
+
+	resp, err := client.GetCommand(ctx, &commandbotv1.GetCommandRequest{Name: "forecast"})
+	if err != nil {
+		t.Fatalf("Failed to get command: %v", err)
+	}
+	if cmd := resp.GetCommand(); cmd.GetName() != "weather" || len(cmd.GetArguments()) == 0 || len(cmd.GetExamples()) == 0 {
+		t.Errorf("Expected weather with arguments and examples, got %v", cmd)
+	}
+
+	_, err = client.GetCommand(ctx, &commandbotv1.GetCommandRequest{Name: "wether"})
+	if status.Code(err) != codes.NotFound || errorInfo(t, err).GetMetadata()["suggestions"] != "weather" {
+		t.Errorf("Expected NotFound with suggestion, got %v", err)
+	}
+}
+
+func TestGRPCAuthentication(t *testing.T) {
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.Auth.APIKeys = map[string]config.APIKeyConfig{
+			"alice": {SHA256: auth.HashAPIKey("alice-key"), UserID: "alice", Chats: []string{"team"}},
+		}
+	})
+	client := commandbotv1.NewCommandServiceClient(dialGRPC(t, srv))
+	ctx := context.Background()
+
+	_, err := client.Execute(ctx, &commandbotv1.ExecuteRequest{Command: "/echo {user}"})
+	if status.Code(err) != codes.Unauthenticated || errorInfo(t, err).GetReason() != server.CodeUnauthenticated {
+		t.Errorf("Expected Unauthenticated without credentials, got %v", err)
+	}
+
+	authCtx := metadata.AppendToOutgoingContext(ctx, "x-api-key", "alice-key")
+	resp, err := client.Execute(authCtx, &commandbotv1.ExecuteRequest{Command: "/echo {user} {chat}"})
+	if err != nil || resp.GetResponse() != "alice team" {
+		t.Errorf("Expected identity from API key, got %v %v", resp, err)
+	}
+
+	_, err = client.Execute(authCtx, &commandbotv1.ExecuteRequest{Command: "/echo hi", ChatId: "ops"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for another chat, got %v", err)
+	}
+
+	// Описание команд требует учетных данных, как GET /commands
+	if _, err := client.ListCommands(ctx, &commandbotv1.ListCommandsRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated for command list without credentials, got %v", err)
+	}
+	if _, err := client.GetCommand(authCtx, &commandbotv1.GetCommandRequest{Name: "echo"}); err != nil {
+		t.Errorf("Expected command description with an API key, got %v", err)
+	}
+}
+
+func TestGRPCReflection(t *testing.T) {
+	srv, _, _ := newServer(t)
+	client := reflectionpb.NewServerReflectionClient(dialGRPC(t, srv))
+
+	stream, err := client.ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to start reflection stream: %v", err)
+	}
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		t.Fatalf("Failed to send reflection request: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive reflection response: %v", err)
+	}
+
+	var services []string
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	if !slices.Contains(services, "commandbot.v1.CommandService") {
+		t.Errorf("Expected CommandService in reflection, got %v", services)
+	}
+}
+
+// testCert — сертификат с ключом; центр сертификации подписывает им другие
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert выпускает сертификат для name; при parent == nil — самоподписанный центр
+func newTestCert(t *testing.T, parent *testCert, name string, serial int64, usage x509.ExtKeyUsage) testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{name},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.ExtKeyUsage = nil
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+
+	return testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// keyPair возвращает сертификат для tls.Config клиента
+func (c testCert) keyPair(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	pair, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		t.Fatalf("Failed to load key pair: %v", err)
+	}
+	return pair
+}
+
+// writeCert записывает сертификат сервера в certFile и keyFile. Время изменения
+// задается явно, чтобы Manager заметил замену файлов в пределах одной секунды.
+func writeCert(t *testing.T, cert testCert, certFile, keyFile string, modTime time.Time) {
+	t.Helper()
+
+	for path, data := range map[string][]byte{certFile: cert.certPEM, keyFile: cert.keyPEM} {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set time of %s: %v", path, err)
+		}
+	}
+}
+
+// serveGRPCTLS запускает gRPC-сервер srv с сертификатами manager и возвращает его адрес
+func serveGRPCTLS(t *testing.T, srv *server.Server, manager *certs.Manager) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	gs := srv.GRPCServer(grpc.Creds(credentials.NewTLS(manager.TLSConfig())))
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	return lis.Addr().String()
+}
+
+// executeTLS выполняет команду в новом соединении, чтобы каждый вызов проходил
+// рукопожатие TLS, и возвращает ответ и серийный номер сертификата сервера
+func executeTLS(t *testing.T, addr string, clientConfig *tls.Config, command string) (*commandbotv1.ExecuteResponse, int64, error) {
+	t.Helper()
+
+	conn, err := grpc.NewClient("passthrough:///"+addr, grpc.WithTransportCredentials(credentials.NewTLS(clientConfig)))
+	if err != nil {
+		t.Fatalf("Failed to dial gRPC server: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var p peer.Peer
+	resp, err := commandbotv1.NewCommandServiceClient(conn).Execute(ctx, &commandbotv1.ExecuteRequest{Command: command}, grpc.Peer(&p))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, _ := p.AuthInfo.(credentials.TLSInfo)
+	if len(info.State.PeerCertificates) == 0 {
+		t.Fatal("Expected the server to present a certificate")
+	}
+	return resp, info.State.PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestGRPCTLSReloadsCertificates(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	ca := newTestCert(t, nil, "ca", 1, x509.ExtKeyUsageServerAuth)
+	writeCert(t, newTestCert(t, &ca, "bot.local", 10, x509.ExtKeyUsageServerAuth), certFile, keyFile, time.Now().Add(-time.Minute))
+
+	manager, err := certs.NewManager(certs.Options{
+		CertFile:      certFile,
+		KeyFile:       keyFile,
+		MinVersion:    tls.VersionTLS12,
+		CheckInterval: time.Nanosecond,
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	srv, _, _ := newServer(t)
+	addr := serveGRPCTLS(t, srv, manager)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "bot.local"}
+
+	resp, serial, err := executeTLS(t, addr, clientConfig, "/echo hi")
+	if err != nil || resp.GetResponse() != "hi" || serial != 10 {
+		t.Fatalf("Expected echo over TLS with certificate 10, got %v %v %d", resp, err, serial)
+	}
+
+	// Продленный сертификат подхватывается без перезапуска gRPC-сервера
+	writeCert(t, newTestCert(t, &ca, "bot.local", 20, x509.ExtKeyUsageServerAuth), certFile, keyFile, time.Now())
+	if _, serial, err := executeTLS(t, addr, clientConfig, "/echo hi"); err != nil || serial != 20 {
+		t.Errorf("Expected renewed certificate 20, got %d %v", serial, err)
+	}
+}
+
+func TestGRPCMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+
+	ca := newTestCert(t, nil, "ca", 1, x509.ExtKeyUsageServerAuth)
+	writeCert(t, newTestCert(t, &ca, "bot.local", 10, x509.ExtKeyUsageServerAuth), certFile, keyFile, time.Now().Add(-time.Minute))
+	if err := os.WriteFile(caFile, ca.certPEM, 0o600); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+
+	manager, err := certs.NewManager(certs.Options{
+		CertFile:      certFile,
+		KeyFile:       keyFile,
+		ClientCAFile:  caFile,
+		ClientAuth:    tls.RequireAndVerifyClientCert,
+		MinVersion:    tls.VersionTLS13,
+		CheckInterval: time.Nanosecond,
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.Auth.ClientCerts = map[string]config.ClientCertConfig{
+			"deploy-bot": {UserID: "deploy", Chats: []string{"ops"}},
+		}
+	})
+	addr := serveGRPCTLS(t, srv, manager)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	deploy := newTestCert(t, &ca, "deploy-bot", 30, x509.ExtKeyUsageClientAuth)
+	stranger := newTestCert(t, &ca, "stranger", 31, x509.ExtKeyUsageClientAuth)
+	clientConfig := func(cert *testCert) *tls.Config {
+		config := &tls.Config{RootCAs: roots, ServerName: "bot.local"}
+		if cert != nil {
+			config.Certificates = []tls.Certificate{cert.keyPair(t)}
+		}
+		return config
+	}
+
+	// Пользователь определяется сертификатом клиента
+	resp, _, err := executeTLS(t, addr, clientConfig(&deploy), "/echo {user} {chat}")
+	if err != nil || resp.GetResponse() != "deploy ops" {
+		t.Fatalf("Expected identity from the client certificate, got %v %v", resp, err)
+	}
+
+	if _, _, err := executeTLS(t, addr, clientConfig(nil), "/echo hi"); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected a connection without a client certificate to fail, got %v", err)
+	}
+
+	// Сертификат, подписанный доверенным центром, но не сопоставленный пользователю, отклоняется
+	_, _, err = executeTLS(t, addr, clientConfig(&stranger), "/echo hi")
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated for an unmapped certificate, got %v", err)
+	}
+
+	// После продления сертификата сервера клиентские сертификаты проверяются по-прежнему
+	writeCert(t, newTestCert(t, &ca, "bot.local", 20, x509.ExtKeyUsageServerAuth), certFile, keyFile, time.Now())
+	resp, serial, err := executeTLS(t, addr, clientConfig(&deploy), "/echo {user}")
+	if err != nil || resp.GetResponse() != "deploy" || serial != 20 {
+		t.Errorf("Expected mTLS with renewed certificate 20, got %v %v %d", resp, err, serial)
+	}
+}
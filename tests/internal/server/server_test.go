@@ -0,0 +1,1044 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"command-bot/internal/auth"
+	"command-bot/internal/bot/quote"
+	"command-bot/internal/config"
+	"command-bot/internal/health"
+	"command-bot/internal/logging"
+	"command-bot/internal/metrics"
+	"command-bot/internal/server"
+	"command-bot/internal/tracing"
+)
+
+// newServer создает сервер, конфигурацию которого тест может менять между перезагрузками
+func newServer(t *testing.T) (*server.Server, *config.Config, *error) {
+	t.Helper()
+
+	return newServerWith(t, func(*config.Config) {})
+}
+
+// newServerWith создает сервер с конфигурацией, измененной функцией configure
+func newServerWith(t *testing.T, configure func(cfg *config.Config)) (*server.Server, *config.Config, *error) {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Log.File = ""
+	cfg.Server.AdminToken = "secret"
+	cfg.Commands.Quote.File = filepath.Join(t.TempDir(), "quotes.json")
+	configure(&cfg)
+
+	next := cfg
+	var loadErr error
+	srv, err := server.New(cfg, server.Options{
+		Load: func() (config.Config, error) {
+			return next, loadErr
+		},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	return srv, &next, &loadErr
+}
+
+func sendCommand(t *testing.T, handler http.Handler, body string) (int, server.CommandResponse) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/command", strings.NewReader(body)))
+
+	var resp server.CommandResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return rec.Code, resp
+}
+
+func TestCommandEndpoint(t *testing.T) {
+	srv, _, _ := newServer(t)
+
+	code, resp := sendCommand(t, srv.Handler(), `{"command": "/echo hi"}`)
+	if code != http.StatusOK || resp.Response != "hi" {
+		t.Errorf("Expected echo response, got %d %+v", code, resp)
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	weather := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer weather.Close()
+
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.RateLimit.Burst = 10
+		cfg.Commands.Weather.GeocodingURL = weather.URL
+		cfg.Commands.Weather.ForecastURL = weather.URL
+	})
+	handler := srv.Handler()
+
+	tests := []struct {
+		input   string
+		status  int
+		code    string
+		command string
+		detail  string
+	}{
+		{"/ech hi", http.StatusNotFound, server.CodeCommandNotFound, "ech", "suggestions"},
+		{"/calc 1 ^ 2", http.StatusBadRequest, server.CodeInvalidArguments, "calc", "usage"},
+		{"/weather", http.StatusBadRequest, server.CodeInvalidArguments, "weather", "usage"},
+		{"/weather Paris", http.StatusBadGateway, server.CodeUnavailable, "weather", ""},
+		{"hello", http.StatusBadRequest, server.CodeInvalidRequest, "", ""},
+	}
+	for _, tt := range tests {
+		code, resp := sendCommand(t, handler, fmt.Sprintf(`{"command": %q}`, tt.input))
+		if code != tt.status || resp.Error == nil {
+			t.Errorf("%s: expected status %d with error, got %d %+v", tt.input, tt.status, code, resp)
+			continue
+		}
+		if resp.Error.Code != tt.code || resp.Error.Command != tt.command || resp.Error.Message == "" {
+			t.Errorf("%s: expected code %s for command %q, got %+v", tt.input, tt.code, tt.command, resp.Error)
+		}
+		if _, ok := resp.Error.Details[tt.detail]; tt.detail != "" && !ok {
+			t.Errorf("%s: expected %s in details, got %+v", tt.input, tt.detail, resp.Error.Details)
+		}
+	}
+
+	_, resp := sendCommand(t, handler, `{"command": "/ech hi"}`)
+	if suggestions, _ := resp.Error.Details["suggestions"].([]any); len(suggestions) == 0 || suggestions[0] != "echo" {
+		t.Errorf("Expected echo to be suggested, got %+v", resp.Error.Details)
+	}
+}
+
+func TestReloadSwapsCommandsAndKeepsOldOnError(t *testing.T) {
+	srv, next, loadErr := newServer(t)
+	handler := srv.Handler()
+
+	next.Bot.DisabledCommands = []string{"echo"}
+	next.RateLimit.PerMinute = 60
+	changes, err := srv.Reload()
+	if err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+
+	joined := strings.Join(changes, "\n")
+	if !strings.Contains(joined, "bot.disabled_commands") || !strings.Contains(joined, "rate_limit.per_minute") {
+		t.Errorf("Expected changes to be reported, got %v", changes)
+	}
+
+	if code, _ := sendCommand(t, handler, `{"command": "/echo hi"}`); code == http.StatusOK {
+		t.Error("Expected echo to be disabled after reload")
+	}
+
+	// Неверная конфигурация отклоняется, прежняя остается в силе
+	*loadErr = errors.New("invalid configuration")
+	if _, err := srv.Reload(); err == nil {
+		t.Fatal("Expected reload error, got nil")
+	}
+	if srv.Runtime().Config.RateLimit.PerMinute != 60 {
+		t.Error("Expected previous configuration to stay active")
+	}
+
+	// Файл цитат перечитывается, только если остальная конфигурация собрана без ошибок
+	quotes := `{"next_id": 2, "quotes": [{"id": 1, "text": "Edited", "author": "Admin"}]}`
+	if err := os.WriteFile(next.Commands.Quote.File, []byte(quotes), 0o644); err != nil {
+		t.Fatalf("Failed to write quote file: %v", err)
+	}
+
+	*loadErr = nil
+	next.Bot.DisabledCommands = []string{"wether"}
+	if _, err := srv.Reload(); err == nil {
+		t.Error("Expected reload error for unknown command, got nil")
+	}
+	if _, err := srv.Runtime().Bot.Handler.GetCommand("echo"); err == nil {
+		t.Error("Expected previous command set to stay active")
+	}
+	if list, _ := srv.Runtime().Quotes.List(""); len(list) == 1 {
+		t.Error("Expected quote file not to be reloaded by a rejected configuration")
+	}
+}
+
+func TestReloadKeepsRecentQuotes(t *testing.T) {
+	srv, _, _ := newServer(t)
+	store := srv.Runtime().Quotes
+
+	// random избегает пяти последних показанных цитат, поэтому из шести
+	// подряд показываются разные, если перезагрузка не сбрасывает историю
+	const keep = 6
+	for _, q := range mustList(t, store) {
+		if q.ID > keep {
+			if err := store.Remove("", q.ID); err != nil {
+				t.Fatalf("Failed to remove quote #%d: %v", q.ID, err)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < keep; i++ {
+		if _, err := srv.Reload(); err != nil {
+			t.Fatalf("Failed to reload: %v", err)
+		}
+
+		_, resp := sendCommand(t, srv.Handler(), `{"command": "/quote"}`)
+		if seen[resp.Response] {
+			t.Fatalf("Quote repeated after reload: %q", resp.Response)
+		}
+		seen[resp.Response] = true
+	}
+}
+
+func TestReloadKeepsCommandHealth(t *testing.T) {
+	weather := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer weather.Close()
+
+	srv, next, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.Commands.Weather.GeocodingURL = weather.URL
+		cfg.Commands.Weather.ForecastURL = weather.URL
+	})
+
+	if code, _ := sendCommand(t, srv.Handler(), `{"command": "/weather Paris"}`); code != http.StatusBadGateway {
+		t.Fatalf("Expected weather request to fail, got %d", code)
+	}
+
+	next.RateLimit.PerMinute = 60
+	if _, err := srv.Reload(); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+
+	code, report := getReport(t, srv.Handler(), "/readyz")
+	component := report.Components["command.weather"]
+	if code != http.StatusOK || component.Status != health.StatusFail || component.Details["last_failure"] == nil {
+		t.Errorf("Expected weather failure to survive reload, got %d %+v", code, component)
+	}
+
+	// Другой API — другое состояние
+	next.Commands.Weather.ForecastURL = weather.URL + "/v1/forecast"
+	if _, err := srv.Reload(); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+	if _, report := getReport(t, srv.Handler(), "/readyz"); report.Components["command.weather"].Status != health.StatusOK {
+		t.Errorf("Expected weather state to reset for a new API, got %+v", report.Components["command.weather"])
+	}
+}
+
+// mustList возвращает цитаты общей коллекции
+func mustList(t *testing.T, store *quote.FileStore) []quote.Quote {
+	t.Helper()
+
+	list, err := store.List("")
+	if err != nil {
+		t.Fatalf("Failed to list quotes: %v", err)
+	}
+	return list
+}
+
+func TestRateLimit(t *testing.T) {
+	srv, next, _ := newServer(t)
+	next.RateLimit = config.RateLimitConfig{PerMinute: 1, Burst: 1}
+	if _, err := srv.Reload(); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+
+	handler := srv.Handler()
+	if code, _ := sendCommand(t, handler, `{"command": "/ping"}`); code != http.StatusOK {
+		t.Fatalf("Expected first command to pass, got %d", code)
+	}
+
+	code, resp := sendCommand(t, handler, `{"command": "/ping"}`)
+	if code != http.StatusTooManyRequests || resp.Error == nil || resp.Error.Code != server.CodeRateLimited {
+		t.Errorf("Expected rate limit error, got %d %+v", code, resp)
+	}
+
+	// Ограничение действует на каждого пользователя отдельно
+	if code, _ := sendCommand(t, handler, `{"command": "/ping", "user_id": "other"}`); code != http.StatusOK {
+		t.Errorf("Expected other user to pass, got %d", code)
+	}
+}
+
+func TestAdminReloadEndpoint(t *testing.T) {
+	srv, next, _ := newServer(t)
+	handler := srv.Handler()
+
+	next.Server.DefaultUserID = "reloaded-user"
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/reload", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var resp server.ReloadResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if rec.Code != http.StatusOK || len(resp.Changes) != 1 || !strings.HasPrefix(resp.Changes[0], "server.default_user_id") {
+		t.Errorf("Unexpected reload response: %d %+v", rec.Code, resp)
+	}
+	if srv.Runtime().Config.Server.DefaultUserID != "reloaded-user" {
+		t.Error("Expected configuration to be reloaded")
+	}
+}
+
+func TestCommandLogsRequestID(t *testing.T) {
+	cfg := config.Default()
+	cfg.Commands.Quote.File = filepath.Join(t.TempDir(), "quotes.json")
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatJSON, new(slog.LevelVar))
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	srv, err := server.New(cfg, server.Options{Logger: logger})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	handler := srv.Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/command", strings.NewReader(`{"command": "/echo {user}", "user_id": "alice"}`))
+	req.Header.Set(server.RequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(server.RequestIDHeader); got != "req-42" {
+		t.Errorf("Expected request ID to be echoed, got %q", got)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected one JSON log entry, got %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		logging.KeyRequestID: "req-42",
+		logging.KeyUser:      "alice",
+		logging.KeyChat:      "api-chat",
+		logging.KeyCommand:   "echo",
+		logging.KeyOutcome:   "ok",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("Expected %s=%v in log entry, got %v", key, value, entry[key])
+		}
+	}
+	if _, ok := entry[logging.KeyDuration]; !ok {
+		t.Error("Expected duration in log entry")
+	}
+
+	// Недопустимый идентификатор клиента заменяется сгенерированным
+	req = httptest.NewRequest(http.MethodPost, "/command", strings.NewReader(`{"command": "/ping"}`))
+	req.Header.Set(server.RequestIDHeader, "bad id\n")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(server.RequestIDHeader); got == "" || got == "bad id\n" {
+		t.Errorf("Expected generated request ID, got %q", got)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	cfg := config.Default()
+	cfg.Commands.Quote.File = filepath.Join(t.TempDir(), "quotes.json")
+	cfg.RateLimit = config.RateLimitConfig{PerMinute: 1, Burst: 1}
+
+	m := metrics.New()
+	srv, err := server.New(cfg, server.Options{
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Metrics: m,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	handler := srv.Handler()
+
+	sendCommand(t, handler, `{"command": "/echo hi"}`)
+	sendCommand(t, handler, `{"command": "/echo again"}`)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`command_bot_command_executions_total{command="echo",outcome="ok"} 1`,
+		`command_bot_rate_limited_total{transport="http"} 1`,
+		`command_bot_command_duration_seconds_count{command="echo"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in metrics, got:\n%s", want, body)
+		}
+	}
+	if got := rec.Header().Get("Content-Type"); got != metrics.ContentType {
+		t.Errorf("Unexpected content type %q", got)
+	}
+}
+
+func TestCommandTracing(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/geo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results":[{"name":"Oslo","country":"Norway","latitude":59.91,"longitude":10.75}]}`))
+	})
+	mux.HandleFunc("/forecast", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"current_weather":{"temperature":5,"windspeed":1,"winddirection":90,"weathercode":3,"time":"2024-05-01T12:00"}}`))
+	})
+	api := httptest.NewServer(mux)
+	defer api.Close()
+
+	cfg := config.Default()
+	cfg.Commands.Quote.File = filepath.Join(t.TempDir(), "quotes.json")
+	cfg.Commands.Weather.GeocodingURL = api.URL + "/geo"
+	cfg.Commands.Weather.ForecastURL = api.URL + "/forecast"
+
+	exporter := &tracing.RecordingExporter{}
+	srv, err := server.New(cfg, server.Options{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Tracer: tracing.NewTracer(exporter),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/command", strings.NewReader(`{"command": "/weather Oslo"}`))
+	req.Header.Set(tracing.TraceparentHeader, "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	byName := make(map[string]tracing.SpanData)
+	for _, span := range exporter.Spans() {
+		if span.TraceID.String() != traceID {
+			t.Errorf("Expected span %s to continue the incoming trace, got %s", span.Name, span.TraceID)
+		}
+		byName[span.Name] = span
+	}
+
+	root := byName["POST /command"]
+	execute := byName["command.execute"]
+	host := strings.TrimPrefix(api.URL, "http://")
+	checks := map[string]tracing.SpanID{
+		"POST /command":                  mustParseSpanID(t, "00f067aa0ba902b7"),
+		"command.parse":                  root.SpanID,
+		"command.execute":                root.SpanID,
+		"command.authorize":              execute.SpanID,
+		"HTTP GET " + host + "/geo":      execute.SpanID,
+		"HTTP GET " + host + "/forecast": execute.SpanID,
+	}
+	for name, parent := range checks {
+		span, ok := byName[name]
+		if !ok {
+			t.Errorf("Expected span %q, got %v", name, exporter.Spans())
+			continue
+		}
+		if span.ParentSpanID != parent {
+			t.Errorf("Expected %q to have parent %s, got %s", name, parent, span.ParentSpanID)
+		}
+	}
+}
+
+func mustParseSpanID(t *testing.T, id string) tracing.SpanID {
+	t.Helper()
+	sc, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-" + id + "-01")
+	if err != nil {
+		t.Fatalf("Failed to parse span ID: %v", err)
+	}
+	return sc.SpanID
+}
+
+func getReport(t *testing.T, handler http.Handler, path string) (int, health.Report) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var report health.Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode %s report: %v", path, err)
+	}
+	return rec.Code, report
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	srv, _, _ := newServer(t)
+	handler := srv.Handler()
+
+	if code, report := getReport(t, handler, "/healthz"); code != http.StatusOK || report.Status != health.StatusOK {
+		t.Errorf("Expected live service, got %d %+v", code, report)
+	}
+
+	transport := health.NewFlag(nil, true)
+	srv.RegisterHealthCheck("transport.http", transport.Check)
+
+	code, report := getReport(t, handler, "/readyz")
+	if code != http.StatusOK || report.Status != health.StatusOK {
+		t.Errorf("Expected ready service, got %d %+v", code, report)
+	}
+	for _, name := range []string{"registry", "storage.quotes", "command.weather", "transport.http"} {
+		if _, ok := report.Components[name]; !ok {
+			t.Errorf("Expected component %s in report, got %+v", name, report.Components)
+		}
+	}
+
+	transport.Set(errors.New("connection lost"))
+	if code, report := getReport(t, handler, "/readyz"); code != http.StatusServiceUnavailable ||
+		report.Components["transport.http"].Error != "connection lost" {
+		t.Errorf("Expected failed transport to make service unready, got %d %+v", code, report)
+	}
+	transport.Set(nil)
+
+	// Во время остановки сервис не готов, но жив
+	srv.SetDraining(true)
+	if code, report := getReport(t, handler, "/readyz"); code != http.StatusServiceUnavailable || report.Reason != "draining" {
+		t.Errorf("Expected draining service to be unready, got %d %+v", code, report)
+	}
+	if code, _ := getReport(t, handler, "/healthz"); code != http.StatusOK {
+		t.Errorf("Expected draining service to stay live, got %d", code)
+	}
+}
+
+func TestShutdownDrainsAndAbortsCommands(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		wantDrained int
+		wantAborted int
+		wantStatus  int
+	}{
+		{"command finishes within timeout", 5 * time.Second, 1, 0, http.StatusOK},
+		{"command outlives timeout", 50 * time.Millisecond, 0, 1, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan struct{})
+			release := make(chan struct{})
+			weather := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/forecast" {
+					w.Write([]byte(`{"current_weather":{"temperature":1}}`))
+					return
+				}
+
+				close(received)
+				select {
+				case <-release:
+					w.Write([]byte(`{"results":[{"name":"Oslo","country":"Norway","latitude":59.91,"longitude":10.75}]}`))
+				case <-r.Context().Done():
+				}
+			}))
+			defer weather.Close()
+
+			srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+				cfg.Commands.Weather.GeocodingURL = weather.URL + "/geo"
+				cfg.Commands.Weather.ForecastURL = weather.URL + "/forecast"
+			})
+			handler := srv.Handler()
+
+			status := make(chan int, 1)
+			go func() {
+				code, _ := sendCommand(t, handler, `{"command": "/weather Oslo"}`)
+				status <- code
+			}()
+			<-received
+
+			if tt.wantAborted == 0 {
+				// Команда завершается, пока Shutdown ее ждет
+				time.AfterFunc(20*time.Millisecond, func() { close(release) })
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			report := srv.Shutdown(ctx)
+
+			if report.Drained != tt.wantDrained || len(report.Aborted) != tt.wantAborted {
+				t.Errorf("Expected %d drained and %d aborted, got %+v", tt.wantDrained, tt.wantAborted, report)
+			}
+			if tt.wantAborted > 0 && report.Aborted[0].Command != "weather" {
+				t.Errorf("Expected aborted weather command, got %+v", report.Aborted)
+			}
+			if code := <-status; code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, code)
+			}
+
+			// После остановки новые команды отклоняются
+			if code, _ := sendCommand(t, handler, `{"command": "/echo hi"}`); code != http.StatusServiceUnavailable {
+				t.Errorf("Expected new command to be rejected, got %d", code)
+			}
+		})
+	}
+}
+
+func TestCommandCanceledByClient(t *testing.T) {
+	received := make(chan struct{})
+	weather := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-r.Context().Done()
+	}))
+	defer weather.Close()
+
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.Commands.Weather.GeocodingURL = weather.URL
+		cfg.Commands.Weather.ForecastURL = weather.URL
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-received
+		cancel()
+	}()
+
+	req := httptest.NewRequest(http.MethodPost, "/command", strings.NewReader(`{"command": "/weather Oslo"}`)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	var resp server.CommandResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if rec.Code != server.StatusClientClosedRequest || resp.Error == nil || resp.Error.Code != server.CodeCanceled {
+		t.Errorf("Expected canceled command, got %d %+v", rec.Code, resp)
+	}
+}
+
+func TestCommandAuthentication(t *testing.T) {
+	jwtSecret := "0123456789abcdef0123456789abcdef"
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.Auth.APIKeys = map[string]config.APIKeyConfig{
+			"alice": {SHA256: auth.HashAPIKey("alice-key"), UserID: "alice", Chats: []string{"team"}},
+		}
+		cfg.Auth.JWT.Secret = jwtSecret
+	})
+	handler := srv.Handler()
+
+	send := func(body string, header ...string) (int, server.CommandResponse) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/command", strings.NewReader(body))
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var resp server.CommandResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp
+	}
+
+	if code, _ := send(`{"command": "/echo {user}"}`); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", code)
+	}
+	if code, _ := send(`{"command": "/echo {user}"}`, auth.APIKeyHeader, "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for unknown key, got %d", code)
+	}
+
+	code, resp := send(`{"command": "/echo {user} {chat}"}`, auth.APIKeyHeader, "alice-key")
+	if code != http.StatusOK || resp.Response != "alice team" {
+		t.Errorf("Expected identity from API key, got %d %+v", code, resp)
+	}
+
+	// Пользователь и чат не подменяются через тело запроса
+	if code, _ := send(`{"command": "/echo hi", "user_id": "admin"}`, auth.APIKeyHeader, "alice-key"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for spoofed user_id, got %d", code)
+	}
+	if code, _ := send(`{"command": "/echo hi", "chat_id": "other"}`, auth.APIKeyHeader, "alice-key"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for disallowed chat, got %d", code)
+	}
+
+	token, err := auth.SignJWT(auth.Claims{Subject: "bob", ExpiresAt: time.Now().Add(time.Hour).Unix()}, []byte(jwtSecret))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	code, resp = send(`{"command": "/echo {user}", "user_id": "bob", "chat_id": "any"}`, "Authorization", "Bearer "+token)
+	if code != http.StatusOK || resp.Response != "bob" {
+		t.Errorf("Expected identity from token, got %d %+v", code, resp)
+	}
+}
+
+func TestBatchEndpoint(t *testing.T) {
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.RateLimit.PerMinute = 1
+		cfg.RateLimit.Burst = 2
+		cfg.Server.Batch.MaxCommands = 4
+		cfg.Server.Batch.MaxCommandBytes = 128
+	})
+	handler := srv.Handler()
+
+	send := func(body string) (int, server.BatchResponse) {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/commands/batch", strings.NewReader(body)))
+
+		var resp server.BatchResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return rec.Code, resp
+	}
+
+	code, resp := send(`{"commands": [
+		{"command": "/echo first"},
+		{"command": "/nosuchcommand"},
+		{"command": "/echo {user}", "user_id": "bob"},
+		{"command": "/echo fourth"}
+	], "sequential": true}`)
+	if code != http.StatusOK || len(resp.Results) != 4 {
+		t.Fatalf("Expected 4 results, got %d %+v", code, resp)
+	}
+
+	want := []struct {
+		status   int
+		response string
+	}{
+		{http.StatusOK, "first"},
+		{http.StatusNotFound, ""},
+		{http.StatusOK, "bob"},
+		// Лимит api-user исчерпан первыми двумя командами; у bob он свой
+		{http.StatusTooManyRequests, ""},
+	}
+	for i, w := range want {
+		got := resp.Results[i]
+		if got.Index != i || got.Status != w.status || got.Response != w.response {
+			t.Errorf("Result %d: expected status %d and response %q, got %+v", i, w.status, w.response, got)
+		}
+	}
+	if resp.Results[1].Error == nil || resp.Results[3].RetryAfter < 1 {
+		t.Errorf("Expected error and retry_after in failed results, got %+v", resp.Results)
+	}
+
+	if code, _ := send(`{"commands": []}`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for empty batch, got %d", code)
+	}
+	if code, _ := send(`{"commands": [{}, {}, {}, {}, {}]}`); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for oversized batch, got %d", code)
+	}
+
+	// Тело ограничено max_commands * max_command_bytes, и /command — max_command_bytes
+	long := strings.Repeat("a", 4*128)
+	code, resp = send(`{"commands": [{"command": "/echo ` + long + `"}]}`)
+	if code != http.StatusRequestEntityTooLarge || resp.Error == nil || resp.Error.Code != server.CodeRequestTooLarge {
+		t.Errorf("Expected request_too_large for oversized batch body, got %d %+v", code, resp.Error)
+	}
+	code, single := sendCommand(t, handler, `{"command": "/echo `+long[:128]+`"}`)
+	if code != http.StatusRequestEntityTooLarge || single.Error == nil || single.Error.Code != server.CodeRequestTooLarge {
+		t.Errorf("Expected request_too_large for oversized command body, got %d %+v", code, single.Error)
+	}
+}
+
+func TestBatchRunsCommandsConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	weather := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.Write([]byte(`{"results":[]}`))
+	}))
+	defer weather.Close()
+
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.Commands.Weather.GeocodingURL = weather.URL
+		cfg.Server.Batch.Parallelism = 2
+	})
+
+	body := `{"commands": [{"command": "/weather a"}, {"command": "/weather b"}, {"command": "/weather c"}, {"command": "/weather d"}]}`
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/commands/batch", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	if peak != 2 {
+		t.Errorf("Expected 2 commands to run at once, got %d", peak)
+	}
+}
+
+// readEvent читает следующее событие Server-Sent Events
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStopWithoutGRPC(t *testing.T) {
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.Server.ShutdownTimeout = config.Duration(10 * time.Millisecond)
+	})
+
+	active := make(chan struct{}, 1)
+	httpServer := &http.Server{
+		Handler: srv.Handler(),
+		ConnState: func(conn net.Conn, state http.ConnState) {
+			if state == http.StateActive {
+				active <- struct{}{}
+			}
+		},
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go httpServer.Serve(ln)
+
+	// Клиент не досылает тело запроса, поэтому HTTP-сервер не успевает закрыть соединение
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "POST /command HTTP/1.1\r\nHost: test\r\nContent-Length: 100\r\n\r\n{")
+	<-active
+
+	done := make(chan server.ShutdownReport, 1)
+	go func() { done <- srv.Stop(httpServer, nil) }()
+
+	select {
+	case report := <-done:
+		if report.Drained != 0 || len(report.Aborted) != 0 {
+			t.Errorf("Expected no commands in the report, got %+v", report)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected Stop to return after the HTTP drain timed out")
+	}
+}
+
+func TestCommandStream(t *testing.T) {
+	release := make(chan struct{})
+	weather := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/geo" {
+			w.Write([]byte(`{"results":[{"name":"Paris","country":"France","latitude":48.85,"longitude":2.35}]}`))
+			return
+		}
+		// Прогноз отвечает только после того, как клиент получил первую часть
+		<-release
+		w.Write([]byte(`{"current_weather":{"temperature":18,"windspeed":2,"winddirection":90,"weathercode":0,"time":"2024-05-01T12:00"}}`))
+	}))
+	defer weather.Close()
+
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.Commands.Weather.GeocodingURL = weather.URL + "/geo"
+		cfg.Commands.Weather.ForecastURL = weather.URL + "/forecast"
+	})
+	api := httptest.NewServer(srv.Handler())
+	defer api.Close()
+
+	resp, err := http.Post(api.URL+"/command?stream=1", "application/json", strings.NewReader(`{"command": "/weather Paris"}`))
+	if err != nil {
+		t.Fatalf("Failed to send command: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	body := bufio.NewReader(resp.Body)
+
+	var chunk server.StreamChunk
+	event, data := readEvent(t, body)
+	if err := json.Unmarshal([]byte(data), &chunk); event != server.EventChunk || err != nil || !strings.Contains(chunk.Text, "Paris, France") {
+		t.Fatalf("Expected header chunk before the forecast, got %s %s", event, data)
+	}
+	close(release)
+
+	event, data = readEvent(t, body)
+	if err := json.Unmarshal([]byte(data), &chunk); event != server.EventChunk || err != nil || !strings.Contains(chunk.Text, "18") {
+		t.Fatalf("Expected forecast chunk, got %s %s", event, data)
+	}
+
+	var done server.StreamDone
+	event, data = readEvent(t, body)
+	if err := json.Unmarshal([]byte(data), &done); event != server.EventDone || err != nil || done.Status != http.StatusOK || done.Error != nil {
+		t.Fatalf("Expected successful done event, got %s %s", event, data)
+	}
+
+	// Ошибка команды приходит в событии done с кодом, который вернул бы /command
+	resp, err = http.Post(api.URL+"/command?stream=1", "application/json", strings.NewReader(`{"command": "/weather"}`))
+	if err != nil {
+		t.Fatalf("Failed to send command: %v", err)
+	}
+	defer resp.Body.Close()
+
+	event, data = readEvent(t, bufio.NewReader(resp.Body))
+	if err := json.Unmarshal([]byte(data), &done); event != server.EventDone || err != nil ||
+		done.Status != http.StatusBadRequest || done.Error == nil || done.Error.Code != server.CodeInvalidArguments {
+		t.Errorf("Expected invalid_arguments in done event, got %s %s", event, data)
+	}
+
+	// Без stream ответ собирается целиком
+	code, plain := sendCommand(t, srv.Handler(), `{"command": "/weather Paris"}`)
+	if code != http.StatusOK || !strings.HasPrefix(plain.Response, "Weather for Paris, France") {
+		t.Errorf("Expected concatenated response, got %d %+v", code, plain)
+	}
+}
+
+func TestCommandStreamRejectedBeforeStart(t *testing.T) {
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		// Неизвестная команда тоже расходует лимит
+		cfg.RateLimit = config.RateLimitConfig{PerMinute: 1, Burst: 2}
+	})
+
+	stream := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/command?stream=1", strings.NewReader(body)))
+		return rec
+	}
+
+	// Ошибки до начала выполнения команды возвращаются обычным ответом /command
+	rec := stream(`{"command": "/ech hi"}`)
+	var resp server.CommandResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); rec.Code != http.StatusNotFound || err != nil ||
+		resp.Error == nil || resp.Error.Code != server.CodeCommandNotFound {
+		t.Errorf("Expected 404 command_not_found before the stream, got %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := stream(`{"command": "/echo hi"}`); rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected event stream, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	rec = stream(`{"command": "/echo hi"}`)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After before the stream, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestCommandsEndpoint(t *testing.T) {
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.Bot.DisabledCommands = []string{"quote"}
+	})
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/commands", nil))
+
+	var resp server.CommandsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if rec.Code != http.StatusOK || resp.Prefix != "/" {
+		t.Fatalf("Unexpected response: %d %+v", rec.Code, resp)
+	}
+
+	names := make([]string, 0, len(resp.Commands))
+	for _, info := range resp.Commands {
+		names = append(names, info.Name)
+		if info.Name == "weather" && (len(info.Arguments) != 1 || !info.Arguments[0].Required) {
+			t.Errorf("Expected weather to require a location, got %+v", info.Arguments)
+		}
+	}
+	if !slices.IsSorted(names) || !slices.Contains(names, "weather") || slices.Contains(names, "quote") {
+		t.Errorf("Expected sorted enabled commands, got %v", names)
+	}
+}
+
+func TestCommandsEndpointRequiresAuthentication(t *testing.T) {
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.Auth.APIKeys = map[string]config.APIKeyConfig{
+			"alice": {SHA256: auth.HashAPIKey("alice-key"), UserID: "alice"},
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/commands", nil))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("Expected 401 without credentials, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/commands", nil)
+	req.Header.Set(auth.APIKeyHeader, "alice-key")
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	var resp server.CommandsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK || len(resp.Commands) == 0 {
+		t.Errorf("Expected command list with an API key, got %d %+v %v", rec.Code, resp, err)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	srv, _, _ := newServer(t)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var doc struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	body := rec.Body.String()
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("Expected OpenAPI 3 document, got %q", doc.OpenAPI)
+	}
+
+	for path, method := range map[string]string{
+		"/command": "post", "/commands/batch": "post", "/commands": "get",
+		"/healthz": "get", "/readyz": "get", "/admin/reload": "post",
+	} {
+		if _, ok := doc.Paths[path][method]; !ok {
+			t.Errorf("Expected %s %s to be described", method, path)
+		}
+	}
+
+	// Каждая ссылка указывает на описанную схему
+	for _, match := range regexp.MustCompile(`"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(body, -1) {
+		if _, ok := doc.Components.Schemas[match[1]]; !ok {
+			t.Errorf("Unresolved schema reference %s", match[1])
+		}
+	}
+	if !strings.Contains(body, `"`+server.CodeCommandNotFound+`"`) {
+		t.Error("Expected error codes to be listed")
+	}
+}
@@ -0,0 +1,106 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"command-bot/internal/bot/quote"
+	"command-bot/internal/server"
+	"command-bot/pkg/transport"
+)
+
+func TestServerTransportSend(t *testing.T) {
+	srv := newWSServer(t)
+
+	conn, _, err := dialWS(t, srv, http.Header{"X-Api-Key": {"alice-key"}})
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	if ready := readWS(t, conn); ready.Type != server.WSTypeReady {
+		t.Fatalf("Expected ready message, got %+v", ready)
+	}
+
+	var sender transport.Sender = srv
+	ctx := context.Background()
+
+	id, err := sender.Send(ctx, "team", "build started")
+	if err != nil || id == "" {
+		t.Fatalf("Failed to send: %q %v", id, err)
+	}
+	msg := readWS(t, conn)
+	if n := msg.Notification; n == nil || n.Kind != server.NotificationMessage || n.ChatID != "team" || n.MessageID != id || n.Text != "build started" {
+		t.Errorf("Expected message notification %s, got %+v", id, n)
+	}
+
+	// Сервер не хранит сообщения, поэтому не может их изменить или удалить
+	if err := sender.Edit(ctx, "team", id, "build finished"); !errors.Is(err, transport.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported from Edit, got %v", err)
+	}
+	if err := sender.Delete(ctx, "team", id); !errors.Is(err, transport.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported from Delete, got %v", err)
+	}
+	if caps := srv.Capabilities(); !caps.Push || caps.Edit || caps.Delete || !caps.Stream {
+		t.Errorf("Unexpected capabilities: %+v", caps)
+	}
+}
+
+func TestServerTransportSendWithoutSubscribers(t *testing.T) {
+	srv := newWSServer(t)
+
+	// Сообщение чата, на который никто не подписан, не доставлено, и отправитель узнает об этом
+	id, err := srv.Send(context.Background(), "team", "nobody listens")
+	if !errors.Is(err, server.ErrNoSubscribers) || id != "" {
+		t.Errorf("Expected ErrNoSubscribers, got %q %v", id, err)
+	}
+
+	// Цитата дня, отправленная через сервер, сообщает о потере так же
+	if err := quote.PostTo(srv)(context.Background(), "team", "Quote of the day"); !errors.Is(err, server.ErrNoSubscribers) {
+		t.Errorf("Expected ErrNoSubscribers from the daily quote post, got %v", err)
+	}
+}
+
+func TestServerTransportReceive(t *testing.T) {
+	srv, _, _ := newServer(t)
+	handler := srv.Handler()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan transport.Message, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Receive(ctx, transport.HandlerFunc(func(ctx context.Context, msg transport.Message) (string, error) {
+			received <- msg
+			return "handled " + msg.Text, nil
+		}))
+	}()
+
+	// Обработчик устанавливается в горутине, поэтому ждем, пока он начнет получать команды
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, resp := sendCommand(t, handler, `{"command": "/echo hi", "chat_id": "ops"}`); strings.HasPrefix(resp.Response, "handled") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected commands to reach the installed handler")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	msg := <-received
+	if msg.Text != "/echo hi" || msg.ChatID != "ops" || msg.UserID == "" || msg.ID == "" || msg.Metadata["request_id"] != msg.ID {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Receive to stop with the context, got %v", err)
+	}
+
+	// После остановки команды снова выполняет обработчик команд
+	if code, resp := sendCommand(t, handler, `{"command": "/echo hi"}`); code != http.StatusOK || resp.Response != "hi" {
+		t.Errorf("Expected echo response after Receive stopped, got %d %+v", code, resp)
+	}
+}
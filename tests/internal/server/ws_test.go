@@ -0,0 +1,290 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"command-bot/internal/auth"
+	"command-bot/internal/config"
+	"command-bot/internal/server"
+)
+
+// dialWS подключается к /ws сервера srv. Ответ сервера возвращается и при
+// отказе в подключении, чтобы проверить его статус.
+func dialWS(t *testing.T, srv *server.Server, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+
+	httpServer := httptest.NewServer(srv.Handler())
+	t.Cleanup(httpServer.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/ws"
+	conn, resp, err := websocket.Dial(ctx, url, &websocket.DialOptions{HTTPHeader: header})
+	if err != nil {
+		return nil, resp, err
+	}
+	t.Cleanup(func() { conn.CloseNow() })
+	return conn, resp, nil
+}
+
+// readWSRaw читает следующее сообщение, ожидая не дольше 5 секунд
+func readWSRaw(conn *websocket.Conn) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, data, err := conn.Read(ctx)
+	return data, err
+}
+
+func sendWS(t *testing.T, conn *websocket.Conn, req server.WSRequest) {
+	t.Helper()
+
+	data, _ := json.Marshal(req)
+	if err := conn.Write(context.Background(), websocket.MessageText, data); err != nil {
+		t.Fatalf("Failed to send %s: %v", req.Type, err)
+	}
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) server.WSMessage {
+	t.Helper()
+
+	data, err := readWSRaw(conn)
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	var msg server.WSMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("Failed to decode %s: %v", data, err)
+	}
+	return msg
+}
+
+func newWSServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	return newWSServerWith(t, func(*config.Config) {})
+}
+
+// newWSServerWith создает сервер с ключом alice-key, измененный функцией configure
+func newWSServerWith(t *testing.T, configure func(cfg *config.Config)) *server.Server {
+	t.Helper()
+
+	srv, _, _ := newServerWith(t, func(cfg *config.Config) {
+		cfg.RateLimit.Burst = 10
+		cfg.Auth.APIKeys = map[string]config.APIKeyConfig{
+			"alice": {SHA256: auth.HashAPIKey("alice-key"), UserID: "alice", Chats: []string{"team"}},
+		}
+		configure(cfg)
+	})
+	return srv
+}
+
+func TestWebSocketSession(t *testing.T) {
+	srv := newWSServer(t)
+
+	// Учетные данные можно передать первым сообщением, если клиент не может задать заголовки
+	conn, _, err := dialWS(t, srv, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	sendWS(t, conn, server.WSRequest{Type: server.WSTypeAuth, APIKey: "alice-key"})
+
+	ready := readWS(t, conn)
+	if ready.Type != server.WSTypeReady || ready.UserID != "alice" || !slices.Equal(ready.Chats, []string{"team"}) {
+		t.Fatalf("Expected ready message for alice, got %+v", ready)
+	}
+
+	sendWS(t, conn, server.WSRequest{Type: server.WSTypeCommand, ID: "1", CommandRequest: server.CommandRequest{Command: "/echo {user} {chat}"}})
+	if msg := readWS(t, conn); msg.Type != server.WSTypeResponse || msg.ID != "1" || msg.Status != http.StatusOK || msg.Response != "alice team" {
+		t.Errorf("Expected echo response, got %+v", msg)
+	}
+
+	sendWS(t, conn, server.WSRequest{Type: server.WSTypeCommand, ID: "2", CommandRequest: server.CommandRequest{Command: "/ech"}})
+	if msg := readWS(t, conn); msg.ID != "2" || msg.Status != http.StatusNotFound || msg.Error == nil || msg.Error.Code != server.CodeCommandNotFound {
+		t.Errorf("Expected command_not_found, got %+v", msg)
+	}
+
+	sendWS(t, conn, server.WSRequest{Type: server.WSTypeSubscribe, ID: "3", Chats: []string{"other"}})
+	if msg := readWS(t, conn); msg.ID != "3" || msg.Error == nil || msg.Error.Code != server.CodeForbidden {
+		t.Errorf("Expected subscription to a foreign chat to be refused, got %+v", msg)
+	}
+
+	if n := srv.Notify(server.Notification{ChatID: "other", Kind: "alert", Text: "not for alice"}); n != 0 {
+		t.Errorf("Expected no sessions for chat other, got %d", n)
+	}
+	if n := srv.Notify(server.Notification{ChatID: "team", Kind: "alert", Text: "deploy finished"}); n != 1 {
+		t.Errorf("Expected notification to reach one session, got %d", n)
+	}
+	msg := readWS(t, conn)
+	if msg.Type != server.WSTypeNotification || msg.Notification == nil || msg.Notification.Text != "deploy finished" {
+		t.Errorf("Expected notification, got %+v", msg)
+	}
+
+	// Остановка сервиса закрывает сессии. Закрытие ждет ответа клиента,
+	// поэтому клиент читает одновременно с остановкой.
+	done := make(chan struct{})
+	go func() {
+		srv.Shutdown(context.Background())
+		close(done)
+	}()
+	if _, err := readWSRaw(conn); websocket.CloseStatus(err) != websocket.StatusGoingAway {
+		t.Errorf("Expected close with code 1001 on shutdown, got %v", err)
+	}
+	<-done
+}
+
+func TestWebSocketAuthentication(t *testing.T) {
+	srv := newWSServer(t)
+
+	if _, resp, err := dialWS(t, srv, http.Header{auth.APIKeyHeader: {"wrong"}}); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for invalid key, got %v", err)
+	}
+
+	conn, _, err := dialWS(t, srv, http.Header{auth.APIKeyHeader: {"alice-key"}})
+	if err != nil {
+		t.Fatalf("Failed to dial with API key: %v", err)
+	}
+	if msg := readWS(t, conn); msg.Type != server.WSTypeReady || msg.UserID != "alice" {
+		t.Errorf("Expected ready message, got %+v", msg)
+	}
+
+	conn, _, err = dialWS(t, srv, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	sendWS(t, conn, server.WSRequest{Type: server.WSTypeCommand, CommandRequest: server.CommandRequest{Command: "/ping"}})
+	if msg := readWS(t, conn); msg.Error == nil || msg.Error.Code != server.CodeUnauthenticated {
+		t.Errorf("Expected unauthenticated error, got %+v", msg)
+	}
+	if _, err := readWSRaw(conn); websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Errorf("Expected close with code 1008, got %v", err)
+	}
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	srv := newWSServer(t)
+	header := http.Header{auth.APIKeyHeader: {"alice-key"}, "Origin": {"https://evil.example"}}
+
+	// Страница чужого сайта не может открыть сессию даже с учетными данными
+	if _, resp, err := dialWS(t, srv, header); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a foreign origin, got %v", err)
+	}
+
+	srv, _, _ = newServerWith(t, func(cfg *config.Config) {
+		cfg.Server.WebSocket.AllowedOrigins = []string{"*.example"}
+	})
+	conn, _, err := dialWS(t, srv, header)
+	if err != nil {
+		t.Fatalf("Expected an allowed origin to connect, got %v", err)
+	}
+	if msg := readWS(t, conn); msg.Type != server.WSTypeReady {
+		t.Errorf("Expected ready message, got %+v", msg)
+	}
+}
+
+func TestWebSocketInvalidUTF8(t *testing.T) {
+	srv := newWSServer(t)
+
+	conn, _, err := dialWS(t, srv, http.Header{auth.APIKeyHeader: {"alice-key"}})
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	if msg := readWS(t, conn); msg.Type != server.WSTypeReady {
+		t.Fatalf("Expected ready message, got %+v", msg)
+	}
+
+	if err := conn.Write(context.Background(), websocket.MessageText, []byte("{\"type\":\"\xff\"}")); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	if _, err := readWSRaw(conn); websocket.CloseStatus(err) != websocket.StatusInvalidFramePayloadData {
+		t.Errorf("Expected close with code 1007 for invalid UTF-8, got %v", err)
+	}
+}
+
+func TestWebSocketMaxInFlight(t *testing.T) {
+	release := make(chan struct{})
+	weather := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer weather.Close()
+
+	srv := newWSServerWith(t, func(cfg *config.Config) {
+		cfg.Server.WebSocket.MaxInFlight = 1
+		cfg.Commands.Weather.GeocodingURL = weather.URL
+		cfg.Commands.Weather.ForecastURL = weather.URL
+	})
+	conn, _, err := dialWS(t, srv, http.Header{auth.APIKeyHeader: {"alice-key"}})
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	if msg := readWS(t, conn); msg.Type != server.WSTypeReady {
+		t.Fatalf("Expected ready message, got %+v", msg)
+	}
+
+	// Первая команда занимает единственное место, пока Open-Meteo не ответит
+	sendWS(t, conn, server.WSRequest{Type: server.WSTypeCommand, ID: "1", CommandRequest: server.CommandRequest{Command: "/weather Oslo"}})
+	sendWS(t, conn, server.WSRequest{Type: server.WSTypeCommand, ID: "2", CommandRequest: server.CommandRequest{Command: "/echo hi"}})
+	if msg := readWS(t, conn); msg.ID != "2" || msg.Status != http.StatusTooManyRequests || msg.Error == nil || msg.Error.Code != server.CodeTooManyInFlight {
+		t.Fatalf("Expected too_many_in_flight, got %+v", msg)
+	}
+
+	close(release)
+	if msg := readWS(t, conn); msg.ID != "1" || msg.Status != http.StatusBadGateway {
+		t.Fatalf("Expected the first command to finish, got %+v", msg)
+	}
+
+	// Освободившееся место снова доступно
+	sendWS(t, conn, server.WSRequest{Type: server.WSTypeCommand, ID: "3", CommandRequest: server.CommandRequest{Command: "/echo hi"}})
+	if msg := readWS(t, conn); msg.ID != "3" || msg.Status != http.StatusOK || msg.Response != "hi" {
+		t.Errorf("Expected echo response, got %+v", msg)
+	}
+}
+
+func TestWebSocketSendBufferOverflow(t *testing.T) {
+	srv := newWSServerWith(t, func(cfg *config.Config) {
+		cfg.Server.WebSocket.SendBuffer = 1
+	})
+	conn, _, err := dialWS(t, srv, http.Header{auth.APIKeyHeader: {"alice-key"}})
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	if msg := readWS(t, conn); msg.Type != server.WSTypeReady {
+		t.Fatalf("Expected ready message, got %+v", msg)
+	}
+
+	// Клиент не читает: когда буферы соединения заполнятся, очередь
+	// переполнится и сервер закроет сессию
+	text := strings.Repeat("x", 16<<10)
+	overflowed := false
+	for i := 0; i < 10000 && !overflowed; i++ {
+		overflowed = srv.Notify(server.Notification{ChatID: "team", Kind: "alert", Text: text}) == 0
+	}
+	if !overflowed {
+		t.Fatal("Expected the send buffer to overflow")
+	}
+
+	for {
+		_, err := readWSRaw(conn)
+		if err == nil {
+			continue
+		}
+		if websocket.CloseStatus(err) != websocket.StatusTryAgainLater {
+			t.Errorf("Expected close with code 1013 for a slow client, got %v", err)
+		}
+		break
+	}
+}
@@ -0,0 +1,146 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"command-bot/internal/tracing"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	sc, err := tracing.ParseTraceparent(traceparent)
+	if err != nil {
+		t.Fatalf("Failed to parse traceparent: %v", err)
+	}
+
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.Sampled {
+		t.Errorf("Unexpected span context: %+v", sc)
+	}
+	if got := tracing.FormatTraceparent(sc); got != traceparent {
+		t.Errorf("Expected %s, got %s", traceparent, got)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+	} {
+		if _, err := tracing.ParseTraceparent(bad); err == nil {
+			t.Errorf("Expected error for %q, got nil", bad)
+		}
+	}
+}
+
+func TestSpansFormTree(t *testing.T) {
+	exporter := &tracing.RecordingExporter{}
+	tracer := tracing.NewTracer(exporter)
+
+	remote, _ := tracing.ParseTraceparent(traceparent)
+	ctx := tracing.ContextWithRemoteSpanContext(context.Background(), remote)
+
+	ctx, root := tracer.Start(ctx, "root")
+	_, child := tracer.Start(ctx, "child", tracing.String("key", "value"))
+	child.RecordError(errors.New("boom"))
+	child.End()
+	root.End()
+	root.End() // повторное завершение игнорируется
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	childData, rootData := spans[0], spans[1]
+	if rootData.TraceID != remote.TraceID || rootData.ParentSpanID != remote.SpanID {
+		t.Errorf("Expected root to continue the remote trace, got %+v", rootData)
+	}
+	if childData.TraceID != remote.TraceID || childData.ParentSpanID != rootData.SpanID {
+		t.Errorf("Expected child of root, got %+v", childData)
+	}
+	if childData.Error != "boom" || childData.Attributes["key"] != "value" {
+		t.Errorf("Expected error and attribute on child, got %+v", childData)
+	}
+}
+
+func TestNilTracerIsNoop(t *testing.T) {
+	var tracer *tracing.Tracer
+
+	ctx, span := tracer.Start(context.Background(), "noop")
+	span.SetAttributes(tracing.Int("n", 1))
+	span.RecordError(errors.New("ignored"))
+	span.End()
+
+	if tracing.SpanFromContext(ctx) != nil {
+		t.Error("Expected no span in context")
+	}
+	if tracer.Transport(nil) != http.DefaultTransport {
+		t.Error("Expected default transport without tracing")
+	}
+}
+
+func TestTransportPropagatesContext(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(tracing.TraceparentHeader)
+	}))
+	defer server.Close()
+
+	exporter := &tracing.RecordingExporter{}
+	tracer := tracing.NewTracer(exporter)
+	client := &http.Client{Transport: tracer.Transport(nil)}
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1/search?name=secret", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	root.End()
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	httpSpan := spans[0]
+	sc, err := tracing.ParseTraceparent(received)
+	if err != nil {
+		t.Fatalf("Expected traceparent header on outbound request, got %q", received)
+	}
+	if sc.SpanID != httpSpan.SpanID || httpSpan.ParentSpanID != root.SpanContext().SpanID {
+		t.Errorf("Expected outbound span to be propagated, got %+v", httpSpan)
+	}
+	if httpSpan.Attributes["http.path"] != "/v1/search" || httpSpan.Attributes["http.status_code"] != http.StatusOK {
+		t.Errorf("Unexpected attributes: %v", httpSpan.Attributes)
+	}
+}
+
+func TestWriterExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := tracing.NewTracer(tracing.NewWriterExporter(&buf))
+
+	_, span := tracer.Start(context.Background(), "op", tracing.String("k", "v"))
+	span.RecordError(errors.New("failed"))
+	span.End()
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", buf.String(), err)
+	}
+	if record["name"] != "op" || record["status"] != "error" || record["error"] != "failed" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+	if _, ok := record["parent_span_id"]; ok {
+		t.Error("Expected no parent for a root span")
+	}
+}